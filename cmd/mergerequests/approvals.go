@@ -0,0 +1,114 @@
+package mergerequests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"mpg-gitlab/cmd/types"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// GetApprovals returns the approval state of a merge request
+// Approval rules are only available on some GitLab tiers; without them the
+// state is built from the overall approval counts.
+func GetApprovals(projectID, mrIID int) (*types.Approvals, error) {
+	config, _, err := client.MergeRequestApprovals.GetConfiguration(projectID, mrIID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approvals: %v", err)
+	}
+
+	state, resp, err := client.MergeRequestApprovals.GetApprovalState(projectID, mrIID)
+	if err != nil {
+		if resp == nil || (resp.StatusCode != http.StatusNotFound && resp.StatusCode != http.StatusForbidden) {
+			return nil, fmt.Errorf("failed to get approval rules: %v", err)
+		}
+		state = nil
+	}
+
+	return convertApprovals(config, state), nil
+}
+
+// convertApprovals converts GitLab approval data to our type
+func convertApprovals(config *gitlab.MergeRequestApprovals, state *gitlab.MergeRequestApprovalState) *types.Approvals {
+	approvals := &types.Approvals{
+		Approved:   config.Approved,
+		Required:   config.ApprovalsRequired,
+		Left:       config.ApprovalsLeft,
+		ApprovedBy: []string{},
+		Rules:      []types.ApprovalRule{},
+	}
+	for _, a := range config.ApprovedBy {
+		if a.User != nil {
+			approvals.ApprovedBy = append(approvals.ApprovedBy, a.User.Username)
+		}
+	}
+
+	if state == nil {
+		return approvals
+	}
+	for _, r := range state.Rules {
+		approvals.Rules = append(approvals.Rules, types.ApprovalRule{
+			Name:              r.Name,
+			Type:              r.RuleType,
+			Required:          r.ApprovalsRequired,
+			Approved:          r.Approved,
+			ApprovedBy:        usernames(r.ApprovedBy),
+			EligibleApprovers: usernames(r.EligibleApprovers),
+		})
+	}
+	return approvals
+}
+
+// usernames returns the usernames of a list of users
+func usernames(users []*gitlab.BasicUser) []string {
+	names := []string{}
+	for _, u := range users {
+		names = append(names, u.Username)
+	}
+	return names
+}
+
+// ApproveMergeRequest approves a merge request as the current user
+// A non-empty sha must match the MR head, so a reviewed state can't be swapped out
+func ApproveMergeRequest(projectID, mrIID int, sha string) (*types.Approvals, error) {
+	opts := &gitlab.ApproveMergeRequestOptions{}
+	if sha != "" {
+		opts.SHA = gitlab.String(sha)
+	}
+
+	config, resp, err := client.MergeRequestApprovals.ApproveMergeRequest(projectID, mrIID, opts)
+	if err != nil {
+		if sha != "" && resp != nil && resp.StatusCode == http.StatusConflict {
+			return nil, fmt.Errorf("merge request head no longer matches %s", shortSHA(sha))
+		}
+		return nil, fmt.Errorf("failed to approve merge request: %v", err)
+	}
+
+	return convertApprovals(config, nil), nil
+}
+
+// UnapproveMergeRequest removes the current user's approval from a merge request
+func UnapproveMergeRequest(projectID, mrIID int) error {
+	if _, err := client.MergeRequestApprovals.UnapproveMergeRequest(projectID, mrIID); err != nil {
+		return fmt.Errorf("failed to unapprove merge request: %v", err)
+	}
+	return nil
+}
+
+// FormatApprovals renders an approval summary like "1/2 approvals (alice)"
+func FormatApprovals(a *types.Approvals) string {
+	given := len(a.ApprovedBy)
+	summary := fmt.Sprintf("%d/%d approvals", given, a.Required)
+	if a.Required == 0 {
+		summary = fmt.Sprintf("%d approvals, none required", given)
+	}
+	if given > 0 {
+		summary += fmt.Sprintf(" (%s)", strings.Join(a.ApprovedBy, ", "))
+	}
+	if a.Approved {
+		summary += ", approved"
+	}
+	return summary
+}
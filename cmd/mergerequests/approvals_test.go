@@ -0,0 +1,87 @@
+package mergerequests
+
+import (
+	"reflect"
+	"testing"
+
+	"mpg-gitlab/cmd/types"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestConvertApprovals(t *testing.T) {
+	config := &gitlab.MergeRequestApprovals{
+		Approved:          false,
+		ApprovalsRequired: 2,
+		ApprovalsLeft:     1,
+		ApprovedBy: []*gitlab.MergeRequestApproverUser{
+			{User: &gitlab.BasicUser{Username: "alice"}},
+		},
+	}
+	state := &gitlab.MergeRequestApprovalState{
+		Rules: []*gitlab.MergeRequestApprovalRule{
+			{
+				Name:              "Backend",
+				RuleType:          "regular",
+				ApprovalsRequired: 2,
+				ApprovedBy:        []*gitlab.BasicUser{{Username: "alice"}},
+				EligibleApprovers: []*gitlab.BasicUser{{Username: "alice"}, {Username: "bob"}},
+			},
+		},
+	}
+
+	want := &types.Approvals{
+		Required:   2,
+		Left:       1,
+		ApprovedBy: []string{"alice"},
+		Rules: []types.ApprovalRule{
+			{
+				Name:              "Backend",
+				Type:              "regular",
+				Required:          2,
+				ApprovedBy:        []string{"alice"},
+				EligibleApprovers: []string{"alice", "bob"},
+			},
+		},
+	}
+
+	if got := convertApprovals(config, state); !reflect.DeepEqual(got, want) {
+		t.Errorf("convertApprovals() = %+v, want %+v", got, want)
+	}
+
+	if got := convertApprovals(config, nil); len(got.Rules) != 0 || got.Rules == nil {
+		t.Errorf("convertApprovals() without rules = %+v, want empty rules", got.Rules)
+	}
+}
+
+func TestFormatApprovals(t *testing.T) {
+	tests := []struct {
+		name      string
+		approvals *types.Approvals
+		want      string
+	}{
+		{
+			name:      "pending",
+			approvals: &types.Approvals{Required: 2, Left: 1, ApprovedBy: []string{"alice"}},
+			want:      "1/2 approvals (alice)",
+		},
+		{
+			name:      "approved",
+			approvals: &types.Approvals{Approved: true, Required: 1, ApprovedBy: []string{"alice", "bob"}},
+			want:      "2/1 approvals (alice, bob), approved",
+		},
+		{
+			name:      "none required",
+			approvals: &types.Approvals{Approved: true},
+			want:      "0 approvals, none required, approved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatApprovals(tt.approvals); got != tt.want {
+				t.Errorf("FormatApprovals() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,9 +2,86 @@ package mergerequests
 
 import (
 	"fmt"
+	"log"
 	"strings"
+
+	"github.com/xanzy/go-gitlab"
 )
 
+// blockedPrefix starts the note left when a merge request is blocked
+const blockedPrefix = "🚫 **Merge Blocked**:"
+
+// BlockMergeRequest blocks a merge request with a note explaining why and a [BLOCKED] title prefix
+func BlockMergeRequest(projectID, mrIID int, reason string) error {
+	return blockMergeRequest(projectID, mrIID, reason, "")
+}
+
+// blockMergeRequest blocks a merge request, tagging the note with the marker of a gate
+// when set, so the gate can later tell its own block from a human one
+func blockMergeRequest(projectID, mrIID int, reason, gate string) error {
+	// First add a blocking note
+	body := fmt.Sprintf("%s %s", blockedPrefix, reason)
+	if gate != "" {
+		body += "\n" + stickyMarker(gate)
+	}
+	noteOpts := &gitlab.CreateMergeRequestNoteOptions{
+		Body: gitlab.String(body),
+	}
+	_, _, err := client.Notes.CreateMergeRequestNote(projectID, mrIID, noteOpts)
+	if err != nil {
+		log.Printf("Warning: Failed to add blocking note: %v", err)
+	}
+
+	// Then update the MR title to indicate it's blocked
+	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get merge request: %v", err)
+	}
+
+	// Add [BLOCKED] prefix if not already present
+	title := mr.Title
+	if !strings.HasPrefix(title, "[BLOCKED]") {
+		title = "[BLOCKED] " + title
+	}
+
+	updateOpts := &gitlab.UpdateMergeRequestOptions{
+		Title: gitlab.String(title),
+	}
+	if _, _, err := client.MergeRequests.UpdateMergeRequest(projectID, mrIID, updateOpts); err != nil {
+		return fmt.Errorf("failed to update merge request: %v", err)
+	}
+
+	return nil
+}
+
+// UnblockMergeRequest removes the [BLOCKED] title prefix and notes that the MR is unblocked
+func UnblockMergeRequest(projectID, mrIID int) error {
+	// Add unblocking note
+	noteOpts := &gitlab.CreateMergeRequestNoteOptions{
+		Body: gitlab.String("✅ **Merge Unblocked**"),
+	}
+	_, _, err := client.Notes.CreateMergeRequestNote(projectID, mrIID, noteOpts)
+	if err != nil {
+		log.Printf("Warning: Failed to add unblocking note: %v", err)
+	}
+
+	// Remove [BLOCKED] prefix from title
+	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get merge request: %v", err)
+	}
+
+	title := strings.TrimPrefix(mr.Title, "[BLOCKED] ")
+	updateOpts := &gitlab.UpdateMergeRequestOptions{
+		Title: gitlab.String(title),
+	}
+	if _, _, err := client.MergeRequests.UpdateMergeRequest(projectID, mrIID, updateOpts); err != nil {
+		return fmt.Errorf("failed to update merge request: %v", err)
+	}
+
+	return nil
+}
+
 // IsBlocked checks if a merge request is blocked
 func IsBlocked(projectID, mrIID int) (bool, error) {
 	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
@@ -17,21 +94,51 @@ func IsBlocked(projectID, mrIID int) (bool, error) {
 
 // GetBlockReason returns the reason why a merge request is blocked
 func GetBlockReason(projectID, mrIID int) (string, error) {
-	notes, _, err := client.Notes.ListMergeRequestNotes(projectID, mrIID, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to get merge request notes: %v", err)
+	note, err := latestBlockNote(projectID, mrIID)
+	if err != nil || note == nil {
+		return "", err
+	}
+	return blockNoteReason(note.Body), nil
+}
+
+// blockedByGate reports whether the newest blocking note of a merge request was left by a gate
+func blockedByGate(projectID, mrIID int, gate string) (bool, error) {
+	note, err := latestBlockNote(projectID, mrIID)
+	if err != nil || note == nil {
+		return false, err
+	}
+	return strings.Contains(note.Body, stickyMarker(gate)), nil
+}
+
+// latestBlockNote returns the newest blocking note of a merge request, or nil
+func latestBlockNote(projectID, mrIID int) (*gitlab.Note, error) {
+	opts := &gitlab.ListMergeRequestNotesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+		OrderBy:     gitlab.String("created_at"),
+		Sort:        gitlab.String("desc"),
 	}
 
-	// Look for the most recent blocking note
-	for i := len(notes) - 1; i >= 0; i-- {
-		if strings.Contains(notes[i].Body, "🚫 **Merge Blocked**:") {
-			parts := strings.SplitN(notes[i].Body, ":", 2)
-			if len(parts) == 2 {
-				return strings.TrimSpace(parts[1]), nil
+	for {
+		notes, resp, err := client.Notes.ListMergeRequestNotes(projectID, mrIID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get merge request notes: %v", err)
+		}
+		for _, note := range notes {
+			if strings.Contains(note.Body, blockedPrefix) {
+				return note, nil
 			}
-			return "", nil
 		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
 	}
+}
 
-	return "", nil
-} 
\ No newline at end of file
+// blockNoteReason extracts the reason from a blocking note, leaving out any gate marker
+func blockNoteReason(body string) string {
+	reason := body[strings.Index(body, blockedPrefix)+len(blockedPrefix):]
+	reason, _, _ = strings.Cut(reason, "<!--")
+	return strings.TrimSpace(reason)
+}
@@ -10,8 +10,41 @@ import (
 const (
 	// ChangelogError is returned when no valid changelog entry is found
 	ChangelogError = "No valid changelog entry found"
+
+	// noChangelogCategory marks an MR that intentionally has no changelog entry
+	noChangelogCategory = "No-Changelog-Entry"
+)
+
+var (
+	// changelogCategories lists the release note categories, in rendering order
+	changelogCategories = []string{"Feature", "Improvement", "Fix", "Infra"}
+
+	// changelogPattern matches a changelog entry: a category tag followed by its text
+	changelogPattern = regexp.MustCompile(`(?i)\[(` + strings.Join(allChangelogCategories(), "|") + `)\].*`)
 )
 
+// allChangelogCategories returns every category a changelog entry may use
+func allChangelogCategories() []string {
+	return append(append([]string{}, changelogCategories...), noChangelogCategory)
+}
+
+// ChangelogCategories returns the release note categories, in rendering order
+func ChangelogCategories() []string {
+	return append([]string{}, changelogCategories...)
+}
+
+// normalizeChangelogCategory returns the canonical spelling of a changelog category
+// It returns an empty string for unknown categories
+func normalizeChangelogCategory(category string) string {
+	category = strings.Trim(strings.TrimSpace(category), "[]")
+	for _, c := range allChangelogCategories() {
+		if strings.EqualFold(c, category) {
+			return c
+		}
+	}
+	return ""
+}
+
 // cleanDescription removes common formatting and noise from text
 func cleanDescription(text string) string {
 	// Remove code blocks
@@ -83,9 +116,7 @@ func GetChangelogEntries(projectID, mrIID int) (string, error) {
 
 // findChangelogEntry extracts changelog entry from text
 func findChangelogEntry(text string) string {
-	// Case insensitive regex for changelog entries
-	re := regexp.MustCompile(`(?i)\[(feature|improvement|fix|infra|no-changelog-entry)\].*`)
-	match := re.FindString(text)
+	match := changelogPattern.FindString(text)
 	if match == "" {
 		return ""
 	}
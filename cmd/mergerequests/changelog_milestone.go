@@ -5,45 +5,98 @@ import (
 	"sort"
 	"strings"
 
+	"mpg-gitlab/cmd/types"
+
 	"github.com/xanzy/go-gitlab"
 )
 
 // AddChangelogToMilestone adds changelog entry from merge request to its milestone
 func AddChangelogToMilestone(projectID, mrIID int) error {
+	milestone, entry, err := milestoneChangelogEntry(projectID, mrIID)
+	if err != nil {
+		return err
+	}
+
+	// Update milestone description with sorted entries
+	return addSortedEntryToMilestone(projectID, milestone, entry)
+}
+
+// milestoneChangelogEntry returns the milestone of a merge request and its changelog entry
+func milestoneChangelogEntry(projectID, mrIID int) (*gitlab.Milestone, string, error) {
 	// Get the MR first
 	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get merge request: %v", err)
+		return nil, "", fmt.Errorf("failed to get merge request: %v", err)
 	}
 
 	// Check if MR has milestone
 	if mr.Milestone == nil {
-		return fmt.Errorf("merge request #%d has no milestone assigned", mrIID)
+		return nil, "", fmt.Errorf("merge request #%d has no milestone assigned", mrIID)
 	}
 
-	// Get milestone
-	milestone, _, err := client.Milestones.GetMilestone(projectID, mr.Milestone.ID, nil)
+	// Get milestone, which belongs to a group or to the project
+	milestone, err := getMilestone(projectID, mr.Milestone)
 	if err != nil {
-		return fmt.Errorf("failed to get milestone: %v", err)
+		return nil, "", err
 	}
 
 	// Get changelog entry from MR
 	entry, err := GetChangelogEntries(projectID, mrIID)
 	if err != nil {
-		return fmt.Errorf("failed to get changelog entry: %v", err)
+		return nil, "", fmt.Errorf("failed to get changelog entry: %v", err)
 	}
 	if entry == ChangelogError {
-		return fmt.Errorf("no changelog entry found in MR #%d", mrIID)
+		return nil, "", fmt.Errorf("no changelog entry found in MR #%d", mrIID)
 	}
 
-	// Update milestone description with sorted entries
-	return addSortedEntryToMilestone(projectID, milestone, entry)
+	return milestone, entry, nil
 }
 
 // addSortedEntryToMilestone adds a changelog entry to the milestone description
 // organizing entries by category and ensuring uniqueness by MR ID
 func addSortedEntryToMilestone(projectID int, milestone *gitlab.Milestone, entry string) error {
-	description := milestone.Description
+	description, err := sortedChangelogDescription(milestone.Description, entry)
+	if err != nil {
+		return err
+	}
+
+	// Update milestone
+	if milestone.GroupID != 0 {
+		_, _, err = client.GroupMilestones.UpdateGroupMilestone(milestone.GroupID, milestone.ID, &gitlab.UpdateGroupMilestoneOptions{
+			Description: gitlab.String(description),
+		})
+	} else {
+		_, _, err = client.Milestones.UpdateMilestone(projectID, milestone.ID, &gitlab.UpdateMilestoneOptions{
+			Description: gitlab.String(description),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update milestone: %v", err)
+	}
+
+	return nil
+}
+
+// getMilestone fetches the full milestone of a merge request, from its group for group milestones
+func getMilestone(projectID int, m *gitlab.Milestone) (*gitlab.Milestone, error) {
+	if m.GroupID == 0 {
+		milestone, _, err := client.Milestones.GetMilestone(projectID, m.ID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get milestone: %v", err)
+		}
+		return milestone, nil
+	}
+
+	gm, _, err := client.GroupMilestones.GetGroupMilestone(m.GroupID, m.ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group milestone: %v", err)
+	}
+	return types.FromGroupMilestone(gm), nil
+}
+
+// sortedChangelogDescription returns the description with a changelog entry added
+// to its category, replacing any earlier entry of the same MR
+func sortedChangelogDescription(description, entry string) (string, error) {
 	if description == "" {
 		description = "## Changelog\n"
 	}
@@ -60,15 +113,13 @@ func addSortedEntryToMilestone(projectID int, milestone *gitlab.Milestone, entry
 		entry = strings.TrimSpace(parts[1])
 	}
 	if mrID == "" {
-		return fmt.Errorf("invalid changelog entry format, missing MR ID: %s", entry)
+		return "", fmt.Errorf("invalid changelog entry format, missing MR ID: %s", entry)
 	}
 
 	// Split description into sections
-	sections := map[string][]string{
-		"Feature":     {},
-		"Improvement": {},
-		"Fix":        {},
-		"Infra":      {},
+	sections := make(map[string][]string)
+	for _, category := range changelogCategories {
+		sections[category] = []string{}
 	}
 
 	// Extract existing entries by category
@@ -100,7 +151,7 @@ func addSortedEntryToMilestone(projectID int, milestone *gitlab.Milestone, entry
 	newDesc.WriteString("## Changelog\n\n")
 
 	// Add each category and its entries
-	for _, category := range []string{"Feature", "Improvement", "Fix", "Infra"} {
+	for _, category := range changelogCategories {
 		entries := sections[category]
 		if len(entries) > 0 {
 			newDesc.WriteString(fmt.Sprintf("### [%s]\n", category))
@@ -112,13 +163,5 @@ func addSortedEntryToMilestone(projectID int, milestone *gitlab.Milestone, entry
 		}
 	}
 
-	// Update milestone
-	_, _, err := client.Milestones.UpdateMilestone(projectID, milestone.ID, &gitlab.UpdateMilestoneOptions{
-		Description: gitlab.String(newDesc.String()),
-	})
-	if err != nil {
-		return fmt.Errorf("failed to update milestone: %v", err)
-	}
-
-	return nil
-} 
\ No newline at end of file
+	return newDesc.String(), nil
+}
@@ -0,0 +1,19 @@
+package mergerequests
+
+import "testing"
+
+func TestSortedChangelogDescription(t *testing.T) {
+	description := "## Changelog\n\n### [Fix]\n- [Fix] Old wording (#12)\n- [Fix] Crash (#3)\n"
+	got, err := sortedChangelogDescription(description, "#12: [Feature] Dark mode")
+	if err != nil {
+		t.Fatalf("sortedChangelogDescription() error = %v", err)
+	}
+	want := "## Changelog\n\n### [Feature]\n- [Feature] Dark mode (#12)\n\n### [Fix]\n- [Fix] Crash (#3)\n\n"
+	if got != want {
+		t.Errorf("sortedChangelogDescription() = %q, want %q", got, want)
+	}
+
+	if _, err := sortedChangelogDescription("", "[Fix] No reference"); err == nil {
+		t.Error("sortedChangelogDescription() without an MR ID should fail")
+	}
+}
@@ -0,0 +1,310 @@
+package mergerequests
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	// DefaultPolicyPath is where the changelog policy is looked up, locally or in the project repository
+	DefaultPolicyPath = ".gitlab/changelog-policy.json"
+
+	// changelogCheckNote identifies the sticky changelog check comment
+	changelogCheckNote = "changelog-check"
+)
+
+// ChangelogPolicy maps the paths and labels of a merge request to changelog requirements
+type ChangelogPolicy struct {
+	// Default lists the categories accepted when no rule sets requirements
+	Default []string `json:"default,omitempty"`
+	// Rules are evaluated in order; the first matching exempt rule wins,
+	// otherwise every matching rule's requirement must be satisfied
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule applies changelog requirements to matching merge requests
+type PolicyRule struct {
+	Name   string   `json:"name"`
+	Paths  []string `json:"paths,omitempty"`  // Path globs; "**" matches any number of directories
+	Match  string   `json:"match,omitempty"`  // "any" (default) or "all" changed files must match Paths
+	Labels []string `json:"labels,omitempty"` // The MR must carry at least one of these labels
+	Exempt bool     `json:"exempt,omitempty"` // Matching MRs need no changelog entry
+	// Require lists the categories of which one must be present, e.g. ["Infra"]
+	// or ["No-Changelog-Entry", "Infra"] to let dependency bumps skip the changelog
+	Require []string `json:"require,omitempty"`
+}
+
+// ChangelogEntry is a changelog entry found on a merge request or one of its issues
+type ChangelogEntry struct {
+	Source   string `json:"source"` // "!12" for the MR, "#34" for an issue
+	Category string `json:"category"`
+	Text     string `json:"text"`
+	URL      string `json:"url,omitempty"` // Web URL of the MR or issue holding the entry
+}
+
+// PolicyResult is the outcome of a changelog policy evaluation
+type PolicyResult struct {
+	Passed      bool             `json:"passed"`
+	Exempt      bool             `json:"exempt"`
+	Entries     []ChangelogEntry `json:"entries"`
+	Explanation []string         `json:"explanation"`
+}
+
+// DefaultChangelogPolicy is used when a project has no policy file
+// It requires a changelog entry on every merge request, accepting [No-Changelog-Entry]
+// as an explicit opt-out
+func DefaultChangelogPolicy() *ChangelogPolicy {
+	return &ChangelogPolicy{Default: allChangelogCategories()}
+}
+
+// ParseChangelogPolicy parses and validates a JSON changelog policy
+func ParseChangelogPolicy(data []byte) (*ChangelogPolicy, error) {
+	policy := &ChangelogPolicy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid changelog policy: %v", err)
+	}
+
+	if len(policy.Default) == 0 {
+		policy.Default = allChangelogCategories()
+	}
+	if err := normalizeCategories(policy.Default); err != nil {
+		return nil, fmt.Errorf("invalid changelog policy default: %v", err)
+	}
+
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if len(rule.Paths) == 0 && len(rule.Labels) == 0 {
+			return nil, fmt.Errorf("changelog policy %s: needs paths or labels", rule.Name)
+		}
+		if rule.Match != "" && rule.Match != "any" && rule.Match != "all" {
+			return nil, fmt.Errorf("changelog policy %s: match must be any or all, got %q", rule.Name, rule.Match)
+		}
+		if rule.Exempt == (len(rule.Require) > 0) {
+			return nil, fmt.Errorf("changelog policy %s: needs either exempt or require", rule.Name)
+		}
+		if err := normalizeCategories(rule.Require); err != nil {
+			return nil, fmt.Errorf("changelog policy %s: %v", rule.Name, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// normalizeCategories rewrites categories to their canonical spelling
+func normalizeCategories(categories []string) error {
+	for i, c := range categories {
+		normalized := normalizeChangelogCategory(c)
+		if normalized == "" {
+			return fmt.Errorf("unknown changelog category %q", c)
+		}
+		categories[i] = normalized
+	}
+	return nil
+}
+
+// LoadChangelogPolicy reads the changelog policy from a local file or the project repository
+// The path, DefaultPolicyPath when empty, is looked up locally, then in the repository
+// at ref. Without an explicit path DefaultChangelogPolicy is used when neither exists.
+func LoadChangelogPolicy(projectID int, policyPath, ref string) (*ChangelogPolicy, string, error) {
+	explicit := policyPath != ""
+	if !explicit {
+		policyPath = DefaultPolicyPath
+	}
+
+	data, found, err := utils.ReadConfigFile(projectID, policyPath, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	if !found {
+		if explicit {
+			return nil, "", fmt.Errorf("changelog policy %s not found", policyPath)
+		}
+		return DefaultChangelogPolicy(), "built-in default", nil
+	}
+
+	policy, err := ParseChangelogPolicy(data)
+	return policy, policyPath, err
+}
+
+// CollectChangelogEntries returns the changelog entries of a merge request and its linked issues
+func CollectChangelogEntries(projectID int, mr *gitlab.MergeRequest) []ChangelogEntry {
+	var entries []ChangelogEntry
+	if entry := parseChangelogEntry(mr.Description); entry != nil {
+		entry.Source, entry.URL = fmt.Sprintf("!%d", mr.IID), mr.WebURL
+		entries = append(entries, *entry)
+	}
+
+	for _, issueID := range utils.GetIssueIDsFromDescription(mr.Description) {
+		issue, _, err := client.Issues.GetIssue(projectID, issueID)
+		if err != nil {
+			continue // Skip issues we can't access
+		}
+		if entry := parseChangelogEntry(issue.Description); entry != nil {
+			entry.Source, entry.URL = fmt.Sprintf("#%d", issue.IID), issue.WebURL
+			entries = append(entries, *entry)
+		}
+	}
+
+	return entries
+}
+
+// PrimaryChangelogEntry returns the entry that goes into the release notes: the first
+// one that isn't a No-Changelog-Entry opt-out, or nil when there is none
+func PrimaryChangelogEntry(entries []ChangelogEntry) *ChangelogEntry {
+	for i, e := range entries {
+		if e.Category != noChangelogCategory {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// parseChangelogEntry finds the changelog entry in a description, or returns nil
+func parseChangelogEntry(description string) *ChangelogEntry {
+	match := changelogPattern.FindStringSubmatch(cleanDescription(description))
+	if match == nil {
+		return nil
+	}
+	return &ChangelogEntry{
+		Category: normalizeChangelogCategory(match[1]),
+		Text:     strings.TrimSpace(match[0]),
+	}
+}
+
+// EvaluateChangelogPolicy checks changelog entries against the policy rules matching an MR
+func EvaluateChangelogPolicy(policy *ChangelogPolicy, paths, labels []string, entries []ChangelogEntry) *PolicyResult {
+	result := &PolicyResult{Entries: entries}
+	explain := func(format string, args ...interface{}) {
+		result.Explanation = append(result.Explanation, fmt.Sprintf(format, args...))
+	}
+
+	var requirements []PolicyRule
+	for _, rule := range policy.Rules {
+		matched, why := rule.matches(paths, labels)
+		if !matched {
+			continue
+		}
+		if rule.Exempt {
+			explain("%s applies (%s): no changelog entry needed", rule.Name, why)
+			result.Passed, result.Exempt = true, true
+			return result
+		}
+		explain("%s applies (%s)", rule.Name, why)
+		requirements = append(requirements, rule)
+	}
+
+	if len(requirements) == 0 {
+		explain("no rule applies, default requires one of %s", formatCategories(policy.Default))
+		requirements = []PolicyRule{{Name: "default", Require: policy.Default}}
+	}
+
+	var found []string
+	for _, e := range entries {
+		found = append(found, fmt.Sprintf("[%s] in %s", e.Category, e.Source))
+	}
+	if len(found) == 0 {
+		explain("no changelog entry found on the MR or its linked issues")
+	} else {
+		explain("found %s", strings.Join(found, ", "))
+	}
+
+	result.Passed = true
+	for _, rule := range requirements {
+		if entry := satisfyingEntry(rule.Require, entries); entry != nil {
+			explain("%s: satisfied by [%s] in %s", rule.Name, entry.Category, entry.Source)
+		} else {
+			explain("%s: requires one of %s", rule.Name, formatCategories(rule.Require))
+			result.Passed = false
+		}
+	}
+
+	return result
+}
+
+// matches reports whether a rule applies to an MR and explains why
+func (r PolicyRule) matches(paths, labels []string) (bool, string) {
+	var reasons []string
+
+	if len(r.Labels) > 0 {
+		label := ""
+		for _, l := range labels {
+			if containsString(r.Labels, l) {
+				label = l
+				break
+			}
+		}
+		if label == "" {
+			return false, ""
+		}
+		reasons = append(reasons, fmt.Sprintf("label %q", label))
+	}
+
+	if len(r.Paths) > 0 {
+		var matching []string
+		for _, p := range paths {
+			if MatchAnyPath(r.Paths, p) {
+				matching = append(matching, p)
+			}
+		}
+		switch {
+		case len(matching) == 0:
+			return false, ""
+		case r.Match == "all" && len(matching) < len(paths):
+			return false, ""
+		case r.Match == "all":
+			reasons = append(reasons, fmt.Sprintf("all %d changed files match %s", len(paths), strings.Join(r.Paths, ", ")))
+		default:
+			reasons = append(reasons, fmt.Sprintf("%s matches %s", matching[0], strings.Join(r.Paths, ", ")))
+		}
+	}
+
+	return true, strings.Join(reasons, ", ")
+}
+
+// satisfyingEntry returns the first entry with one of the required categories
+func satisfyingEntry(required []string, entries []ChangelogEntry) *ChangelogEntry {
+	for i, e := range entries {
+		if containsString(required, e.Category) {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// formatCategories renders categories as changelog tags
+func formatCategories(categories []string) string {
+	return "[" + strings.Join(categories, "] / [") + "]"
+}
+
+// CheckChangelogPolicy evaluates the changelog policy for a merge request
+func CheckChangelogPolicy(projectID int, mr *gitlab.MergeRequest, policy *ChangelogPolicy) (*PolicyResult, error) {
+	paths, err := ChangedPaths(projectID, mr.IID)
+	if err != nil {
+		return nil, err
+	}
+	entries := CollectChangelogEntries(projectID, mr)
+	return EvaluateChangelogPolicy(policy, paths, mr.Labels, entries), nil
+}
+
+// PolicyComment renders a policy result as the sticky MR comment
+func PolicyComment(result *PolicyResult, source string) string {
+	var sb strings.Builder
+	if result.Passed {
+		sb.WriteString("✅ **Changelog check passed**\n\n")
+	} else {
+		sb.WriteString("❌ **Changelog check failed**: add a changelog entry to the MR description or the issue it closes.\n\n")
+	}
+	for _, line := range result.Explanation {
+		fmt.Fprintf(&sb, "- %s\n", line)
+	}
+	fmt.Fprintf(&sb, "\nPolicy: `%s`\n", source)
+	return sb.String()
+}
@@ -0,0 +1,95 @@
+package mergerequests
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPolicy = `{
+  "rules": [
+    {"name": "docs-only", "paths": ["docs/", "*.md"], "match": "all", "exempt": true},
+    {"name": "tests-only", "paths": ["**/*_test.go"], "match": "all", "exempt": true},
+    {"name": "migrations", "paths": ["db/migrations/**"], "require": ["infra"]},
+    {"name": "dependencies", "labels": ["dependencies"], "require": ["No-Changelog-Entry", "Infra"]}
+  ]
+}`
+
+func TestParseChangelogPolicy(t *testing.T) {
+	policy, err := ParseChangelogPolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("ParseChangelogPolicy() error = %v", err)
+	}
+	if got := policy.Rules[2].Require[0]; got != "Infra" {
+		t.Errorf("Require not normalized, got %q", got)
+	}
+	if want := allChangelogCategories(); len(policy.Default) != len(want) {
+		t.Errorf("Default = %v, want %v", policy.Default, want)
+	}
+
+	invalid := []string{
+		`{"rules": [{"name": "x", "require": ["Fix"]}]}`,
+		`{"rules": [{"name": "x", "paths": ["a"]}]}`,
+		`{"rules": [{"name": "x", "paths": ["a"], "exempt": true, "require": ["Fix"]}]}`,
+		`{"rules": [{"name": "x", "paths": ["a"], "require": ["Bogus"]}]}`,
+		`{"rules": [{"name": "x", "paths": ["a"], "match": "some", "exempt": true}]}`,
+		`{"default": ["Nope"]}`,
+		`not json`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseChangelogPolicy([]byte(data)); err == nil {
+			t.Errorf("ParseChangelogPolicy(%s) expected error", data)
+		}
+	}
+}
+
+func TestEvaluateChangelogPolicy(t *testing.T) {
+	policy, err := ParseChangelogPolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("ParseChangelogPolicy() error = %v", err)
+	}
+
+	fix := []ChangelogEntry{{Source: "#3", Category: "Fix", Text: "[Fix] crash"}}
+	infra := []ChangelogEntry{{Source: "!7", Category: "Infra", Text: "[Infra] new table"}}
+	skip := []ChangelogEntry{{Source: "!7", Category: noChangelogCategory, Text: "[No-Changelog-Entry]"}}
+
+	tests := []struct {
+		name       string
+		paths      []string
+		labels     []string
+		entries    []ChangelogEntry
+		wantPassed bool
+		wantExempt bool
+		explains   string
+	}{
+		{"docs only is exempt", []string{"docs/guide.md", "README.md"}, nil, nil, true, true, "docs-only applies"},
+		{"docs and code is not exempt", []string{"docs/guide.md", "main.go"}, nil, nil, false, false, "no changelog entry found"},
+		{"tests only is exempt", []string{"cmd/a_test.go", "b_test.go"}, nil, nil, true, true, "tests-only applies"},
+		{"default accepts any category", []string{"main.go"}, nil, fix, true, false, "satisfied by [Fix] in #3"},
+		{"default accepts no-changelog", []string{"main.go"}, nil, skip, true, false, "satisfied by [No-Changelog-Entry] in !7"},
+		{"migration needs infra", []string{"db/migrations/001.sql"}, nil, fix, false, false, "migrations: requires one of [Infra]"},
+		{"migration with infra", []string{"db/migrations/001.sql"}, nil, infra, true, false, "migrations: satisfied"},
+		{"dependency bump may skip", []string{"go.mod"}, []string{"dependencies"}, skip, true, false, `label "dependencies"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := EvaluateChangelogPolicy(policy, tt.paths, tt.labels, tt.entries)
+			if result.Passed != tt.wantPassed || result.Exempt != tt.wantExempt {
+				t.Errorf("Passed, Exempt = %v, %v, want %v, %v (%v)", result.Passed, result.Exempt, tt.wantPassed, tt.wantExempt, result.Explanation)
+			}
+			if explanation := strings.Join(result.Explanation, "\n"); !strings.Contains(explanation, tt.explains) {
+				t.Errorf("Explanation = %q, want it to mention %q", explanation, tt.explains)
+			}
+		})
+	}
+}
+
+func TestParseChangelogEntry(t *testing.T) {
+	entry := parseChangelogEntry("Some context\n\n[improvement] Faster `mr list` output")
+	if entry == nil || entry.Category != "Improvement" {
+		t.Fatalf("parseChangelogEntry() = %+v, want an Improvement entry", entry)
+	}
+	if entry := parseChangelogEntry("no entry here"); entry != nil {
+		t.Errorf("parseChangelogEntry() = %+v, want nil", entry)
+	}
+}
@@ -0,0 +1,200 @@
+package mergerequests
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// conflictNote is posted on merge requests that became unmergeable
+const conflictNote = "⚠️ **Merge conflict**: this merge request no longer merges cleanly into `%s`. Please rebase it.%s"
+
+// ScanOptions configures a bulk conflict scan
+type ScanOptions struct {
+	TargetBranch string
+	Label        string // Label added to conflicting MRs and removed once they merge cleanly again
+	Comment      bool   // Comment on MRs that newly became conflicting
+	DryRun       bool
+}
+
+// ScanResult reports the conflict state of one merge request
+type ScanResult struct {
+	IID      int      `json:"iid"`
+	Title    string   `json:"title"`
+	Conflict bool     `json:"conflict"`
+	New      bool     `json:"new"`     // Conflict was not flagged by a previous scan
+	Pending  bool     `json:"pending"` // GitLab was still checking mergeability, so the MR was left as it was
+	Files    []string `json:"files,omitempty"`
+}
+
+// ConflictingFiles returns the files changed both in the merge request and on its target branch
+// since the merge base. GitLab doesn't expose the actual conflicts over the API, so these are
+// the files that can conflict.
+func ConflictingFiles(projectID, mrIID int) ([]string, error) {
+	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request: %v", err)
+	}
+	if mr.DiffRefs.BaseSha == "" {
+		return nil, fmt.Errorf("merge request !%d has no diff base", mrIID)
+	}
+
+	compare, _, err := client.Repositories.Compare(projectID, &gitlab.CompareOptions{
+		From:     gitlab.String(mr.DiffRefs.BaseSha),
+		To:       gitlab.String(mr.TargetBranch),
+		Straight: gitlab.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s with %s: %v", shortSHA(mr.DiffRefs.BaseSha), mr.TargetBranch, err)
+	}
+
+	var targetPaths []string
+	for _, d := range compare.Diffs {
+		targetPaths = append(targetPaths, d.OldPath, d.NewPath)
+	}
+
+	mrPaths, err := ChangedPaths(projectID, mrIID)
+	if err != nil {
+		return nil, err
+	}
+	return intersectPaths(mrPaths, targetPaths), nil
+}
+
+// diffPaths returns the old and new paths touched by a set of merge request diffs
+func diffPaths(diffs []*gitlab.MergeRequestDiff) []string {
+	var paths []string
+	for _, d := range diffs {
+		paths = append(paths, d.OldPath, d.NewPath)
+	}
+	return paths
+}
+
+// intersectPaths returns the sorted, unique paths present in both lists
+func intersectPaths(a, b []string) []string {
+	inB := make(map[string]bool)
+	for _, p := range b {
+		inB[p] = true
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, p := range a {
+		if p != "" && inB[p] && !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ScanConflicts checks every open merge request targeting a branch for conflicts
+// MRs that newly conflict get the label and an optional comment; MRs that merge
+// cleanly again lose the label, so the label tracks the current state. The listing
+// only starts a recheck, so MRs whose merge status is still being computed are
+// reported as pending and left alone until a later scan.
+func ScanConflicts(projectID int, opts *ScanOptions) ([]ScanResult, error) {
+	// The label is what tells a new conflict from one already commented on
+	if opts.Comment && opts.Label == "" {
+		return nil, fmt.Errorf("commenting needs a label to remember which merge requests were already flagged")
+	}
+
+	listOpts := &gitlab.ListProjectMergeRequestsOptions{
+		State:                  gitlab.String("opened"),
+		TargetBranch:           gitlab.String(opts.TargetBranch),
+		WithMergeStatusRecheck: gitlab.Bool(true),
+		ListOptions:            gitlab.ListOptions{PerPage: 100},
+	}
+	var mrs []*gitlab.MergeRequest
+	for {
+		page, resp, err := client.MergeRequests.ListProjectMergeRequests(projectID, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list merge requests: %v", err)
+		}
+		mrs = append(mrs, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	var results []ScanResult
+	for _, mr := range mrs {
+		// HasConflicts is stale until the recheck finishes
+		if isMergeStatusPending(mr) {
+			results = append(results, ScanResult{IID: mr.IID, Title: mr.Title, Pending: true})
+			continue
+		}
+
+		flagged := opts.Label != "" && hasLabel(mr.Labels, opts.Label)
+		result := ScanResult{IID: mr.IID, Title: mr.Title, Conflict: mr.HasConflicts}
+
+		if mr.HasConflicts {
+			result.New = !flagged
+			if files, err := ConflictingFiles(projectID, mr.IID); err == nil {
+				result.Files = files
+			}
+		}
+
+		if !opts.DryRun {
+			if err := updateConflictState(projectID, mr, &result, flagged, opts); err != nil {
+				return results, err
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// updateConflictState labels and comments on a merge request according to its scan result
+func updateConflictState(projectID int, mr *gitlab.MergeRequest, result *ScanResult, flagged bool, opts *ScanOptions) error {
+	switch {
+	case result.New:
+		if opts.Comment {
+			files := ""
+			if len(result.Files) > 0 {
+				files = "\n\nFiles changed on both sides:\n"
+				for _, f := range result.Files {
+					files += fmt.Sprintf("- `%s`\n", f)
+				}
+			}
+			noteOpts := &gitlab.CreateMergeRequestNoteOptions{
+				Body: gitlab.String(fmt.Sprintf(conflictNote, mr.TargetBranch, files)),
+			}
+			if _, _, err := client.Notes.CreateMergeRequestNote(projectID, mr.IID, noteOpts); err != nil {
+				return fmt.Errorf("failed to comment on !%d: %v", mr.IID, err)
+			}
+		}
+		if opts.Label != "" {
+			labels := gitlab.Labels{opts.Label}
+			_, _, err := client.MergeRequests.UpdateMergeRequest(projectID, mr.IID, &gitlab.UpdateMergeRequestOptions{
+				AddLabels: &labels,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to label !%d: %v", mr.IID, err)
+			}
+		}
+	case !result.Conflict && flagged:
+		labels := gitlab.Labels{opts.Label}
+		_, _, err := client.MergeRequests.UpdateMergeRequest(projectID, mr.IID, &gitlab.UpdateMergeRequestOptions{
+			RemoveLabels: &labels,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to unlabel !%d: %v", mr.IID, err)
+		}
+	}
+	return nil
+}
+
+// hasLabel reports whether a label list contains a label
+func hasLabel(labels gitlab.Labels, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package mergerequests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestIntersectPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want []string
+	}{
+		{
+			name: "common files sorted",
+			a:    []string{"main.go", "cmd/root.go", "README.md"},
+			b:    []string{"README.md", "go.mod", "cmd/root.go"},
+			want: []string{"README.md", "cmd/root.go"},
+		},
+		{
+			name: "duplicates and empty paths ignored",
+			a:    []string{"a.go", "a.go", ""},
+			b:    []string{"a.go", ""},
+			want: []string{"a.go"},
+		},
+		{
+			name: "nothing in common",
+			a:    []string{"a.go"},
+			b:    []string{"b.go"},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := intersectPaths(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("intersectPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffPathsIncludesRenames(t *testing.T) {
+	diffs := []*gitlab.MergeRequestDiff{
+		{OldPath: "old.go", NewPath: "new.go", RenamedFile: true},
+		{OldPath: "same.go", NewPath: "same.go"},
+	}
+
+	got := intersectPaths(diffPaths(diffs), []string{"old.go", "same.go"})
+	want := []string{"old.go", "same.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffPaths() matched %v, want %v", got, want)
+	}
+}
+
+func TestScanConflictsCommentNeedsLabel(t *testing.T) {
+	if _, err := ScanConflicts(1, &ScanOptions{TargetBranch: "main", Comment: true}); err == nil {
+		t.Error("ScanConflicts() with --comment and no label should fail")
+	}
+}
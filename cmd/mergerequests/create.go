@@ -0,0 +1,155 @@
+package mergerequests
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// draftPrefix is the title prefix GitLab uses to mark a merge request as draft
+const draftPrefix = "Draft: "
+
+// CreateRequest describes a merge request to create
+// Users and milestones are given as usernames and titles and resolved through the API
+type CreateRequest struct {
+	Title              string
+	Description        string
+	SourceBranch       string
+	TargetBranch       string
+	Labels             []string
+	Milestone          string // Milestone title or ID
+	Assignees          []string
+	Reviewers          []string
+	Draft              bool
+	Squash             bool
+	AllowCollaboration bool
+	RemoveSource       bool
+	TargetProjectID    int // Target project for MRs opened from a fork
+}
+
+// CreateMergeRequest resolves the request's users and milestone and creates the merge request
+func CreateMergeRequest(projectID int, req *CreateRequest) (*gitlab.MergeRequest, error) {
+	opts, err := buildCreateOptions(projectID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	mr, _, err := client.MergeRequests.CreateMergeRequest(projectID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merge request: %v", err)
+	}
+
+	return mr, nil
+}
+
+// buildCreateOptions converts a CreateRequest into API options
+func buildCreateOptions(projectID int, req *CreateRequest) (*gitlab.CreateMergeRequestOptions, error) {
+	opts := &gitlab.CreateMergeRequestOptions{
+		Title:              gitlab.String(draftTitle(req.Title, req.Draft)),
+		SourceBranch:       gitlab.String(req.SourceBranch),
+		TargetBranch:       gitlab.String(req.TargetBranch),
+		RemoveSourceBranch: gitlab.Bool(req.RemoveSource),
+	}
+
+	if req.Description != "" {
+		opts.Description = gitlab.String(req.Description)
+	}
+	if len(req.Labels) > 0 {
+		labels := gitlab.Labels(req.Labels)
+		opts.Labels = &labels
+	}
+	if req.Squash {
+		opts.Squash = gitlab.Bool(true)
+	}
+	if req.AllowCollaboration {
+		opts.AllowCollaboration = gitlab.Bool(true)
+	}
+
+	// Milestones belong to the project the MR is opened against
+	milestoneProject := projectID
+	if req.TargetProjectID != 0 {
+		opts.TargetProjectID = gitlab.Int(req.TargetProjectID)
+		milestoneProject = req.TargetProjectID
+	}
+
+	if req.Milestone != "" {
+		milestoneID, err := ResolveMilestoneID(milestoneProject, req.Milestone)
+		if err != nil {
+			return nil, err
+		}
+		opts.MilestoneID = gitlab.Int(milestoneID)
+	}
+
+	if len(req.Assignees) > 0 {
+		ids, err := utils.ResolveUserIDs(req.Assignees)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assignee: %v", err)
+		}
+		opts.AssigneeIDs = &ids
+	}
+	if len(req.Reviewers) > 0 {
+		ids, err := utils.ResolveUserIDs(req.Reviewers)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reviewer: %v", err)
+		}
+		opts.ReviewerIDs = &ids
+	}
+
+	return opts, nil
+}
+
+// ResolveMilestoneID resolves a milestone given by ID or title to its ID
+// Titles are matched case-insensitively against project and parent group milestones;
+// a number that is no milestone ID of the project is looked up as a title too.
+func ResolveMilestoneID(projectID int, milestone string) (int, error) {
+	// A numeric value may also be the title of a milestone, like "2026"
+	if id, err := strconv.Atoi(milestone); err == nil {
+		m, resp, err := client.Milestones.GetMilestone(projectID, id)
+		if err == nil {
+			return m.ID, nil
+		}
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return 0, fmt.Errorf("failed to get milestone %d: %v", id, err)
+		}
+	}
+
+	milestones, _, err := client.Milestones.ListMilestones(projectID, &gitlab.ListMilestonesOptions{
+		Title:                   gitlab.String(milestone),
+		IncludeParentMilestones: gitlab.Bool(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list milestones: %v", err)
+	}
+
+	for _, m := range milestones {
+		if strings.EqualFold(m.Title, milestone) {
+			return m.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("milestone %q not found", milestone)
+}
+
+// draftTitle adds or keeps the draft prefix on a title when draft is requested
+func draftTitle(title string, draft bool) string {
+	if !draft || isDraftTitle(title) {
+		return title
+	}
+	return draftPrefix + title
+}
+
+// isDraftTitle reports whether a title already carries one of GitLab's draft markers
+func isDraftTitle(title string) bool {
+	lower := strings.ToLower(title)
+	for _, prefix := range []string{"draft:", "[draft]", "(draft)"} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package mergerequests
+
+import "testing"
+
+func TestDraftTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		draft bool
+		want  string
+	}{
+		{
+			name:  "not draft",
+			title: "Add login",
+			draft: false,
+			want:  "Add login",
+		},
+		{
+			name:  "adds draft prefix",
+			title: "Add login",
+			draft: true,
+			want:  "Draft: Add login",
+		},
+		{
+			name:  "keeps existing draft prefix",
+			title: "Draft: Add login",
+			draft: true,
+			want:  "Draft: Add login",
+		},
+		{
+			name:  "keeps bracket draft marker",
+			title: "[Draft] Add login",
+			draft: true,
+			want:  "[Draft] Add login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := draftTitle(tt.title, tt.draft); got != tt.want {
+				t.Errorf("draftTitle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
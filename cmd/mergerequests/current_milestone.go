@@ -0,0 +1,265 @@
+package mergerequests
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"mpg-gitlab/cmd/types"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	// Current milestone resolution strategies
+	StrategyTitle    = "title"    // Active milestone with an exact title, compared case-insensitively
+	StrategyRegex    = "regex"    // Active milestone whose title matches a regular expression
+	StrategyDates    = "dates"    // Active milestone whose start and due dates contain today
+	StrategyUpcoming = "upcoming" // Active milestone with the nearest due date that hasn't passed
+	StrategyVariable = "variable" // Active milestone titled after a CI/CD variable of the project or group
+)
+
+// MilestoneStrategies lists the supported current milestone strategies
+var MilestoneStrategies = []string{StrategyTitle, StrategyRegex, StrategyDates, StrategyUpcoming, StrategyVariable}
+
+// MilestoneStrategy selects how the current milestone is resolved
+type MilestoneStrategy struct {
+	Name         string // One of MilestoneStrategies
+	Value        string // Title, pattern or variable name, depending on the strategy
+	IncludeGroup bool   // Also consider milestones of the project's groups
+}
+
+// DefaultMilestoneStrategy resolves the active milestone titled "Current"
+func DefaultMilestoneStrategy() MilestoneStrategy {
+	return MilestoneStrategy{Name: StrategyTitle, Value: "Current", IncludeGroup: true}
+}
+
+// Validate checks that the strategy is known and has the value it needs
+func (s MilestoneStrategy) Validate() error {
+	switch s.Name {
+	case StrategyTitle, StrategyRegex, StrategyVariable:
+		if strings.TrimSpace(s.Value) == "" {
+			return fmt.Errorf("milestone strategy %s needs a value", s.Name)
+		}
+	case StrategyDates, StrategyUpcoming:
+	default:
+		return fmt.Errorf("unknown milestone strategy %q, expected one of %s", s.Name, strings.Join(MilestoneStrategies, ", "))
+	}
+	if s.Name == StrategyRegex {
+		if _, err := regexp.Compile(s.Value); err != nil {
+			return fmt.Errorf("invalid milestone pattern: %v", err)
+		}
+	}
+	return nil
+}
+
+// MilestoneResolution records which milestone a strategy resolved to and why
+type MilestoneResolution struct {
+	ID          int      `json:"id"`
+	Title       string   `json:"title"`
+	Scope       string   `json:"scope"` // "project" or "group"
+	Explanation []string `json:"explanation"`
+}
+
+// ResolveCurrentMilestone finds the current milestone of a project with a strategy
+func ResolveCurrentMilestone(projectID int, strategy MilestoneStrategy, today time.Time) (*MilestoneResolution, error) {
+	if err := strategy.Validate(); err != nil {
+		return nil, err
+	}
+
+	candidates, err := activeMilestones(projectID, strategy.IncludeGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	var explanation []string
+	if strategy.Name == StrategyVariable {
+		title, source, err := lookupVariable(projectID, strategy.Value)
+		if err != nil {
+			return nil, err
+		}
+		explanation = append(explanation, fmt.Sprintf("variable %s is %q (%s)", strategy.Value, title, source))
+		strategy = MilestoneStrategy{Name: StrategyTitle, Value: title}
+	}
+
+	milestone, why, err := selectMilestone(strategy, candidates, today)
+	if err != nil {
+		return nil, err
+	}
+
+	resolution := &MilestoneResolution{
+		ID:          milestone.ID,
+		Title:       milestone.Title,
+		Scope:       milestoneScope(milestone),
+		Explanation: append(explanation, why...),
+	}
+	return resolution, nil
+}
+
+// activeMilestones lists the active milestones available to a project
+func activeMilestones(projectID int, includeGroup bool) ([]*gitlab.Milestone, error) {
+	opts := &gitlab.ListMilestonesOptions{
+		State:       gitlab.String("active"),
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+	}
+	if includeGroup {
+		opts.IncludeParentMilestones = gitlab.Bool(true)
+	}
+
+	var milestones []*gitlab.Milestone
+	for {
+		page, resp, err := client.Milestones.ListMilestones(projectID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list milestones: %v", err)
+		}
+		milestones = append(milestones, page...)
+
+		if resp == nil || resp.NextPage == 0 {
+			return milestones, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// selectMilestone applies a strategy to the active milestones and explains the choice
+// The variable strategy must already be resolved to a title.
+func selectMilestone(strategy MilestoneStrategy, candidates []*gitlab.Milestone, today time.Time) (*gitlab.Milestone, []string, error) {
+	today = types.TruncateDay(today)
+
+	switch strategy.Name {
+	case StrategyTitle:
+		var matches []*gitlab.Milestone
+		for _, m := range candidates {
+			if strings.EqualFold(m.Title, strategy.Value) {
+				matches = append(matches, m)
+			}
+		}
+		if len(matches) == 0 {
+			return nil, nil, fmt.Errorf("no active milestone named '%s' found", strategy.Value)
+		}
+		// A project milestone shadows a group milestone with the same title
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].GroupID == 0 && matches[j].GroupID != 0 })
+		return matches[0], []string{fmt.Sprintf("%s milestone titled %q", milestoneScope(matches[0]), matches[0].Title)}, nil
+
+	case StrategyRegex:
+		pattern := regexp.MustCompile(strategy.Value)
+		var matches []*gitlab.Milestone
+		for _, m := range candidates {
+			if pattern.MatchString(m.Title) {
+				matches = append(matches, m)
+			}
+		}
+		why := []string{fmt.Sprintf("%d active milestone(s) match /%s/", len(matches), strategy.Value)}
+		switch len(matches) {
+		case 0:
+			return nil, nil, fmt.Errorf("no active milestone matches /%s/", strategy.Value)
+		case 1:
+			return matches[0], append(why, fmt.Sprintf("picked %q", matches[0].Title)), nil
+		}
+		// Several sprints may be planned ahead; prefer the running one, then the next due
+		if m, more, err := selectMilestone(MilestoneStrategy{Name: StrategyDates}, matches, today); err == nil {
+			return m, append(why, more...), nil
+		}
+		m, more, err := selectMilestone(MilestoneStrategy{Name: StrategyUpcoming}, matches, today)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%d active milestones match /%s/ and none is running or upcoming", len(matches), strategy.Value)
+		}
+		return m, append(why, more...), nil
+
+	case StrategyDates:
+		var best *gitlab.Milestone
+		for _, m := range candidates {
+			if m.DueDate == nil || today.After(types.TruncateDay(time.Time(*m.DueDate))) {
+				continue
+			}
+			if m.StartDate != nil && today.Before(types.TruncateDay(time.Time(*m.StartDate))) {
+				continue
+			}
+			// With overlapping milestones, the one that started last is the most specific
+			if best == nil || startOf(m).After(startOf(best)) {
+				best = m
+			}
+		}
+		if best == nil {
+			return nil, nil, fmt.Errorf("no active milestone runs on %s", today.Format("2006-01-02"))
+		}
+		return best, []string{fmt.Sprintf("%q runs from %s to %s", best.Title, formatISODate(best.StartDate), formatISODate(best.DueDate))}, nil
+
+	case StrategyUpcoming:
+		var best *gitlab.Milestone
+		for _, m := range candidates {
+			if m.DueDate == nil || today.After(types.TruncateDay(time.Time(*m.DueDate))) {
+				continue
+			}
+			if best == nil || time.Time(*m.DueDate).Before(time.Time(*best.DueDate)) {
+				best = m
+			}
+		}
+		if best == nil {
+			return nil, nil, fmt.Errorf("no active milestone is due on or after %s", today.Format("2006-01-02"))
+		}
+		return best, []string{fmt.Sprintf("%q is the next due, on %s", best.Title, formatISODate(best.DueDate))}, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown milestone strategy %q", strategy.Name)
+}
+
+// lookupVariable reads a CI/CD variable from the environment, the project, or its group
+// In a pipeline the variable is in the environment; elsewhere it is read through the API.
+func lookupVariable(projectID int, key string) (string, string, error) {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value, "environment", nil
+	}
+
+	variable, resp, err := client.ProjectVariables.GetVariable(projectID, key, nil)
+	if err == nil {
+		return strings.TrimSpace(variable.Value), "project variable", nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return "", "", fmt.Errorf("failed to get variable %s: %v", key, err)
+	}
+
+	project, _, err := client.Projects.GetProject(projectID, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get project: %v", err)
+	}
+	if project.Namespace != nil && project.Namespace.Kind == "group" {
+		groupVariable, resp, err := client.GroupVariables.GetVariable(project.Namespace.FullPath, key)
+		if err == nil {
+			return strings.TrimSpace(groupVariable.Value), "group variable of " + project.Namespace.FullPath, nil
+		}
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return "", "", fmt.Errorf("failed to get group variable %s: %v", key, err)
+		}
+	}
+
+	return "", "", fmt.Errorf("variable %s is not set in the environment, the project or its group", key)
+}
+
+// milestoneScope tells project milestones from group milestones
+func milestoneScope(m *gitlab.Milestone) string {
+	if m.GroupID != 0 {
+		return "group"
+	}
+	return "project"
+}
+
+// startOf returns the start date of a milestone, or the zero time without one
+func startOf(m *gitlab.Milestone) time.Time {
+	if m.StartDate == nil {
+		return time.Time{}
+	}
+	return time.Time(*m.StartDate)
+}
+
+// formatISODate renders an optional milestone date
+func formatISODate(d *gitlab.ISOTime) string {
+	if d == nil {
+		return "open"
+	}
+	return time.Time(*d).Format("2006-01-02")
+}
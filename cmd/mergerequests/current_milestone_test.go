@@ -0,0 +1,97 @@
+package mergerequests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func milestoneDate(s string) *gitlab.ISOTime {
+	t, _ := time.Parse("2006-01-02", s)
+	d := gitlab.ISOTime(t)
+	return &d
+}
+
+func TestSelectMilestone(t *testing.T) {
+	candidates := []*gitlab.Milestone{
+		{ID: 1, Title: "Backlog"},
+		{ID: 2, Title: "Sprint 41", StartDate: milestoneDate("2026-10-05"), DueDate: milestoneDate("2026-10-16")},
+		{ID: 3, Title: "Sprint 42", StartDate: milestoneDate("2026-10-19"), DueDate: milestoneDate("2026-10-30")},
+		{ID: 4, Title: "Sprint 43", StartDate: milestoneDate("2026-11-02"), DueDate: milestoneDate("2026-11-13")},
+		{ID: 5, Title: "2026.10", GroupID: 9, DueDate: milestoneDate("2026-10-31")},
+		{ID: 6, Title: "current", GroupID: 9},
+		{ID: 7, Title: "Current"},
+	}
+	today := time.Date(2026, 10, 21, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		strategy MilestoneStrategy
+		wantID   int
+		wantErr  string
+	}{
+		{MilestoneStrategy{Name: StrategyTitle, Value: "CURRENT"}, 7, ""},
+		{MilestoneStrategy{Name: StrategyTitle, Value: "Sprint 99"}, 0, "no active milestone named 'Sprint 99' found"},
+		{MilestoneStrategy{Name: StrategyRegex, Value: `^\d{4}\.\d{2}$`}, 5, ""},
+		{MilestoneStrategy{Name: StrategyRegex, Value: `^Sprint \d+$`}, 3, ""},
+		{MilestoneStrategy{Name: StrategyRegex, Value: `^Release`}, 0, "no active milestone matches"},
+		{MilestoneStrategy{Name: StrategyDates}, 3, ""},
+		{MilestoneStrategy{Name: StrategyUpcoming}, 3, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy.Name+" "+tt.strategy.Value, func(t *testing.T) {
+			m, why, err := selectMilestone(tt.strategy, candidates, today)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("selectMilestone() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectMilestone() error = %v", err)
+			}
+			if m.ID != tt.wantID {
+				t.Errorf("selectMilestone() = #%d %q, want #%d (%v)", m.ID, m.Title, tt.wantID, why)
+			}
+			if len(why) == 0 {
+				t.Error("selectMilestone() gave no explanation")
+			}
+		})
+	}
+
+	// Between sprints nothing runs, but the next one is still upcoming
+	gap := time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+	sprints := candidates[1:4]
+	if _, _, err := selectMilestone(MilestoneStrategy{Name: StrategyDates}, sprints, gap); err == nil {
+		t.Error("selectMilestone(dates) found a running sprint between sprints")
+	}
+	if m, _, _ := selectMilestone(MilestoneStrategy{Name: StrategyUpcoming}, sprints, gap); m == nil || m.ID != 4 {
+		t.Errorf("selectMilestone(upcoming) between sprints = %v, want #4", m)
+	}
+}
+
+func TestMilestoneStrategyValidate(t *testing.T) {
+	valid := []MilestoneStrategy{
+		DefaultMilestoneStrategy(),
+		{Name: StrategyDates},
+		{Name: StrategyVariable, Value: "CURRENT_MILESTONE"},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("%+v.Validate() error = %v", s, err)
+		}
+	}
+
+	invalid := []MilestoneStrategy{
+		{Name: "latest"},
+		{Name: StrategyTitle},
+		{Name: StrategyRegex, Value: "Sprint ("},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("%+v.Validate() accepted an invalid strategy", s)
+		}
+	}
+}
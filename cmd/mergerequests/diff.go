@@ -0,0 +1,265 @@
+package mergerequests
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	// Changed file statuses
+	fileAdded    = "added"
+	fileModified = "modified"
+	fileDeleted  = "deleted"
+	fileRenamed  = "renamed"
+)
+
+// ChangedFile describes one file touched by a merge request
+type ChangedFile struct {
+	Path      string `json:"path"`
+	OldPath   string `json:"old_path,omitempty"` // Only set for renames
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// MergeRequestDiffs returns the diffs of every file changed in a merge request
+func MergeRequestDiffs(projectID, mrIID int) ([]*gitlab.MergeRequestDiff, error) {
+	opts := &gitlab.ListMergeRequestDiffsOptions{PerPage: 100}
+
+	var diffs []*gitlab.MergeRequestDiff
+	for {
+		page, resp, err := client.MergeRequests.ListMergeRequestDiffs(projectID, mrIID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get merge request diffs: %v", err)
+		}
+		diffs = append(diffs, page...)
+
+		if resp.NextPage == 0 {
+			return diffs, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ChangedFiles returns the files changed in a merge request with their line counts
+func ChangedFiles(projectID, mrIID int) ([]ChangedFile, error) {
+	diffs, err := MergeRequestDiffs(projectID, mrIID)
+	if err != nil {
+		return nil, err
+	}
+	return changedFiles(diffs), nil
+}
+
+// ChangedPaths returns the paths changed in a merge request
+// Renamed files are reported under both their old and new path, so path
+// policies apply to files moved out of a directory as well as into it.
+func ChangedPaths(projectID, mrIID int) ([]string, error) {
+	diffs, err := MergeRequestDiffs(projectID, mrIID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, p := range diffPaths(diffs) {
+		if p != "" && !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// changedFiles summarizes diffs as changed files
+func changedFiles(diffs []*gitlab.MergeRequestDiff) []ChangedFile {
+	var files []ChangedFile
+	for _, d := range diffs {
+		file := ChangedFile{Path: d.NewPath, Status: fileModified}
+		switch {
+		case d.NewFile:
+			file.Status = fileAdded
+		case d.DeletedFile:
+			file.Path, file.Status = d.OldPath, fileDeleted
+		case d.RenamedFile:
+			file.OldPath, file.Status = d.OldPath, fileRenamed
+		}
+		file.Additions, file.Deletions = countDiffLines(d.Diff)
+		files = append(files, file)
+	}
+	return files
+}
+
+// countDiffLines counts the added and removed lines of a diff body
+func countDiffLines(diff string) (int, int) {
+	additions, deletions := 0, 0
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			// File headers, present when GitLab returns a full git diff
+		case strings.HasPrefix(line, "+"):
+			additions++
+		case strings.HasPrefix(line, "-"):
+			deletions++
+		}
+	}
+	return additions, deletions
+}
+
+// MatchPath reports whether a path matches a pattern
+// A pattern ending in "/" matches everything under that directory, "**" matches
+// any number of directories, other wildcards are matched with path.Match, and any
+// other pattern matches the path itself or, if it is a directory, everything under it.
+func MatchPath(pattern, p string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	switch {
+	case strings.HasSuffix(pattern, "/"):
+		return strings.HasPrefix(p, pattern)
+	case strings.Contains(pattern, "**"):
+		return globPattern(pattern).MatchString(p)
+	case strings.ContainsAny(pattern, "*?["):
+		matched, _ := path.Match(pattern, p)
+		return matched
+	default:
+		return p == pattern || strings.HasPrefix(p, pattern+"/")
+	}
+}
+
+// globPattern compiles a glob with "**" segments to a regular expression
+func globPattern(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// MatchAnyPath reports whether a path matches at least one of the patterns
+func MatchAnyPath(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if MatchPath(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterDiffs keeps the diffs touching a path matching one of the patterns
+// No patterns keeps every diff
+func FilterDiffs(diffs []*gitlab.MergeRequestDiff, patterns []string) []*gitlab.MergeRequestDiff {
+	if len(patterns) == 0 {
+		return diffs
+	}
+
+	var filtered []*gitlab.MergeRequestDiff
+	for _, d := range diffs {
+		if MatchAnyPath(patterns, d.NewPath) || MatchAnyPath(patterns, d.OldPath) {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// RenderDiff renders merge request diffs as a unified diff, like git diff
+func RenderDiff(diffs []*gitlab.MergeRequestDiff) string {
+	var sb strings.Builder
+	for _, d := range diffs {
+		fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", d.OldPath, d.NewPath)
+
+		oldName, newName := "a/"+d.OldPath, "b/"+d.NewPath
+		switch {
+		case d.NewFile:
+			fmt.Fprintf(&sb, "new file mode %s\n", d.BMode)
+			oldName = "/dev/null"
+		case d.DeletedFile:
+			fmt.Fprintf(&sb, "deleted file mode %s\n", d.AMode)
+			newName = "/dev/null"
+		case d.RenamedFile:
+			fmt.Fprintf(&sb, "rename from %s\nrename to %s\n", d.OldPath, d.NewPath)
+		}
+		if d.AMode != d.BMode && !d.NewFile && !d.DeletedFile {
+			fmt.Fprintf(&sb, "old mode %s\nnew mode %s\n", d.AMode, d.BMode)
+		}
+
+		if d.Diff == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+		sb.WriteString(d.Diff)
+		if !strings.HasSuffix(d.Diff, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// RenderStat renders changed files as a diffstat, like git diff --stat
+func RenderStat(files []ChangedFile) string {
+	const barWidth = 40
+
+	width, largest := 0, 0
+	for _, f := range files {
+		if n := len(statName(f)); n > width {
+			width = n
+		}
+		if n := f.Additions + f.Deletions; n > largest {
+			largest = n
+		}
+	}
+
+	var sb strings.Builder
+	additions, deletions := 0, 0
+	for _, f := range files {
+		additions += f.Additions
+		deletions += f.Deletions
+
+		plus, minus := f.Additions, f.Deletions
+		if largest > barWidth {
+			// Scale bars down, keeping at least one mark for any change
+			plus = scaleBar(plus, largest, barWidth)
+			minus = scaleBar(minus, largest, barWidth)
+		}
+		fmt.Fprintf(&sb, " %-*s | %4d %s%s\n", width, statName(f), f.Additions+f.Deletions,
+			strings.Repeat("+", plus), strings.Repeat("-", minus))
+	}
+
+	fmt.Fprintf(&sb, " %d file(s) changed, %d insertion(s)(+), %d deletion(s)(-)\n", len(files), additions, deletions)
+	return sb.String()
+}
+
+// statName is how a file is named in a diffstat
+func statName(f ChangedFile) string {
+	if f.Status == fileRenamed {
+		return f.OldPath + " => " + f.Path
+	}
+	return f.Path
+}
+
+// scaleBar scales a line count to a bar of at most width marks
+func scaleBar(count, largest, width int) int {
+	if count == 0 {
+		return 0
+	}
+	if scaled := count * width / largest; scaled > 0 {
+		return scaled
+	}
+	return 1
+}
@@ -0,0 +1,101 @@
+package mergerequests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"migrations/", "migrations/001_init.sql", true},
+		{"migrations/", "db/migrations/001_init.sql", false},
+		{"migrations", "migrations/001_init.sql", true},
+		{"migrations", "migrations_old/001.sql", false},
+		{"./api/", "api/v1/handler.go", true},
+		{"go.mod", "go.mod", true},
+		{"*.md", "README.md", true},
+		{"*.md", "docs/guide.md", false},
+		{"docs/*.md", "docs/guide.md", true},
+		{"db/migrations/**", "db/migrations/2026/001_init.sql", true},
+		{"db/migrations/**", "db/seeds/001.sql", false},
+		{"**/*_test.go", "cmd/mergerequests/diff_test.go", true},
+		{"**/*_test.go", "main_test.go", true},
+		{"**/*_test.go", "main.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			if got := MatchPath(tt.pattern, tt.path); got != tt.want {
+				t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	diffs := []*gitlab.MergeRequestDiff{
+		{OldPath: "main.go", NewPath: "main.go", Diff: "@@ -1,2 +1,3 @@\n package main\n-import \"fmt\"\n+import (\n+\t\"fmt\"\n"},
+		{OldPath: "new.go", NewPath: "new.go", NewFile: true, Diff: "@@ -0,0 +1 @@\n+package main\n"},
+		{OldPath: "old.go", NewPath: "old.go", DeletedFile: true, Diff: "@@ -1 +0,0 @@\n-package main\n"},
+		{OldPath: "a.go", NewPath: "b.go", RenamedFile: true},
+	}
+
+	want := []ChangedFile{
+		{Path: "main.go", Status: fileModified, Additions: 2, Deletions: 1},
+		{Path: "new.go", Status: fileAdded, Additions: 1},
+		{Path: "old.go", Status: fileDeleted, Deletions: 1},
+		{Path: "b.go", OldPath: "a.go", Status: fileRenamed},
+	}
+
+	if got := changedFiles(diffs); !reflect.DeepEqual(got, want) {
+		t.Errorf("changedFiles() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFilterDiffs(t *testing.T) {
+	diffs := []*gitlab.MergeRequestDiff{
+		{OldPath: "api/handler.go", NewPath: "api/handler.go"},
+		{OldPath: "api/old.go", NewPath: "internal/old.go", RenamedFile: true},
+		{OldPath: "README.md", NewPath: "README.md"},
+	}
+
+	got := FilterDiffs(diffs, []string{"api/"})
+	if len(got) != 2 || got[0].NewPath != "api/handler.go" || got[1].NewPath != "internal/old.go" {
+		t.Errorf("FilterDiffs() kept %d diffs, want the api/ file and the file moved out of api/", len(got))
+	}
+
+	if got := FilterDiffs(diffs, nil); len(got) != len(diffs) {
+		t.Errorf("FilterDiffs() without patterns kept %d diffs, want %d", len(got), len(diffs))
+	}
+}
+
+func TestRenderDiff(t *testing.T) {
+	diffs := []*gitlab.MergeRequestDiff{
+		{OldPath: "new.go", NewPath: "new.go", NewFile: true, BMode: "100644", Diff: "@@ -0,0 +1 @@\n+package main\n"},
+	}
+
+	want := "diff --git a/new.go b/new.go\nnew file mode 100644\n--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+package main\n"
+	if got := RenderDiff(diffs); got != want {
+		t.Errorf("RenderDiff() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderStat(t *testing.T) {
+	files := []ChangedFile{
+		{Path: "main.go", Status: fileModified, Additions: 2, Deletions: 1},
+		{Path: "b.go", OldPath: "a.go", Status: fileRenamed},
+	}
+
+	want := " main.go      |    3 ++-\n" +
+		" a.go => b.go |    0 \n" +
+		" 2 file(s) changed, 2 insertion(s)(+), 1 deletion(s)(-)\n"
+	if got := RenderStat(files); got != want {
+		t.Errorf("RenderStat() = %q, want %q", got, want)
+	}
+}
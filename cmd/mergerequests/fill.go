@@ -0,0 +1,169 @@
+package mergerequests
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// issueBranchPattern matches branches created from an issue, like "123-add-login"
+var issueBranchPattern = regexp.MustCompile(`^(?:.*/)?(\d+)-(.+)$`)
+
+// labelCategories maps common issue labels to changelog categories
+var labelCategories = map[string]string{
+	"bug":         "Fix",
+	"fix":         "Fix",
+	"feature":     "Feature",
+	"enhancement": "Improvement",
+	"improvement": "Improvement",
+	"infra":       "Infra",
+	"ci":          "Infra",
+	"devops":      "Infra",
+}
+
+// defaultFillCategory is used when the linked issue gives no hint about its category
+const defaultFillCategory = "Improvement"
+
+// FillFromBranch completes a CreateRequest from the local git branch
+// The source branch, title and description are only filled when not already set
+func FillFromBranch(projectID int, req *CreateRequest) error {
+	if req.SourceBranch == "" {
+		branch, err := utils.CurrentBranch()
+		if err != nil {
+			return fmt.Errorf("failed to read current branch: %v", err)
+		}
+		req.SourceBranch = branch
+	}
+
+	commits, err := utils.CommitMessages(req.TargetBranch)
+	if err != nil {
+		return fmt.Errorf("failed to read commits: %v", err)
+	}
+
+	// Branches created from an issue link back to it
+	issue, err := branchIssue(projectID, req.SourceBranch)
+	if err != nil {
+		return err
+	}
+
+	if req.Title == "" {
+		req.Title = fillTitle(req.SourceBranch, issue, commits)
+	}
+	if req.Description == "" {
+		req.Description = fillDescription(issue, commits)
+	}
+
+	return nil
+}
+
+// branchIssue returns the issue a branch was created from, or nil when the branch
+// doesn't name one. A number prefix that isn't an issue of the project, like in
+// "2026-upgrade", counts as no linked issue.
+func branchIssue(projectID int, branch string) (*gitlab.Issue, error) {
+	iid := branchIssueIID(branch)
+	if iid == 0 {
+		return nil, nil
+	}
+	issue, resp, err := client.Issues.GetIssue(projectID, iid)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get issue #%d: %v", iid, err)
+	}
+	return issue, nil
+}
+
+// branchIssueIID returns the issue IID encoded in a branch name, or 0
+func branchIssueIID(branch string) int {
+	matches := issueBranchPattern.FindStringSubmatch(branch)
+	if matches == nil {
+		return 0
+	}
+	iid, _ := strconv.Atoi(matches[1])
+	return iid
+}
+
+// humanizeBranch turns a branch name like "123-add-login" into "Add login"
+func humanizeBranch(branch string) string {
+	if matches := issueBranchPattern.FindStringSubmatch(branch); matches != nil {
+		branch = matches[2]
+	} else if i := strings.LastIndex(branch, "/"); i >= 0 {
+		branch = branch[i+1:]
+	}
+
+	words := strings.FieldsFunc(branch, func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	text := strings.Join(words, " ")
+	if text == "" {
+		return branch
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
+
+// fillTitle derives a merge request title from the issue, the branch or the first commit
+func fillTitle(branch string, issue *gitlab.Issue, commits []string) string {
+	if issue != nil {
+		return fmt.Sprintf("Resolve \"%s\"", issue.Title)
+	}
+	if branchIssueIID(branch) != 0 {
+		return fmt.Sprintf("Resolve \"%s\"", humanizeBranch(branch))
+	}
+	if len(commits) > 0 {
+		return strings.SplitN(commits[0], "\n", 2)[0]
+	}
+	return humanizeBranch(branch)
+}
+
+// fillDescription assembles a description from the linked issue and commit messages
+// The changelog line comes first so findChangelogEntry picks it up
+func fillDescription(issue *gitlab.Issue, commits []string) string {
+	var desc strings.Builder
+
+	if issue != nil {
+		desc.WriteString(issueChangelogLine(issue) + "\n\n")
+		desc.WriteString(fmt.Sprintf("Closes #%d\n", issue.IID))
+	}
+
+	if len(commits) > 0 {
+		if desc.Len() > 0 {
+			desc.WriteString("\n")
+		}
+		desc.WriteString("## Commits\n\n")
+		for _, commit := range commits {
+			lines := strings.Split(commit, "\n")
+			desc.WriteString("- " + lines[0] + "\n")
+			for _, line := range lines[1:] {
+				if line = strings.TrimSpace(line); line != "" {
+					desc.WriteString("  " + line + "\n")
+				}
+			}
+		}
+	}
+
+	return desc.String()
+}
+
+// issueChangelogLine returns the changelog entry to put in the MR description
+// It reuses the issue's own entry when present, otherwise infers the category from labels
+func issueChangelogLine(issue *gitlab.Issue) string {
+	if entry := findChangelogEntry(cleanDescription(issue.Description)); entry != "" {
+		return entry
+	}
+
+	category := defaultFillCategory
+	for _, label := range issue.Labels {
+		if c, ok := labelCategories[strings.ToLower(label)]; ok {
+			category = c
+			break
+		}
+	}
+	return fmt.Sprintf("[%s] %s", category, issue.Title)
+}
@@ -0,0 +1,130 @@
+package mergerequests
+
+import (
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestHumanizeBranch(t *testing.T) {
+	tests := []struct {
+		name   string
+		branch string
+		want   string
+		iid    int
+	}{
+		{
+			name:   "issue branch",
+			branch: "123-add-login",
+			want:   "Add login",
+			iid:    123,
+		},
+		{
+			name:   "issue branch with prefix",
+			branch: "feature/45-fix_logout-button",
+			want:   "Fix logout button",
+			iid:    45,
+		},
+		{
+			name:   "plain branch",
+			branch: "update-deps",
+			want:   "Update deps",
+			iid:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := humanizeBranch(tt.branch); got != tt.want {
+				t.Errorf("humanizeBranch() = %v, want %v", got, tt.want)
+			}
+			if got := branchIssueIID(tt.branch); got != tt.iid {
+				t.Errorf("branchIssueIID() = %v, want %v", got, tt.iid)
+			}
+		})
+	}
+}
+
+func TestFillTitle(t *testing.T) {
+	tests := []struct {
+		name    string
+		branch  string
+		issue   *gitlab.Issue
+		commits []string
+		want    string
+	}{
+		{
+			name:   "uses issue title",
+			branch: "123-add-login",
+			issue:  &gitlab.Issue{IID: 123, Title: "Add login page"},
+			want:   "Resolve \"Add login page\"",
+		},
+		{
+			name:   "falls back to branch name",
+			branch: "123-add-login",
+			want:   "Resolve \"Add login\"",
+		},
+		{
+			name:    "uses first commit subject",
+			branch:  "update-deps",
+			commits: []string{"Bump cobra to 1.8\n\nDetails", "Tidy go.sum"},
+			want:    "Bump cobra to 1.8",
+		},
+		{
+			name:   "no commits",
+			branch: "update-deps",
+			want:   "Update deps",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fillTitle(tt.branch, tt.issue, tt.commits); got != tt.want {
+				t.Errorf("fillTitle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillDescription(t *testing.T) {
+	tests := []struct {
+		name    string
+		issue   *gitlab.Issue
+		commits []string
+		want    string
+	}{
+		{
+			name:    "issue with changelog entry",
+			issue:   &gitlab.Issue{IID: 123, Title: "Add login", Description: "Details\n[Feature] Users can log in"},
+			commits: []string{"Add login form\n\nWith validation"},
+			want:    "[Feature] Users can log in\n\nCloses #123\n\n## Commits\n\n- Add login form\n  With validation\n",
+		},
+		{
+			name:  "category inferred from labels",
+			issue: &gitlab.Issue{IID: 7, Title: "Logout is broken", Labels: gitlab.Labels{"bug"}},
+			want:  "[Fix] Logout is broken\n\nCloses #7\n",
+		},
+		{
+			name:  "default category",
+			issue: &gitlab.Issue{IID: 8, Title: "Faster search"},
+			want:  "[Improvement] Faster search\n\nCloses #8\n",
+		},
+		{
+			name:    "commits only",
+			commits: []string{"Tidy go.sum"},
+			want:    "## Commits\n\n- Tidy go.sum\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fillDescription(tt.issue, tt.commits)
+			if got != tt.want {
+				t.Errorf("fillDescription() = %q, want %q", got, tt.want)
+			}
+			if tt.issue != nil && findChangelogEntry(cleanDescription(got)) == "" {
+				t.Errorf("fillDescription() has no changelog entry: %q", got)
+			}
+		})
+	}
+}
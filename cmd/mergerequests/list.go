@@ -0,0 +1,400 @@
+package mergerequests
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"mpg-gitlab/cmd/types"
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// listFilter holds the merge request filters shared by project and global listing
+type listFilter struct {
+	State          string
+	TargetBranch   string
+	SourceBranch   string
+	Search         string
+	Milestone      string
+	Author         string
+	Reviewer       string
+	Assignee       *gitlab.AssigneeIDValue
+	Labels         gitlab.Labels
+	NotLabels      gitlab.Labels
+	Draft          *bool
+	Approved       *bool
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	UpdatedAfter   *time.Time
+	UpdatedBefore  *time.Time
+	MergedAfter    *time.Time
+	MergedBefore   *time.Time
+	PipelineStatus string
+	OrderBy        string
+	Sort           string
+	Scope          string // Which MRs an instance-wide listing covers, empty for the API default
+	Limit          int    // Maximum number of results, 0 for all
+}
+
+// DefaultListLimit is how many merge requests the list command shows by default
+const DefaultListLimit = 20
+
+// addListFlags registers the filter flags used by the list command
+func addListFlags(cmd *cobra.Command) {
+	cmd.Flags().IntP("project", "p", 0, "Project ID")
+	cmd.Flags().StringP("state", "s", "", "MR state (opened/closed/merged/locked/all)")
+	cmd.Flags().StringP("target", "t", "", "Target branch")
+	cmd.Flags().String("source", "", "Source branch")
+	cmd.Flags().StringP("author", "a", "", "Author username")
+	cmd.Flags().String("assignee", "", "Assignee username (or none/any)")
+	cmd.Flags().String("reviewer", "", "Reviewer username (or none/any)")
+	cmd.Flags().StringP("labels", "l", "", "Comma-separated labels the MR must have")
+	cmd.Flags().String("not-labels", "", "Comma-separated labels the MR must not have")
+	cmd.Flags().String("milestone", "", "Milestone title (or none/any)")
+	cmd.Flags().Bool("draft", false, "Only draft MRs (use --draft=false for non-draft MRs)")
+	cmd.Flags().Bool("approved", false, "Only approved MRs (use --approved=false for unapproved MRs)")
+	cmd.Flags().String("search", "", "Search in title and description")
+	cmd.Flags().String("created-after", "", "Created on or after date (YYYY-MM-DD)")
+	cmd.Flags().String("created-before", "", "Created before date (YYYY-MM-DD)")
+	cmd.Flags().String("updated-after", "", "Updated on or after date (YYYY-MM-DD)")
+	cmd.Flags().String("updated-before", "", "Updated before date (YYYY-MM-DD)")
+	cmd.Flags().String("merged-after", "", "Merged on or after date (YYYY-MM-DD)")
+	cmd.Flags().String("merged-before", "", "Merged before date (YYYY-MM-DD)")
+	cmd.Flags().String("pipeline", "", "Head pipeline status (success/failed/running/pending/canceled/skipped/manual)")
+	cmd.Flags().String("order-by", "", "Order by field (created_at/updated_at/title)")
+	cmd.Flags().String("sort", "", "Sort order (asc/desc)")
+	cmd.Flags().String("scope", "", "Without --project, list MRs created_by_me/assigned_to_me/all (default created_by_me)")
+	cmd.Flags().Int("limit", DefaultListLimit, "Maximum number of merge requests to show (0 for all)")
+	cmd.Flags().BoolP("json", "j", false, "Output as JSON")
+}
+
+// parseListFilter builds a listFilter from the list command flags
+func parseListFilter(cmd *cobra.Command) (*listFilter, error) {
+	f := &listFilter{}
+	flags := cmd.Flags()
+
+	f.State, _ = flags.GetString("state")
+	f.TargetBranch, _ = flags.GetString("target")
+	f.SourceBranch, _ = flags.GetString("source")
+	f.Search, _ = flags.GetString("search")
+	f.Author, _ = flags.GetString("author")
+	f.PipelineStatus, _ = flags.GetString("pipeline")
+
+	if milestone, _ := flags.GetString("milestone"); milestone != "" {
+		f.Milestone = normalizeAnyNone(milestone)
+	}
+	if reviewer, _ := flags.GetString("reviewer"); reviewer != "" {
+		f.Reviewer = strings.TrimPrefix(reviewer, "@")
+	}
+	if assignee, _ := flags.GetString("assignee"); assignee != "" {
+		switch normalizeAnyNone(assignee) {
+		case "None":
+			f.Assignee = gitlab.AssigneeID(gitlab.UserIDNone)
+		case "Any":
+			f.Assignee = gitlab.AssigneeID(gitlab.UserIDAny)
+		default:
+			id, err := utils.ResolveUserID(assignee)
+			if err != nil {
+				return nil, err
+			}
+			f.Assignee = gitlab.AssigneeID(id)
+		}
+	}
+
+	if labels, _ := flags.GetString("labels"); labels != "" {
+		f.Labels = gitlab.Labels(utils.SplitList(labels))
+	}
+	if notLabels, _ := flags.GetString("not-labels"); notLabels != "" {
+		f.NotLabels = gitlab.Labels(utils.SplitList(notLabels))
+	}
+
+	if flags.Changed("draft") {
+		draft, _ := flags.GetBool("draft")
+		f.Draft = gitlab.Bool(draft)
+	}
+	if flags.Changed("approved") {
+		approved, _ := flags.GetBool("approved")
+		f.Approved = gitlab.Bool(approved)
+	}
+
+	dates := []struct {
+		flag   string
+		target **time.Time
+	}{
+		{"created-after", &f.CreatedAfter},
+		{"created-before", &f.CreatedBefore},
+		{"updated-after", &f.UpdatedAfter},
+		{"updated-before", &f.UpdatedBefore},
+		{"merged-after", &f.MergedAfter},
+		{"merged-before", &f.MergedBefore},
+	}
+	for _, d := range dates {
+		value, _ := flags.GetString(d.flag)
+		parsed, err := parseDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %v", d.flag, err)
+		}
+		*d.target = parsed
+	}
+
+	// Merged date windows only make sense for merged MRs
+	if (f.MergedAfter != nil || f.MergedBefore != nil) && f.State == "" {
+		f.State = "merged"
+	}
+
+	f.OrderBy, _ = flags.GetString("order-by")
+	switch f.OrderBy {
+	case "", "created_at", "updated_at", "title":
+	default:
+		return nil, fmt.Errorf("invalid --order-by %q, expected created_at, updated_at or title", f.OrderBy)
+	}
+	f.Sort, _ = flags.GetString("sort")
+	switch f.Sort {
+	case "", "asc", "desc":
+	default:
+		return nil, fmt.Errorf("invalid --sort %q, expected asc or desc", f.Sort)
+	}
+	f.Scope, _ = flags.GetString("scope")
+	switch f.Scope {
+	case "", "created_by_me", "assigned_to_me", "all":
+	default:
+		return nil, fmt.Errorf("invalid --scope %q, expected created_by_me, assigned_to_me or all", f.Scope)
+	}
+	f.Limit, _ = flags.GetInt("limit")
+	if f.Limit < 0 {
+		return nil, fmt.Errorf("invalid --limit %d, expected 0 or more", f.Limit)
+	}
+
+	return f, nil
+}
+
+// parseDate parses a YYYY-MM-DD date, returning nil for an empty value
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid YYYY-MM-DD date", value)
+	}
+	return &parsed, nil
+}
+
+// normalizeAnyNone maps the none/any keywords to the casing the API expects
+func normalizeAnyNone(value string) string {
+	switch strings.ToLower(value) {
+	case "none":
+		return "None"
+	case "any":
+		return "Any"
+	}
+	return value
+}
+
+// approvedBy translates the approval filter into the approved_by_ids API value
+func (f *listFilter) approvedBy() *gitlab.ApproverIDsValue {
+	if f.Approved == nil {
+		return nil
+	}
+	if *f.Approved {
+		return gitlab.ApproverIDs(gitlab.UserIDAny)
+	}
+	return gitlab.ApproverIDs(gitlab.UserIDNone)
+}
+
+// reviewerID translates the none/any reviewer keywords into the reviewer_id API value
+func (f *listFilter) reviewerID() (*gitlab.ReviewerIDValue, *string) {
+	switch normalizeAnyNone(f.Reviewer) {
+	case "":
+		return nil, nil
+	case "None":
+		return gitlab.ReviewerID(gitlab.UserIDNone), nil
+	case "Any":
+		return gitlab.ReviewerID(gitlab.UserIDAny), nil
+	}
+	return nil, gitlab.String(f.Reviewer)
+}
+
+// projectOptions converts the filter into project-scoped list options
+func (f *listFilter) projectOptions() *gitlab.ListProjectMergeRequestsOptions {
+	reviewerID, reviewerUsername := f.reviewerID()
+	return &gitlab.ListProjectMergeRequestsOptions{
+		State:            optionalString(f.State),
+		TargetBranch:     optionalString(f.TargetBranch),
+		SourceBranch:     optionalString(f.SourceBranch),
+		Search:           optionalString(f.Search),
+		Milestone:        optionalString(f.Milestone),
+		AuthorUsername:   optionalString(f.Author),
+		AssigneeID:       f.Assignee,
+		ReviewerID:       reviewerID,
+		ReviewerUsername: reviewerUsername,
+		Labels:           optionalLabels(f.Labels),
+		NotLabels:        optionalLabels(f.NotLabels),
+		Draft:            f.Draft,
+		ApprovedByIDs:    f.approvedBy(),
+		CreatedAfter:     f.CreatedAfter,
+		CreatedBefore:    f.CreatedBefore,
+		UpdatedAfter:     f.updatedAfter(),
+		UpdatedBefore:    f.UpdatedBefore,
+		OrderBy:          optionalString(f.OrderBy),
+		Sort:             optionalString(f.Sort),
+	}
+}
+
+// globalOptions converts the filter into instance-wide list options
+func (f *listFilter) globalOptions() *gitlab.ListMergeRequestsOptions {
+	reviewerID, reviewerUsername := f.reviewerID()
+	return &gitlab.ListMergeRequestsOptions{
+		State:            optionalString(f.State),
+		TargetBranch:     optionalString(f.TargetBranch),
+		SourceBranch:     optionalString(f.SourceBranch),
+		Search:           optionalString(f.Search),
+		Milestone:        optionalString(f.Milestone),
+		AuthorUsername:   optionalString(f.Author),
+		AssigneeID:       f.Assignee,
+		ReviewerID:       reviewerID,
+		ReviewerUsername: reviewerUsername,
+		Labels:           optionalLabels(f.Labels),
+		NotLabels:        optionalLabels(f.NotLabels),
+		Draft:            f.Draft,
+		ApprovedByIDs:    f.approvedBy(),
+		CreatedAfter:     f.CreatedAfter,
+		CreatedBefore:    f.CreatedBefore,
+		UpdatedAfter:     f.updatedAfter(),
+		UpdatedBefore:    f.UpdatedBefore,
+		OrderBy:          optionalString(f.OrderBy),
+		Sort:             optionalString(f.Sort),
+		Scope:            optionalString(f.Scope),
+	}
+}
+
+// updatedAfter returns the updated_after bound sent to the API
+// Merging updates an MR, so a merged-after date also bounds the update time; this
+// keeps the pages scanned for the client-side merged date filter to a minimum.
+func (f *listFilter) updatedAfter() *time.Time {
+	if f.MergedAfter != nil && (f.UpdatedAfter == nil || f.UpdatedAfter.Before(*f.MergedAfter)) {
+		return f.MergedAfter
+	}
+	return f.UpdatedAfter
+}
+
+// matchesMergeWindow reports whether the MR was merged inside the merged date window
+// The list API has no merged date filters, so this is applied client-side
+func (f *listFilter) matchesMergeWindow(mr *gitlab.MergeRequest) bool {
+	if f.MergedAfter == nil && f.MergedBefore == nil {
+		return true
+	}
+	if mr.MergedAt == nil {
+		return false
+	}
+	if f.MergedAfter != nil && mr.MergedAt.Before(*f.MergedAfter) {
+		return false
+	}
+	if f.MergedBefore != nil && !mr.MergedAt.Before(*f.MergedBefore) {
+		return false
+	}
+	return true
+}
+
+// applyClientFilters applies the filters the list API cannot evaluate, keeping at most
+// limit matches (0 for all)
+// Pipeline status requires fetching each MR, since list results carry no head pipeline,
+// so the MRs after the limit is met are not fetched.
+func (f *listFilter) applyClientFilters(mrs []*gitlab.MergeRequest, limit int) ([]*gitlab.MergeRequest, error) {
+	var result []*gitlab.MergeRequest
+	for _, mr := range mrs {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		if !f.matchesMergeWindow(mr) {
+			continue
+		}
+		if f.PipelineStatus != "" {
+			detail, _, err := client.MergeRequests.GetMergeRequest(mr.ProjectID, mr.IID, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get merge request !%d: %v", mr.IID, err)
+			}
+			if detail.HeadPipeline == nil || !strings.EqualFold(detail.HeadPipeline.Status, f.PipelineStatus) {
+				continue
+			}
+		}
+		result = append(result, mr)
+	}
+	return result, nil
+}
+
+// ListMergeRequests lists merge requests matching the filter
+// When projectID is 0 the listing is instance-wide. Pages are fetched until the
+// limit is reached after the client-side filters, or the results run out.
+func ListMergeRequests(projectID int, f *listFilter) ([]*gitlab.MergeRequest, error) {
+	projectOpts, globalOpts := f.projectOptions(), f.globalOptions()
+	listOpts := gitlab.ListOptions{PerPage: 100}
+
+	var result []*gitlab.MergeRequest
+	for {
+		var page []*gitlab.MergeRequest
+		var resp *gitlab.Response
+		var err error
+		if projectID != 0 {
+			projectOpts.ListOptions = listOpts
+			page, resp, err = client.MergeRequests.ListProjectMergeRequests(projectID, projectOpts)
+		} else {
+			globalOpts.ListOptions = listOpts
+			page, resp, err = client.MergeRequests.ListMergeRequests(globalOpts)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list merge requests: %v", err)
+		}
+
+		remaining := 0
+		if f.Limit > 0 {
+			remaining = f.Limit - len(result)
+		}
+		matched, err := f.applyClientFilters(page, remaining)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, matched...)
+
+		if f.Limit > 0 && len(result) >= f.Limit {
+			return result, nil
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// MergeRequestsAsJSON converts merge requests to our type and returns formatted JSON
+func MergeRequestsAsJSON(mrs []*gitlab.MergeRequest) (string, error) {
+	result := make([]types.MergeRequest, len(mrs))
+	for i, mr := range mrs {
+		result[i] = *convertGitLabMR(mr)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal merge requests: %v", err)
+	}
+
+	return string(jsonData), nil
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return gitlab.String(value)
+}
+
+func optionalLabels(labels gitlab.Labels) *gitlab.Labels {
+	if len(labels) == 0 {
+		return nil
+	}
+	return &labels
+}
@@ -0,0 +1,187 @@
+package mergerequests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    *time.Time
+		wantErr bool
+	}{
+		{
+			name:  "empty value",
+			value: "",
+			want:  nil,
+		},
+		{
+			name:  "valid date",
+			value: "2026-10-01",
+			want:  gitlab.Time(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:    "invalid month",
+			value:   "2026-13-01",
+			wantErr: true,
+		},
+		{
+			name:    "wrong format",
+			value:   "01/10/2026",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("parseDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListFilterMatchesMergeWindow(t *testing.T) {
+	after := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	filter := &listFilter{MergedAfter: &after, MergedBefore: &before}
+
+	tests := []struct {
+		name     string
+		mergedAt *time.Time
+		want     bool
+	}{
+		{
+			name:     "not merged",
+			mergedAt: nil,
+			want:     false,
+		},
+		{
+			name:     "merged inside window",
+			mergedAt: gitlab.Time(time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC)),
+			want:     true,
+		},
+		{
+			name:     "merged on start date",
+			mergedAt: gitlab.Time(after),
+			want:     true,
+		},
+		{
+			name:     "merged before window",
+			mergedAt: gitlab.Time(time.Date(2026, 9, 30, 23, 0, 0, 0, time.UTC)),
+			want:     false,
+		},
+		{
+			name:     "merged on end date",
+			mergedAt: gitlab.Time(before),
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := &gitlab.MergeRequest{MergedAt: tt.mergedAt}
+			if got := filter.matchesMergeWindow(mr); got != tt.want {
+				t.Errorf("matchesMergeWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListFilterApplyClientFiltersLimit(t *testing.T) {
+	after := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	filter := &listFilter{MergedAfter: &after}
+	merged := gitlab.Time(time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC))
+	mrs := []*gitlab.MergeRequest{{IID: 1, MergedAt: merged}, {IID: 2}, {IID: 3, MergedAt: merged}, {IID: 4, MergedAt: merged}}
+
+	got, err := filter.applyClientFilters(mrs, 2)
+	if err != nil {
+		t.Fatalf("applyClientFilters() error = %v", err)
+	}
+	if len(got) != 2 || got[0].IID != 1 || got[1].IID != 3 {
+		t.Errorf("applyClientFilters() with limit 2 kept %d MRs, want !1 and !3", len(got))
+	}
+	if got, _ := filter.applyClientFilters(mrs, 0); len(got) != 3 {
+		t.Errorf("applyClientFilters() without limit kept %d MRs, want 3", len(got))
+	}
+}
+
+func TestListFilterOptionsMatch(t *testing.T) {
+	created := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	filter := &listFilter{
+		State:        "opened",
+		TargetBranch: "main",
+		Author:       "alice",
+		Reviewer:     "none",
+		Labels:       gitlab.Labels{"backend"},
+		Draft:        gitlab.Bool(false),
+		CreatedAfter: &created,
+		OrderBy:      "updated_at",
+		Sort:         "asc",
+	}
+
+	project := filter.projectOptions()
+	global := filter.globalOptions()
+
+	if *project.State != *global.State || *project.TargetBranch != *global.TargetBranch {
+		t.Errorf("state/target differ between project and global options")
+	}
+	if *project.AuthorUsername != "alice" || *global.AuthorUsername != "alice" {
+		t.Errorf("author not set on both options")
+	}
+	if project.ReviewerUsername != nil || project.ReviewerID == nil || global.ReviewerID == nil {
+		t.Errorf("reviewer none keyword should map to reviewer_id")
+	}
+	if len(*project.Labels) != 1 || len(*global.Labels) != 1 {
+		t.Errorf("labels not set on both options")
+	}
+	if *project.Draft || *global.Draft {
+		t.Errorf("draft filter not set on both options")
+	}
+	if !project.CreatedAfter.Equal(created) || !global.CreatedAfter.Equal(created) {
+		t.Errorf("created-after not set on both options")
+	}
+	if *project.OrderBy != "updated_at" || *global.Sort != "asc" {
+		t.Errorf("sort order not set on both options")
+	}
+	if global.Scope != nil {
+		t.Errorf("global scope should be left to the API default, got %q", *global.Scope)
+	}
+	if project.NotLabels != nil || global.Milestone != nil {
+		t.Errorf("unset filters should stay nil")
+	}
+}
+
+func TestListFilterUpdatedAfter(t *testing.T) {
+	early := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter *listFilter
+		want   *time.Time
+	}{
+		{name: "no dates", filter: &listFilter{}, want: nil},
+		{name: "updated only", filter: &listFilter{UpdatedAfter: &early}, want: &early},
+		{name: "merged only", filter: &listFilter{MergedAfter: &late}, want: &late},
+		{name: "merged after is later", filter: &listFilter{UpdatedAfter: &early, MergedAfter: &late}, want: &late},
+		{name: "updated after is later", filter: &listFilter{UpdatedAfter: &late, MergedAfter: &early}, want: &late},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filter.updatedAfter()
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("updatedAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package mergerequests
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"mpg-gitlab/cmd/pipelines"
+	"mpg-gitlab/cmd/types"
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// MergeOptions controls how a merge request is merged
+type MergeOptions struct {
+	Message              string
+	SHA                  string // Only merge if the MR head matches this SHA
+	Squash               bool
+	RemoveSourceBranch   bool
+	WhenPipelineSucceeds bool     // Merge once the head pipeline succeeds instead of now
+	Checks               []string // Preconditions to run, in order
+	MilestoneGroup       string   // The milestone check requires a milestone of this group
+}
+
+// CheckResult is the outcome of a single merge precondition
+type CheckResult struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+}
+
+// mergeCheck evaluates one precondition against a merge request
+type mergeCheck func(projectID int, mr *gitlab.MergeRequest, opts *MergeOptions) (bool, string, error)
+
+// mergeChecks lists the available preconditions by name
+var mergeChecks = map[string]mergeCheck{
+	"blocked":   checkNotBlocked,
+	"changelog": checkChangelogPresent,
+	"milestone": checkMilestoneAssigned,
+	"approvals": checkApprovals,
+	"pipeline":  checkPipeline,
+	"conflicts": checkNoConflicts,
+	"threads":   checkThreadsResolved,
+	"sha":       checkHeadSHA,
+}
+
+// DefaultMergeChecks is the order preconditions run in when none are configured
+var DefaultMergeChecks = []string{"blocked", "conflicts", "changelog", "milestone", "approvals", "pipeline"}
+
+// ValidateMergeChecks verifies that every check name is known
+func ValidateMergeChecks(names []string) error {
+	for _, name := range names {
+		if _, ok := mergeChecks[name]; !ok {
+			var available []string
+			for n := range mergeChecks {
+				available = append(available, n)
+			}
+			sort.Strings(available)
+			return fmt.Errorf("unknown merge check %q (available: %s)", name, strings.Join(available, ", "))
+		}
+	}
+	return nil
+}
+
+// CheckMergePreconditions runs the configured checks against a merge request
+// Every check runs so the caller can report all failures at once
+func CheckMergePreconditions(projectID, mrIID int, opts *MergeOptions) ([]CheckResult, error) {
+	if err := ValidateMergeChecks(opts.Checks); err != nil {
+		return nil, err
+	}
+
+	// A pinned SHA is always verified first, whatever checks are configured
+	checks := opts.Checks
+	if opts.SHA != "" && !containsString(checks, "sha") {
+		checks = append([]string{"sha"}, checks...)
+	}
+
+	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request: %v", err)
+	}
+
+	// Conflict state is stale while GitLab is still checking mergeability
+	if isMergeStatusPending(mr) {
+		mr, err = WaitMergeStatus(projectID, mrIID, utils.WaitOptions{Timeout: mergeStatusTimeout})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var results []CheckResult
+	for _, name := range checks {
+		passed, message, err := mergeChecks[name](projectID, mr, opts)
+		if err != nil {
+			passed, message = false, err.Error()
+		}
+		results = append(results, CheckResult{Name: name, Passed: passed, Message: message})
+	}
+
+	return results, nil
+}
+
+// FailedChecks returns the names of the checks that did not pass
+func FailedChecks(results []CheckResult) []string {
+	var failed []string
+	for _, r := range results {
+		if !r.Passed {
+			failed = append(failed, r.Name)
+		}
+	}
+	return failed
+}
+
+// MergeMergeRequest checks the preconditions and merges the merge request if they all pass
+// The check results are always returned so callers can report them
+func MergeMergeRequest(projectID, mrIID int, opts *MergeOptions) (*gitlab.MergeRequest, []CheckResult, error) {
+	results, err := CheckMergePreconditions(projectID, mrIID, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if failed := FailedChecks(results); len(failed) > 0 {
+		return nil, results, fmt.Errorf("merge preconditions failed: %s", strings.Join(failed, ", "))
+	}
+
+	acceptOpts := &gitlab.AcceptMergeRequestOptions{}
+	if opts.Message != "" {
+		acceptOpts.MergeCommitMessage = gitlab.String(opts.Message)
+	}
+	if opts.SHA != "" {
+		acceptOpts.SHA = gitlab.String(opts.SHA)
+	}
+	if opts.Squash {
+		acceptOpts.Squash = gitlab.Bool(true)
+	}
+	if opts.RemoveSourceBranch {
+		acceptOpts.ShouldRemoveSourceBranch = gitlab.Bool(true)
+	}
+	if opts.WhenPipelineSucceeds {
+		acceptOpts.MergeWhenPipelineSucceeds = gitlab.Bool(true)
+	}
+
+	mr, _, err := client.MergeRequests.AcceptMergeRequest(projectID, mrIID, acceptOpts)
+	if err != nil {
+		return nil, results, fmt.Errorf("failed to merge request: %v", err)
+	}
+
+	return mr, results, nil
+}
+
+func checkNotBlocked(projectID int, mr *gitlab.MergeRequest, opts *MergeOptions) (bool, string, error) {
+	blocked, err := IsBlocked(projectID, mr.IID)
+	if err != nil {
+		return false, "", err
+	}
+	if !blocked {
+		return true, "not blocked", nil
+	}
+
+	reason, _ := GetBlockReason(projectID, mr.IID)
+	if reason == "" {
+		return false, "merge request is blocked", nil
+	}
+	return false, fmt.Sprintf("merge request is blocked: %s", reason), nil
+}
+
+func checkChangelogPresent(projectID int, mr *gitlab.MergeRequest, opts *MergeOptions) (bool, string, error) {
+	policy, _, err := LoadChangelogPolicy(projectID, "", mr.TargetBranch)
+	if err != nil {
+		return false, "", err
+	}
+	result, err := CheckChangelogPolicy(projectID, mr, policy)
+	if err != nil {
+		return false, "", err
+	}
+	return result.Passed, strings.Join(result.Explanation, "; "), nil
+}
+
+func checkMilestoneAssigned(projectID int, mr *gitlab.MergeRequest, opts *MergeOptions) (bool, string, error) {
+	// Report the milestone of the MR as checked, which may have changed since mr was fetched
+	milestone, err := checkedMilestone(projectID, mr.IID, opts.MilestoneGroup)
+	if err != nil {
+		return false, err.Error(), nil
+	}
+	return true, fmt.Sprintf("milestone %s", milestone.Title), nil
+}
+
+func checkApprovals(projectID int, mr *gitlab.MergeRequest, opts *MergeOptions) (bool, string, error) {
+	approvals, err := GetApprovals(projectID, mr.IID)
+	if err != nil {
+		return false, "", err
+	}
+	if approvals.Left > 0 || !approvals.Approved {
+		var pending []string
+		for _, r := range approvals.Rules {
+			if !r.Approved {
+				pending = append(pending, r.Name)
+			}
+		}
+		message := fmt.Sprintf("%d more approval(s) required", approvals.Left)
+		if len(pending) > 0 {
+			message += fmt.Sprintf(" (rules: %s)", strings.Join(pending, ", "))
+		}
+		return false, message, nil
+	}
+	return true, FormatApprovals(approvals), nil
+}
+
+func checkPipeline(projectID int, mr *gitlab.MergeRequest, opts *MergeOptions) (bool, string, error) {
+	pipeline, err := pipelines.MergeRequestHeadPipeline(projectID, mr)
+	if err != nil {
+		return false, "", err
+	}
+	passed, message := pipelineVerdict(pipeline, mr.SHA, opts)
+	return passed, message, nil
+}
+
+// pipelineVerdict decides the pipeline check from the pipeline on the head commit, if any
+func pipelineVerdict(pipeline *types.Pipeline, sha string, opts *MergeOptions) (bool, string) {
+	if pipeline == nil {
+		return false, "no pipeline for the head commit " + shortSHA(sha)
+	}
+
+	switch pipeline.Status {
+	case "success":
+		return true, fmt.Sprintf("pipeline #%d succeeded", pipeline.ID)
+	case "created", "waiting_for_resource", "preparing", "pending", "running":
+		// Merging when the pipeline succeeds only needs a pipeline that can still succeed
+		if opts.WhenPipelineSucceeds {
+			return true, fmt.Sprintf("pipeline #%d is %s", pipeline.ID, pipeline.Status)
+		}
+	}
+	return false, fmt.Sprintf("pipeline #%d is %s", pipeline.ID, pipeline.Status)
+}
+
+func checkNoConflicts(projectID int, mr *gitlab.MergeRequest, opts *MergeOptions) (bool, string, error) {
+	if mr.HasConflicts {
+		return false, fmt.Sprintf("%s has conflicts with %s", mr.SourceBranch, mr.TargetBranch), nil
+	}
+	return true, "no conflicts", nil
+}
+
+func checkThreadsResolved(projectID int, mr *gitlab.MergeRequest, opts *MergeOptions) (bool, string, error) {
+	threads, err := ListThreads(projectID, mr.IID)
+	if err != nil {
+		return false, "", err
+	}
+	if unresolved := UnresolvedThreads(threads); len(unresolved) > 0 {
+		return false, fmt.Sprintf("%d unresolved thread(s)", len(unresolved)), nil
+	}
+	return true, fmt.Sprintf("%d thread(s) resolved", len(threads)), nil
+}
+
+func checkHeadSHA(projectID int, mr *gitlab.MergeRequest, opts *MergeOptions) (bool, string, error) {
+	if opts.SHA == "" {
+		return true, "no SHA pinned", nil
+	}
+	if mr.SHA != opts.SHA {
+		return false, fmt.Sprintf("head is %s, not %s", shortSHA(mr.SHA), shortSHA(opts.SHA)), nil
+	}
+	return true, fmt.Sprintf("head is %s", shortSHA(mr.SHA)), nil
+}
+
+// containsString reports whether a slice contains a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// shortSHA abbreviates a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
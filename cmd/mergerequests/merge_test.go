@@ -0,0 +1,99 @@
+package mergerequests
+
+import (
+	"reflect"
+	"testing"
+
+	"mpg-gitlab/cmd/types"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestPipelineVerdict(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline *types.Pipeline
+		opts     *MergeOptions
+		wantPass bool
+	}{
+		{
+			name:     "no pipeline on head",
+			opts:     &MergeOptions{},
+			wantPass: false,
+		},
+		{
+			name:     "successful pipeline",
+			pipeline: &types.Pipeline{ID: 1, Status: "success"},
+			opts:     &MergeOptions{},
+			wantPass: true,
+		},
+		{
+			name:     "running pipeline",
+			pipeline: &types.Pipeline{ID: 1, Status: "running"},
+			opts:     &MergeOptions{},
+			wantPass: false,
+		},
+		{
+			name:     "running pipeline when pipeline succeeds",
+			pipeline: &types.Pipeline{ID: 1, Status: "running"},
+			opts:     &MergeOptions{WhenPipelineSucceeds: true},
+			wantPass: true,
+		},
+		{
+			name:     "failed pipeline when pipeline succeeds",
+			pipeline: &types.Pipeline{ID: 1, Status: "failed"},
+			opts:     &MergeOptions{WhenPipelineSucceeds: true},
+			wantPass: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passed, message := pipelineVerdict(tt.pipeline, "abc", tt.opts)
+			if passed != tt.wantPass {
+				t.Errorf("pipelineVerdict() = %v (%s), want %v", passed, message, tt.wantPass)
+			}
+		})
+	}
+}
+
+func TestCheckHeadSHAAndConflicts(t *testing.T) {
+	mr := &gitlab.MergeRequest{SHA: "abcdef1234567890", SourceBranch: "feature", TargetBranch: "main"}
+
+	if passed, _, _ := checkHeadSHA(1, mr, &MergeOptions{SHA: "abcdef1234567890"}); !passed {
+		t.Errorf("checkHeadSHA() should pass on matching SHA")
+	}
+	if passed, _, _ := checkHeadSHA(1, mr, &MergeOptions{SHA: "0000000"}); passed {
+		t.Errorf("checkHeadSHA() should fail on different SHA")
+	}
+	if passed, _, _ := checkNoConflicts(1, mr, &MergeOptions{}); !passed {
+		t.Errorf("checkNoConflicts() should pass without conflicts")
+	}
+
+	mr.HasConflicts = true
+	if passed, _, _ := checkNoConflicts(1, mr, &MergeOptions{}); passed {
+		t.Errorf("checkNoConflicts() should fail with conflicts")
+	}
+}
+
+func TestValidateMergeChecks(t *testing.T) {
+	if err := ValidateMergeChecks(DefaultMergeChecks); err != nil {
+		t.Errorf("ValidateMergeChecks() default checks error = %v", err)
+	}
+	if err := ValidateMergeChecks([]string{"blocked", "reviews"}); err == nil {
+		t.Errorf("ValidateMergeChecks() expected error for unknown check")
+	}
+}
+
+func TestFailedChecks(t *testing.T) {
+	results := []CheckResult{
+		{Name: "blocked", Passed: true},
+		{Name: "changelog", Passed: false},
+		{Name: "pipeline", Passed: false},
+	}
+
+	want := []string{"changelog", "pipeline"}
+	if got := FailedChecks(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("FailedChecks() = %v, want %v", got, want)
+	}
+}
@@ -1,11 +1,16 @@
 package mergerequests
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
+	"mpg-gitlab/cmd/pipelines"
 	"mpg-gitlab/cmd/utils"
 
 	"github.com/spf13/cobra"
@@ -94,18 +99,108 @@ var (
 		Run:   runCheckMilestone,
 	}
 
-	addChangelogCmd = &cobra.Command{
-		Use:   "add-changelog",
-		Short: "Add changelog entry from merge request to its milestone",
-		Run:   runAddChangelog,
-	}
-
 	getMRFromCommitCmd = &cobra.Command{
 		Use:   "get-mr-from-commit",
 		Short: "Get merge request IID from commit",
 		Run:   runGetMRFromCommit,
 	}
 
+	queueCmd = &cobra.Command{
+		Use:   "queue",
+		Short: "Merge queued merge requests one at a time, rebasing each onto the latest target",
+		Run:   runQueue,
+	}
+
+	rebaseCmd = &cobra.Command{
+		Use:   "rebase",
+		Short: "Rebase a merge request onto its target branch and wait for the result",
+		Run:   runRebase,
+	}
+
+	conflictsCmd = &cobra.Command{
+		Use:   "conflicts",
+		Short: "List files changed both in a merge request and on its target branch",
+		Run:   runConflicts,
+	}
+
+	scanConflictsCmd = &cobra.Command{
+		Use:   "scan-conflicts",
+		Short: "Flag open merge requests that no longer merge cleanly into a branch",
+		Run:   runScanConflicts,
+	}
+
+	diffCmd = &cobra.Command{
+		Use:   "diff",
+		Short: "Show the changes of a merge request",
+		Run:   runDiff,
+	}
+
+	approvalsCmd = &cobra.Command{
+		Use:   "approvals",
+		Short: "Show the approval state of a merge request",
+		Run:   runApprovals,
+	}
+
+	approveCmd = &cobra.Command{
+		Use:   "approve",
+		Short: "Approve a merge request",
+		Run:   runApprove,
+	}
+
+	unapproveCmd = &cobra.Command{
+		Use:   "unapprove",
+		Short: "Remove your approval from a merge request",
+		Run:   runUnapprove,
+	}
+
+	assignReviewersCmd = &cobra.Command{
+		Use:   "assign-reviewers",
+		Short: "Assign reviewers from a pool using round-robin or load-based selection",
+		Run:   runAssignReviewers,
+	}
+
+	threadsCmd = &cobra.Command{
+		Use:   "threads",
+		Short: "List discussion threads of a merge request",
+		Run:   runThreads,
+	}
+
+	threadsReplyCmd = &cobra.Command{
+		Use:   "reply",
+		Short: "Reply to a discussion thread",
+		Run:   runThreadsReply,
+	}
+
+	threadsResolveCmd = &cobra.Command{
+		Use:   "resolve",
+		Short: "Resolve a discussion thread",
+		Run:   runThreadsResolve,
+	}
+
+	threadsUnresolveCmd = &cobra.Command{
+		Use:   "unresolve",
+		Short: "Reopen a resolved discussion thread",
+		Run:   runThreadsResolve,
+	}
+
+	checkThreadsCmd = &cobra.Command{
+		Use:   "check-threads",
+		Short: "Fail when a merge request has unresolved discussion threads",
+		Run:   runCheckThreads,
+	}
+
+	pipelinesCmd = &cobra.Command{
+		Use:   "pipelines",
+		Short: "List the pipelines of a merge request",
+		Run:   runPipelines,
+	}
+
+	checkPipelineCmd = &cobra.Command{
+		Use:   "check-pipeline",
+		Short: "Fail unless the pipeline on the merge request head succeeded",
+		Run:   runCheckPipeline,
+	}
+
 	addCurrentMilestoneCmd = &cobra.Command{
 		Use:   "add-current-milestone",
 		Short: "Add 'Current' milestone to MR and linked issues",
@@ -117,16 +212,15 @@ func init() {
 	client = utils.GetClient()
 
 	// Add subcommands
-	MergeRequestsCmd.AddCommand(listCmd, getCmd, createCmd, updateCmd, mergeCmd, closeCmd, getDescriptionCmd, getIssuesCmd, checkChangelogCmd, blockCmd, unblockCmd, checkMilestoneCmd, addChangelogCmd, getMRFromCommitCmd, addCurrentMilestoneCmd)
+	MergeRequestsCmd.AddCommand(listCmd, getCmd, createCmd, updateCmd, mergeCmd, closeCmd, getDescriptionCmd, getIssuesCmd, checkChangelogCmd, blockCmd, unblockCmd, checkMilestoneCmd, getMRFromCommitCmd, addCurrentMilestoneCmd, queueCmd, rebaseCmd, conflictsCmd, scanConflictsCmd, diffCmd, approvalsCmd, approveCmd, unapproveCmd, assignReviewersCmd, threadsCmd, checkThreadsCmd, pipelinesCmd, checkPipelineCmd)
 
 	// List flags
-	listCmd.Flags().IntP("project", "p", 0, "Project ID")
-	listCmd.Flags().StringP("state", "s", "", "MR state (opened/closed/merged/all)")
-	listCmd.Flags().StringP("target", "t", "", "Target branch")
+	addListFlags(listCmd)
 
 	// Get flags
 	getCmd.Flags().IntP("project", "p", 0, "Project ID")
 	getCmd.Flags().IntP("mr", "m", 0, "Merge Request IID")
+	getCmd.Flags().BoolP("json", "j", false, "Output as JSON")
 	getCmd.MarkFlagRequired("mr")
 
 	// Create flags
@@ -136,8 +230,19 @@ func init() {
 	createCmd.Flags().StringP("title", "T", "", "Merge request title")
 	createCmd.Flags().StringP("description", "d", "", "Merge request description")
 	createCmd.Flags().BoolP("remove-source", "r", false, "Remove source branch when merged")
-	createCmd.MarkFlagRequired("source")
-	createCmd.MarkFlagRequired("title")
+	createCmd.Flags().StringP("labels", "l", "", "Comma-separated list of labels")
+	createCmd.Flags().String("milestone", "", "Milestone title or ID")
+	createCmd.Flags().StringP("assignees", "a", "", "Comma-separated list of assignee usernames")
+	createCmd.Flags().String("reviewers", "", "Comma-separated list of reviewer usernames")
+	createCmd.Flags().Bool("draft", false, "Create the merge request as draft")
+	createCmd.Flags().Bool("squash", false, "Squash commits when merged")
+	createCmd.Flags().Bool("allow-collaboration", false, "Allow commits from members who can merge to the target branch")
+	createCmd.Flags().Int("target-project", 0, "Target project ID (for merge requests from a fork)")
+	createCmd.Flags().BoolP("fill", "f", false, "Fill source branch, title and description from the local git branch")
+	createCmd.Flags().String("template", "", "Description template name from .gitlab/merge_request_templates")
+	createCmd.Flags().String("template-dir", "", "Local directory to load the description template from")
+	createCmd.Flags().String("category", "", "Changelog category for the template (Feature/Improvement/Fix/Infra/No-Changelog-Entry)")
+	createCmd.MarkFlagsMutuallyExclusive("template", "description")
 
 	// Update flags
 	updateCmd.Flags().IntP("project", "p", 0, "Project ID")
@@ -151,6 +256,13 @@ func init() {
 	mergeCmd.Flags().IntP("project", "p", 0, "Project ID")
 	mergeCmd.Flags().IntP("mr", "m", 0, "Merge Request IID")
 	mergeCmd.Flags().StringP("message", "M", "", "Merge commit message")
+	mergeCmd.Flags().String("sha", "", "Only merge if the MR head matches this SHA")
+	mergeCmd.Flags().Bool("squash", false, "Squash commits on merge")
+	mergeCmd.Flags().Bool("remove-source-branch", false, "Remove the source branch after merge")
+	mergeCmd.Flags().Bool("when-pipeline-succeeds", false, "Merge when the head pipeline succeeds")
+	mergeCmd.Flags().StringSlice("checks", DefaultMergeChecks, "Preconditions to run before merging")
+	mergeCmd.Flags().StringSlice("skip-checks", nil, "Preconditions to skip")
+	mergeCmd.Flags().String("milestone-group", "", "Milestone check requires a milestone of this group (ID or path)")
 	mergeCmd.MarkFlagRequired("mr")
 
 	// Close flags
@@ -169,6 +281,9 @@ func init() {
 
 	// Check changelog flags
 	checkChangelogCmd.Flags().IntP("mr", "m", 0, "Merge Request IID")
+	checkChangelogCmd.Flags().IntP("project", "p", 0, "Project ID")
+	checkChangelogCmd.Flags().String("policy", "", "Changelog policy file (default "+DefaultPolicyPath+", locally or in the repository)")
+	checkChangelogCmd.Flags().BoolP("json", "j", false, "Output the result as JSON")
 	checkChangelogCmd.MarkFlagRequired("mr")
 
 	// Block flags
@@ -185,11 +300,7 @@ func init() {
 	checkMilestoneCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
 	checkMilestoneCmd.MarkFlagRequired("mr")
 	checkMilestoneCmd.Flags().IntP("project", "p", 0, "Project ID")
-
-	// Add changelog flags
-	addChangelogCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
-	addChangelogCmd.Flags().IntP("project", "p", 0, "Project ID")
-	addChangelogCmd.MarkFlagRequired("mr")
+	checkMilestoneCmd.Flags().StringP("group", "g", "", "Require a milestone of this group (ID or path)")
 
 	// Get MR from commit flags
 	getMRFromCommitCmd.Flags().StringP("commit", "c", "", "Commit ID (SHA)")
@@ -200,54 +311,145 @@ func init() {
 	// Add current milestone flags
 	addCurrentMilestoneCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
 	addCurrentMilestoneCmd.Flags().IntP("project", "p", 0, "Project ID")
+	addCurrentMilestoneCmd.Flags().String("strategy", StrategyTitle, "How to find the current milestone ("+strings.Join(MilestoneStrategies, "/")+")")
+	addCurrentMilestoneCmd.Flags().String("match", "Current", "Title, title pattern or variable name used by the strategy")
+	addCurrentMilestoneCmd.Flags().Bool("project-only", false, "Ignore group milestones")
+	addCurrentMilestoneCmd.Flags().Bool("dry-run", false, "Only show which milestone would be added")
 	addCurrentMilestoneCmd.MarkFlagRequired("mr")
 
+	// Queue flags
+	queueCmd.Flags().IntP("project", "p", 0, "Project ID")
+	queueCmd.Flags().StringP("target", "t", "main", "Target branch of the queue")
+	queueCmd.Flags().StringP("label", "l", "merge-queue", "Label marking merge requests as queued")
+	queueCmd.Flags().IntSlice("mr", nil, "Explicit merge request IIDs to queue, in order (instead of --label)")
+	queueCmd.Flags().String("order", "", "Queue order (created/updated/iid, default created or --mr order)")
+	queueCmd.Flags().Bool("squash", false, "Squash commits on merge")
+	queueCmd.Flags().Bool("remove-source-branch", false, "Remove source branches after merge")
+	queueCmd.Flags().StringSlice("checks", DefaultMergeChecks, "Preconditions to run before each merge")
+	queueCmd.Flags().Duration("rebase-timeout", 10*time.Minute, "Maximum time to wait for a rebase")
+	queueCmd.Flags().Duration("pipeline-timeout", time.Hour, "Maximum time to wait for a pipeline")
+	queueCmd.Flags().Bool("dry-run", false, "Only show the queue order")
+
+	// Rebase flags
+	rebaseCmd.Flags().IntP("project", "p", 0, "Project ID")
+	rebaseCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	rebaseCmd.Flags().Duration("timeout", 10*time.Minute, "Maximum time to wait for the rebase")
+	rebaseCmd.MarkFlagRequired("mr")
+
+	// Conflicts flags
+	conflictsCmd.Flags().IntP("project", "p", 0, "Project ID")
+	conflictsCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	conflictsCmd.MarkFlagRequired("mr")
+
+	// Scan conflicts flags
+	scanConflictsCmd.Flags().IntP("project", "p", 0, "Project ID")
+	scanConflictsCmd.Flags().StringP("target", "t", "main", "Target branch to scan merge requests for")
+	scanConflictsCmd.Flags().StringP("label", "l", "conflict", "Label to add to conflicting merge requests (empty to disable)")
+	scanConflictsCmd.Flags().Bool("comment", false, "Comment on merge requests that newly became conflicting")
+	scanConflictsCmd.Flags().Bool("dry-run", false, "Only report conflicts, don't label or comment")
+	scanConflictsCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+
+	// Diff flags
+	diffCmd.Flags().IntP("project", "p", 0, "Project ID")
+	diffCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	diffCmd.Flags().Bool("name-only", false, "Only show the names of changed files")
+	diffCmd.Flags().Bool("stat", false, "Show a diffstat instead of the diff")
+	diffCmd.Flags().StringSlice("path", nil, "Only show files matching these paths (directories, files or globs)")
+	diffCmd.Flags().BoolP("json", "j", false, "Output changed files as JSON")
+	diffCmd.MarkFlagRequired("mr")
+	diffCmd.MarkFlagsMutuallyExclusive("name-only", "stat", "json")
+
+	// Approval flags
+	approvalsCmd.Flags().IntP("project", "p", 0, "Project ID")
+	approvalsCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	approvalsCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	approvalsCmd.MarkFlagRequired("mr")
+
+	approveCmd.Flags().IntP("project", "p", 0, "Project ID")
+	approveCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	approveCmd.Flags().String("sha", "", "Only approve if the MR head matches this SHA")
+	approveCmd.MarkFlagRequired("mr")
+
+	unapproveCmd.Flags().IntP("project", "p", 0, "Project ID")
+	unapproveCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	unapproveCmd.MarkFlagRequired("mr")
+
+	// Assign reviewers flags
+	assignReviewersCmd.Flags().IntP("project", "p", 0, "Project ID")
+	assignReviewersCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	assignReviewersCmd.Flags().String("config", "", "Reviewer config file (default "+DefaultReviewerConfigPath+", locally or in the repository)")
+	assignReviewersCmd.Flags().String("pool", "", "Comma-separated list of candidate reviewer usernames")
+	assignReviewersCmd.Flags().String("out-of-office", "", "Comma-separated list of unavailable usernames")
+	assignReviewersCmd.Flags().IntP("count", "n", 0, "Number of reviewers the merge request should have (default 1)")
+	assignReviewersCmd.Flags().String("strategy", "", "Selection strategy (round-robin/load, default round-robin)")
+	assignReviewersCmd.Flags().Bool("codeowners", false, "Prefer CODEOWNERS of the changed files")
+	assignReviewersCmd.Flags().Bool("dry-run", false, "Only show who would be assigned")
+	assignReviewersCmd.MarkFlagRequired("mr")
+
+	// Threads flags
+	threadsCmd.AddCommand(threadsReplyCmd, threadsResolveCmd, threadsUnresolveCmd)
+	threadsCmd.Flags().IntP("project", "p", 0, "Project ID")
+	threadsCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	threadsCmd.Flags().Bool("unresolved", false, "Only list unresolved threads")
+	threadsCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	threadsCmd.MarkFlagRequired("mr")
+
+	for _, c := range []*cobra.Command{threadsReplyCmd, threadsResolveCmd, threadsUnresolveCmd} {
+		c.Flags().IntP("project", "p", 0, "Project ID")
+		c.Flags().IntP("mr", "m", 0, "Merge request IID")
+		c.Flags().StringP("thread", "t", "", "Thread ID (or a unique prefix)")
+		c.MarkFlagRequired("mr")
+		c.MarkFlagRequired("thread")
+	}
+	threadsReplyCmd.Flags().StringP("body", "b", "", "Reply text")
+	threadsReplyCmd.Flags().Bool("resolve", false, "Resolve the thread after replying")
+	threadsReplyCmd.MarkFlagRequired("body")
+
+	// Check threads flags
+	checkThreadsCmd.Flags().IntP("project", "p", 0, "Project ID")
+	checkThreadsCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	checkThreadsCmd.Flags().Bool("block", false, "Block the MR while threads are unresolved, unblock once resolved")
+	checkThreadsCmd.MarkFlagRequired("mr")
+
+	// Pipelines flags
+	pipelinesCmd.Flags().IntP("project", "p", 0, "Project ID")
+	pipelinesCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	pipelinesCmd.Flags().IntP("limit", "l", 10, "Maximum number of pipelines to show")
+	pipelinesCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	pipelinesCmd.MarkFlagRequired("mr")
+
+	// Check pipeline flags
+	checkPipelineCmd.Flags().IntP("project", "p", 0, "Project ID")
+	checkPipelineCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	checkPipelineCmd.MarkFlagRequired("mr")
+
 	// Add command to parent
-	MergeRequestsCmd.AddCommand(checkMilestoneCmd, addChangelogCmd, getMRFromCommitCmd, addCurrentMilestoneCmd)
+	MergeRequestsCmd.AddCommand(checkMilestoneCmd, getMRFromCommitCmd, addCurrentMilestoneCmd)
 }
 
 func runList(cmd *cobra.Command, args []string) {
-	// If running in CI, scope to current project
-	if projectID, _ := utils.GetProjectID(cmd); projectID != 0 {
-		// Create project-specific options
-		projectOpts := &gitlab.ListProjectMergeRequestsOptions{}
-		
-		// Copy over the filter options
-		if state, _ := cmd.Flags().GetString("state"); state != "" {
-			projectOpts.State = gitlab.String(state)
-		}
-		if target, _ := cmd.Flags().GetString("target"); target != "" {
-			projectOpts.TargetBranch = gitlab.String(target)
-		}
-
-		// For merge requests, we need to list them within the project
-		mrs, _, err := client.MergeRequests.ListProjectMergeRequests(projectID, projectOpts)
-		if err != nil {
-			log.Fatalf("Failed to list merge requests: %v", err)
-		}
-
-		for _, mr := range mrs {
-			fmt.Printf("#%d: [%s] %s\n", mr.IID, mr.State, mr.Title)
-			fmt.Printf("  %s -> %s\n", mr.SourceBranch, mr.TargetBranch)
-		}
-		return
+	filter, err := parseListFilter(cmd)
+	if err != nil {
+		log.Fatalf("Invalid filter: %v", err)
 	}
 
-	// If no project ID, use global options
-	opts := &gitlab.ListMergeRequestsOptions{}
-	if state, _ := cmd.Flags().GetString("state"); state != "" {
-		opts.State = gitlab.String(state)
-	}
-	if target, _ := cmd.Flags().GetString("target"); target != "" {
-		opts.TargetBranch = gitlab.String(target)
-	}
+	// If running in CI, scope to current project, otherwise list globally
+	projectID, _ := utils.GetProjectID(cmd)
 
-	// List all merge requests
-	mrs, _, err := client.MergeRequests.ListMergeRequests(opts)
+	mrs, err := ListMergeRequests(projectID, filter)
 	if err != nil {
 		log.Fatalf("Failed to list merge requests: %v", err)
 	}
 
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := MergeRequestsAsJSON(mrs)
+		if err != nil {
+			log.Fatalf("Failed to list merge requests: %v", err)
+		}
+		fmt.Println(output)
+		return
+	}
+
 	for _, mr := range mrs {
 		fmt.Printf("#%d: [%s] %s\n", mr.IID, mr.State, mr.Title)
 		fmt.Printf("  %s -> %s\n", mr.SourceBranch, mr.TargetBranch)
@@ -258,6 +460,15 @@ func runGet(cmd *cobra.Command, args []string) {
 	projectID, _ := utils.GetProjectID(cmd)
 	mrIID, _ := cmd.Flags().GetInt("mr")
 
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := ReadMergeRequestAsJSON(projectID, mrIID)
+		if err != nil {
+			log.Fatalf("Failed to get merge request: %v", err)
+		}
+		fmt.Println(output)
+		return
+	}
+
 	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
 	if err != nil {
 		log.Fatalf("Failed to get merge request: %v", err)
@@ -268,6 +479,11 @@ func runGet(cmd *cobra.Command, args []string) {
 	fmt.Printf("State: %s\n", mr.State)
 	fmt.Printf("Source: %s\n", mr.SourceBranch)
 	fmt.Printf("Target: %s\n", mr.TargetBranch)
+	if approvals, err := GetApprovals(projectID, mrIID); err == nil {
+		fmt.Printf("Approvals: %s\n", FormatApprovals(approvals))
+	} else {
+		log.Printf("Warning: %v", err)
+	}
 	if mr.Description != "" {
 		fmt.Printf("Description:\n%s\n", mr.Description)
 	}
@@ -279,29 +495,58 @@ func runCreate(cmd *cobra.Command, args []string) {
 		log.Fatal("Project ID is required. Provide --project flag or run in GitLab CI")
 	}
 
-	sourceBranch, _ := cmd.Flags().GetString("source")
-	targetBranch, _ := cmd.Flags().GetString("target")
-	title, _ := cmd.Flags().GetString("title")
-	description, _ := cmd.Flags().GetString("description")
-	removeSource, _ := cmd.Flags().GetBool("remove-source")
-
-	opts := &gitlab.CreateMergeRequestOptions{
-		Title:              gitlab.String(title),
-		SourceBranch:       gitlab.String(sourceBranch),
-		TargetBranch:       gitlab.String(targetBranch),
-		RemoveSourceBranch: gitlab.Bool(removeSource),
+	req := &CreateRequest{}
+	req.SourceBranch, _ = cmd.Flags().GetString("source")
+	req.TargetBranch, _ = cmd.Flags().GetString("target")
+	req.Title, _ = cmd.Flags().GetString("title")
+	req.Description, _ = cmd.Flags().GetString("description")
+	req.RemoveSource, _ = cmd.Flags().GetBool("remove-source")
+	req.Milestone, _ = cmd.Flags().GetString("milestone")
+	req.Draft, _ = cmd.Flags().GetBool("draft")
+	req.Squash, _ = cmd.Flags().GetBool("squash")
+	req.AllowCollaboration, _ = cmd.Flags().GetBool("allow-collaboration")
+	req.TargetProjectID, _ = cmd.Flags().GetInt("target-project")
+
+	if labels, _ := cmd.Flags().GetString("labels"); labels != "" {
+		req.Labels = utils.SplitList(labels)
+	}
+	if assignees, _ := cmd.Flags().GetString("assignees"); assignees != "" {
+		req.Assignees = utils.SplitList(assignees)
+	}
+	if reviewers, _ := cmd.Flags().GetString("reviewers"); reviewers != "" {
+		req.Reviewers = utils.SplitList(reviewers)
 	}
 
-	if description != "" {
-		opts.Description = gitlab.String(description)
+	if fill, _ := cmd.Flags().GetBool("fill"); fill {
+		if err := FillFromBranch(projectID, req); err != nil {
+			log.Fatalf("Failed to fill merge request from branch: %v", err)
+		}
+	}
+	if template, _ := cmd.Flags().GetString("template"); template != "" {
+		templateOpts := &TemplateOptions{
+			Name:   template,
+			Input:  os.Stdin,
+			Output: os.Stderr,
+		}
+		templateOpts.LocalDir, _ = cmd.Flags().GetString("template-dir")
+		templateOpts.Category, _ = cmd.Flags().GetString("category")
+		if err := ApplyTemplate(projectID, req, templateOpts); err != nil {
+			log.Fatalf("Failed to apply template: %v", err)
+		}
+	}
+	if req.SourceBranch == "" || req.Title == "" {
+		log.Fatal("Both --source and --title are required unless --fill is used")
 	}
 
-	mr, _, err := client.MergeRequests.CreateMergeRequest(projectID, opts)
+	mr, err := CreateMergeRequest(projectID, req)
 	if err != nil {
 		log.Fatalf("Failed to create merge request: %v", err)
 	}
 
 	fmt.Printf("Created merge request #%d: %s\n", mr.IID, mr.Title)
+	if mr.WebURL != "" {
+		fmt.Printf("URL: %s\n", mr.WebURL)
+	}
 }
 
 func runUpdate(cmd *cobra.Command, args []string) {
@@ -332,19 +577,49 @@ func runMerge(cmd *cobra.Command, args []string) {
 	projectID, _ := utils.GetProjectID(cmd)
 	mrIID, _ := cmd.Flags().GetInt("mr")
 
-	opts := &gitlab.AcceptMergeRequestOptions{}
-	if message, _ := cmd.Flags().GetString("message"); message != "" {
-		opts.MergeCommitMessage = gitlab.String(message)
+	opts := &MergeOptions{}
+	opts.Message, _ = cmd.Flags().GetString("message")
+	opts.SHA, _ = cmd.Flags().GetString("sha")
+	opts.Squash, _ = cmd.Flags().GetBool("squash")
+	opts.RemoveSourceBranch, _ = cmd.Flags().GetBool("remove-source-branch")
+	opts.WhenPipelineSucceeds, _ = cmd.Flags().GetBool("when-pipeline-succeeds")
+	opts.MilestoneGroup, _ = cmd.Flags().GetString("milestone-group")
+
+	checks, _ := cmd.Flags().GetStringSlice("checks")
+	skip, _ := cmd.Flags().GetStringSlice("skip-checks")
+	if err := ValidateMergeChecks(skip); err != nil {
+		log.Fatalf("Invalid --skip-checks: %v", err)
+	}
+	for _, check := range checks {
+		if !containsString(skip, check) {
+			opts.Checks = append(opts.Checks, check)
+		}
 	}
 
-	mr, _, err := client.MergeRequests.AcceptMergeRequest(projectID, mrIID, opts)
+	mr, results, err := MergeMergeRequest(projectID, mrIID, opts)
+	printCheckResults(results)
 	if err != nil {
-		log.Fatalf("Failed to merge request: %v", err)
+		log.Fatalf("Failed to merge request #%d: %v", mrIID, err)
 	}
 
+	if opts.WhenPipelineSucceeds && mr.State != "merged" {
+		fmt.Printf("Merge request #%d will be merged when the pipeline succeeds\n", mr.IID)
+		return
+	}
 	fmt.Printf("Merged request #%d\n", mr.IID)
 }
 
+// printCheckResults prints one line per merge precondition
+func printCheckResults(results []CheckResult) {
+	for _, r := range results {
+		status := "PASS"
+		if !r.Passed {
+			status = "FAIL"
+		}
+		fmt.Printf("[%s] %s: %s\n", status, r.Name, r.Message)
+	}
+}
+
 func runClose(cmd *cobra.Command, args []string) {
 	projectID, _ := utils.GetProjectID(cmd)
 	mrIID, _ := cmd.Flags().GetInt("mr")
@@ -405,29 +680,49 @@ func runGetIssues(cmd *cobra.Command, args []string) {
 func runCheckChangelog(cmd *cobra.Command, args []string) {
 	projectID, _ := utils.GetProjectID(cmd)
 	mrIID, _ := cmd.Flags().GetInt("mr")
+	policyPath, _ := cmd.Flags().GetString("policy")
 
-	entry, err := GetChangelogEntries(projectID, mrIID)
+	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
+	if err != nil {
+		log.Fatalf("Failed to get merge request: %v", err)
+	}
+
+	policy, source, err := LoadChangelogPolicy(projectID, policyPath, mr.TargetBranch)
+	if err != nil {
+		log.Fatalf("Failed to load changelog policy: %v", err)
+	}
+
+	result, err := CheckChangelogPolicy(projectID, mr, policy)
 	if err != nil {
 		log.Fatalf("Failed to check changelog: %v", err)
 	}
 
-	if entry == changelogError {
-		// If running in CI, add a comment to the MR
-		if os.Getenv("CI") != "" {
-			comment := &gitlab.CreateMergeRequestNoteOptions{
-				Body: gitlab.String(noChangelogComment),
-			}
-			_, _, err := client.Notes.CreateMergeRequestNote(projectID, mrIID, comment)
-			if err != nil {
-				log.Printf("Warning: Failed to add comment to MR: %v", err)
-			}
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal result: %v", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		fmt.Printf("Changelog policy: %s\n", source)
+		for _, line := range result.Explanation {
+			fmt.Printf("  %s\n", line)
 		}
-		
-		// Exit with error to block the merge
-		log.Fatal(noChangelogComment)
 	}
 
-	fmt.Printf("Found changelog entry: %s\n", entry)
+	// If running in CI, keep a single comment on the MR up to date. A passing
+	// check only updates an existing comment so clean MRs stay quiet.
+	if os.Getenv("CI") != "" {
+		if err := UpsertStickyNote(projectID, mrIID, changelogCheckNote, PolicyComment(result, source), !result.Passed); err != nil {
+			log.Printf("Warning: Failed to add comment to MR: %v", err)
+		}
+	}
+
+	if !result.Passed {
+		// Exit with error to block the merge
+		log.Fatal("Changelog check failed")
+	}
+	fmt.Println("Changelog check passed")
 }
 
 func runBlock(cmd *cobra.Command, args []string) {
@@ -435,117 +730,520 @@ func runBlock(cmd *cobra.Command, args []string) {
 	mrIID, _ := cmd.Flags().GetInt("mr")
 	reason, _ := cmd.Flags().GetString("reason")
 
-	// First add a blocking note
-	noteOpts := &gitlab.CreateMergeRequestNoteOptions{
-		Body: gitlab.String(fmt.Sprintf("🚫 **Merge Blocked**: %s", reason)),
+	if err := BlockMergeRequest(projectID, mrIID, reason); err != nil {
+		log.Fatalf("Failed to block merge request: %v", err)
+	}
+
+	fmt.Printf("Blocked merge request #%d\n", mrIID)
+}
+
+func runUnblock(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+
+	if err := UnblockMergeRequest(projectID, mrIID); err != nil {
+		log.Fatalf("Failed to unblock merge request: %v", err)
+	}
+
+	fmt.Printf("Unblocked merge request #%d\n", mrIID)
+}
+
+func runCheckMilestone(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+	group, _ := cmd.Flags().GetString("group")
+
+	if err := CheckGroupMilestone(projectID, mrIID, group); err != nil {
+		log.Fatalf("Milestone check failed: %v", err)
+	}
+	fmt.Println("Milestone check passed")
+}
+
+func runGetMRFromCommit(cmd *cobra.Command, args []string) {
+	var mrIID int
+	var err error
+
+	if commitID, _ := cmd.Flags().GetString("commit"); commitID != "" {
+		projectID, _ := utils.GetProjectID(cmd)
+		mrIID, err = GetMRFromCommit(projectID, commitID)
+	} else if message, _ := cmd.Flags().GetString("message"); message != "" {
+		mrIID, err = GetMRFromCommitMessage(message)
+	} else {
+		log.Fatal("Either --commit or --message flag is required")
+	}
+
+	if err != nil {
+		log.Fatalf("Failed to get merge request IID: %v", err)
+	}
+
+	fmt.Printf("%d\n", mrIID)
+}
+
+func runAddCurrentMilestone(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	strategy := MilestoneStrategy{}
+	strategy.Name, _ = cmd.Flags().GetString("strategy")
+	strategy.Value, _ = cmd.Flags().GetString("match")
+	projectOnly, _ := cmd.Flags().GetBool("project-only")
+	strategy.IncludeGroup = !projectOnly
+
+	milestone, issueIDs, err := AddResolvedMilestone(projectID, mrIID, strategy, dryRun)
+	if milestone != nil {
+		fmt.Printf("Current milestone (%s strategy): %q, %s milestone #%d\n", strategy.Name, milestone.Title, milestone.Scope, milestone.ID)
+		for _, line := range milestone.Explanation {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Failed to add current milestone: %v", err)
+	}
+
+	if dryRun {
+		fmt.Printf("Would add it to MR #%d", mrIID)
+		for _, id := range issueIDs {
+			fmt.Printf(", issue #%d", id)
+		}
+		fmt.Println()
+		return
+	}
+	fmt.Printf("Successfully added milestone %q to MR #%d and its linked issues\n", milestone.Title, mrIID)
+}
+
+func runQueue(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	if projectID == 0 {
+		log.Fatal("Project ID is required. Provide --project flag or run in GitLab CI")
+	}
+
+	opts := &QueueOptions{}
+	opts.TargetBranch, _ = cmd.Flags().GetString("target")
+	opts.Label, _ = cmd.Flags().GetString("label")
+	opts.IIDs, _ = cmd.Flags().GetIntSlice("mr")
+	opts.Order, _ = cmd.Flags().GetString("order")
+	opts.Squash, _ = cmd.Flags().GetBool("squash")
+	opts.RemoveSourceBranch, _ = cmd.Flags().GetBool("remove-source-branch")
+	opts.Checks, _ = cmd.Flags().GetStringSlice("checks")
+	opts.RebaseTimeout, _ = cmd.Flags().GetDuration("rebase-timeout")
+	opts.PipelineTimeout, _ = cmd.Flags().GetDuration("pipeline-timeout")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+
+	if err := ValidateMergeChecks(opts.Checks); err != nil {
+		log.Fatalf("Invalid --checks: %v", err)
 	}
-	_, _, err := client.Notes.CreateMergeRequestNote(projectID, mrIID, noteOpts)
+
+	results, err := RunQueue(projectID, opts, os.Stdout)
+
+	fmt.Println()
+	for _, r := range results {
+		fmt.Printf("!%-6d %-8s %s\n", r.IID, r.Status, r.Title)
+	}
+	if err != nil {
+		log.Fatalf("Merge queue failed: %v", err)
+	}
+	if len(results) == 0 {
+		fmt.Println("Merge queue is empty")
+	}
+}
+
+func runRebase(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	fmt.Printf("Rebasing merge request !%d...\n", mrIID)
+	mr, err := RebaseAndWait(projectID, mrIID, utils.WaitOptions{Timeout: timeout, Progress: os.Stdout})
 	if err != nil {
-		log.Printf("Warning: Failed to add blocking note: %v", err)
+		if errors.Is(err, errRebaseFailed) {
+			if files, ferr := ConflictingFiles(projectID, mrIID); ferr == nil && len(files) > 0 {
+				fmt.Println("Files changed on both sides:")
+				for _, f := range files {
+					fmt.Printf("  %s\n", f)
+				}
+			}
+		}
+		log.Fatalf("Failed to rebase merge request: %v", err)
 	}
 
-	// Then update the MR title to indicate it's blocked
+	fmt.Printf("Rebased %s onto %s, head is now %s\n", mr.SourceBranch, mr.TargetBranch, shortSHA(mr.SHA))
+}
+
+func runConflicts(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+
 	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
 	if err != nil {
 		log.Fatalf("Failed to get merge request: %v", err)
 	}
 
-	// Add [BLOCKED] prefix if not already present
-	title := mr.Title
-	if !strings.HasPrefix(title, "[BLOCKED]") {
-		title = "[BLOCKED] " + title
+	files, err := ConflictingFiles(projectID, mrIID)
+	if err != nil {
+		log.Fatalf("Failed to get conflicting files: %v", err)
 	}
 
-	updateOpts := &gitlab.UpdateMergeRequestOptions{
-		Title: gitlab.String(title),
+	if mr.HasConflicts {
+		fmt.Printf("Merge request !%d has conflicts with %s\n", mrIID, mr.TargetBranch)
+	} else {
+		fmt.Printf("Merge request !%d merges cleanly into %s\n", mrIID, mr.TargetBranch)
+	}
+	if len(files) == 0 {
+		fmt.Println("No files changed on both sides")
+		return
 	}
-	mr, _, err = client.MergeRequests.UpdateMergeRequest(projectID, mrIID, updateOpts)
+	fmt.Println("Files changed on both sides:")
+	for _, f := range files {
+		fmt.Printf("  %s\n", f)
+	}
+}
+
+func runScanConflicts(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	if projectID == 0 {
+		log.Fatal("Project ID is required. Provide --project flag or run in GitLab CI")
+	}
+
+	opts := &ScanOptions{}
+	opts.TargetBranch, _ = cmd.Flags().GetString("target")
+	opts.Label, _ = cmd.Flags().GetString("label")
+	opts.Comment, _ = cmd.Flags().GetBool("comment")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+
+	results, err := ScanConflicts(projectID, opts)
 	if err != nil {
-		log.Fatalf("Failed to update merge request: %v", err)
+		log.Fatalf("Failed to scan merge requests: %v", err)
 	}
 
-	fmt.Printf("Blocked merge request #%d\n", mrIID)
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal results: %v", err)
+		}
+		fmt.Println(string(output))
+		return
+	}
+
+	conflicts, pending := 0, 0
+	for _, r := range results {
+		if r.Pending {
+			pending++
+			continue
+		}
+		if !r.Conflict {
+			continue
+		}
+		conflicts++
+		status := "conflict"
+		if r.New {
+			status = "new conflict"
+		}
+		fmt.Printf("!%-6d %-12s %s\n", r.IID, status, r.Title)
+		for _, f := range r.Files {
+			fmt.Printf("         %s\n", f)
+		}
+	}
+	fmt.Printf("%d of %d open merge requests targeting %s have conflicts\n", conflicts, len(results), opts.TargetBranch)
+	if pending > 0 {
+		fmt.Printf("%d merge requests are still being checked by GitLab; scan again later\n", pending)
+	}
 }
 
-func runUnblock(cmd *cobra.Command, args []string) {
+func runDiff(cmd *cobra.Command, args []string) {
 	projectID, _ := utils.GetProjectID(cmd)
 	mrIID, _ := cmd.Flags().GetInt("mr")
+	paths, _ := cmd.Flags().GetStringSlice("path")
+
+	diffs, err := MergeRequestDiffs(projectID, mrIID)
+	if err != nil {
+		log.Fatalf("Failed to get merge request diff: %v", err)
+	}
+	diffs = FilterDiffs(diffs, paths)
+
+	nameOnly, _ := cmd.Flags().GetBool("name-only")
+	stat, _ := cmd.Flags().GetBool("stat")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	switch {
+	case nameOnly:
+		for _, f := range changedFiles(diffs) {
+			fmt.Println(f.Path)
+		}
+	case stat:
+		fmt.Print(RenderStat(changedFiles(diffs)))
+	case jsonOutput:
+		output, err := json.MarshalIndent(changedFiles(diffs), "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal changed files: %v", err)
+		}
+		fmt.Println(string(output))
+	default:
+		fmt.Print(RenderDiff(diffs))
+	}
+}
+
+func runApprovals(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+
+	approvals, err := GetApprovals(projectID, mrIID)
+	if err != nil {
+		log.Fatalf("Failed to get approvals: %v", err)
+	}
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := json.MarshalIndent(approvals, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal approvals: %v", err)
+		}
+		fmt.Println(string(output))
+		return
+	}
 
-	// Add unblocking note
-	noteOpts := &gitlab.CreateMergeRequestNoteOptions{
-		Body: gitlab.String("✅ **Merge Unblocked**"),
+	fmt.Printf("Merge request !%d: %s\n", mrIID, FormatApprovals(approvals))
+	for _, r := range approvals.Rules {
+		status := "pending"
+		if r.Approved {
+			status = "approved"
+		}
+		fmt.Printf("\nRule %q (%s): %d required, %s\n", r.Name, r.Type, r.Required, status)
+		if len(r.ApprovedBy) > 0 {
+			fmt.Printf("  Approved by: %s\n", strings.Join(r.ApprovedBy, ", "))
+		}
+		if len(r.EligibleApprovers) > 0 {
+			fmt.Printf("  Eligible approvers: %s\n", strings.Join(r.EligibleApprovers, ", "))
+		}
 	}
-	_, _, err := client.Notes.CreateMergeRequestNote(projectID, mrIID, noteOpts)
+}
+
+func runApprove(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+	sha, _ := cmd.Flags().GetString("sha")
+
+	approvals, err := ApproveMergeRequest(projectID, mrIID, sha)
 	if err != nil {
-		log.Printf("Warning: Failed to add unblocking note: %v", err)
+		log.Fatalf("Failed to approve merge request: %v", err)
+	}
+
+	fmt.Printf("Approved merge request !%d: %s\n", mrIID, FormatApprovals(approvals))
+}
+
+func runUnapprove(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+
+	if err := UnapproveMergeRequest(projectID, mrIID); err != nil {
+		log.Fatalf("Failed to unapprove merge request: %v", err)
 	}
 
-	// Remove [BLOCKED] prefix from title
+	fmt.Printf("Removed approval from merge request !%d\n", mrIID)
+}
+
+func runAssignReviewers(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+	configPath, _ := cmd.Flags().GetString("config")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
 	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
 	if err != nil {
 		log.Fatalf("Failed to get merge request: %v", err)
 	}
 
-	title := strings.TrimPrefix(mr.Title, "[BLOCKED] ")
-	updateOpts := &gitlab.UpdateMergeRequestOptions{
-		Title: gitlab.String(title),
+	config, err := LoadReviewerConfig(projectID, configPath, mr.TargetBranch)
+	if err != nil {
+		log.Fatalf("Failed to load reviewer config: %v", err)
+	}
+
+	// Flags override the configuration file
+	if cmd.Flags().Changed("pool") {
+		pool, _ := cmd.Flags().GetString("pool")
+		config.Pool = utils.SplitList(pool)
 	}
-	mr, _, err = client.MergeRequests.UpdateMergeRequest(projectID, mrIID, updateOpts)
+	if cmd.Flags().Changed("out-of-office") {
+		ooo, _ := cmd.Flags().GetString("out-of-office")
+		config.OutOfOffice = utils.SplitList(ooo)
+	}
+	if cmd.Flags().Changed("count") {
+		config.Count, _ = cmd.Flags().GetInt("count")
+	}
+	if cmd.Flags().Changed("strategy") {
+		config.Strategy, _ = cmd.Flags().GetString("strategy")
+	}
+	if cmd.Flags().Changed("codeowners") {
+		config.CodeOwners, _ = cmd.Flags().GetBool("codeowners")
+	}
+
+	selection, err := AssignReviewers(projectID, mrIID, config, dryRun)
 	if err != nil {
-		log.Fatalf("Failed to update merge request: %v", err)
+		log.Fatalf("Failed to assign reviewers: %v", err)
 	}
 
-	fmt.Printf("Unblocked merge request #%d\n", mrIID)
+	var skipped []string
+	for username := range selection.Skipped {
+		skipped = append(skipped, username)
+	}
+	sort.Strings(skipped)
+	for _, username := range skipped {
+		fmt.Printf("Skipped %s: %s\n", username, selection.Skipped[username])
+	}
+	if len(selection.Selected) == 0 {
+		fmt.Printf("Merge request !%d already has enough reviewers or no candidate is available\n", mrIID)
+		return
+	}
+
+	verb := "Assigned"
+	if dryRun {
+		verb = "Would assign"
+	}
+	for _, username := range selection.Selected {
+		fmt.Printf("%s %s (%s)\n", verb, username, selection.Reasons[username])
+	}
 }
 
-func runCheckMilestone(cmd *cobra.Command, args []string) {
+func runThreads(cmd *cobra.Command, args []string) {
 	projectID, _ := utils.GetProjectID(cmd)
 	mrIID, _ := cmd.Flags().GetInt("mr")
 
-	if err := CheckMilestone(projectID, mrIID); err != nil {
-		log.Fatalf("Milestone check failed: %v", err)
+	threads, err := ListThreads(projectID, mrIID)
+	if err != nil {
+		log.Fatalf("Failed to list threads: %v", err)
 	}
-	fmt.Println("Milestone check passed")
+	if unresolved, _ := cmd.Flags().GetBool("unresolved"); unresolved {
+		threads = UnresolvedThreads(threads)
+	}
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := json.MarshalIndent(threads, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal threads: %v", err)
+		}
+		fmt.Println(string(output))
+		return
+	}
+
+	if len(threads) == 0 {
+		fmt.Println("No threads found")
+		return
+	}
+	for _, t := range threads {
+		status := "unresolved"
+		if t.Resolved {
+			status = "resolved"
+		}
+		fmt.Printf("%s  %-10s  %s\n", shortSHA(t.ID), status, threadLocation(t))
+		fmt.Printf("          %s: %s\n", t.Author, threadSummary(t.Body))
+		if t.Replies > 0 {
+			fmt.Printf("          %d replies, last by %s\n", t.Replies, t.LastAuthor)
+		}
+	}
+	fmt.Printf("\n%d thread(s), %d unresolved\n", len(threads), len(UnresolvedThreads(threads)))
 }
 
-func runAddChangelog(cmd *cobra.Command, args []string) {
+// findThreadFromFlags resolves the --thread flag to a full thread ID
+func findThreadFromFlags(cmd *cobra.Command) (int, int, *Thread) {
 	projectID, _ := utils.GetProjectID(cmd)
 	mrIID, _ := cmd.Flags().GetInt("mr")
+	id, _ := cmd.Flags().GetString("thread")
+
+	threads, err := ListThreads(projectID, mrIID)
+	if err != nil {
+		log.Fatalf("Failed to list threads: %v", err)
+	}
+	thread, err := FindThread(threads, id)
+	if err != nil {
+		log.Fatalf("Failed to find thread: %v", err)
+	}
+	return projectID, mrIID, thread
+}
+
+func runThreadsReply(cmd *cobra.Command, args []string) {
+	projectID, mrIID, thread := findThreadFromFlags(cmd)
+	body, _ := cmd.Flags().GetString("body")
 
-	if err := AddChangelogToMilestone(projectID, mrIID); err != nil {
-		log.Fatalf("Failed to add changelog: %v", err)
+	if err := ReplyToThread(projectID, mrIID, thread.ID, body); err != nil {
+		log.Fatalf("Failed to reply: %v", err)
 	}
+	fmt.Printf("Replied to thread %s\n", shortSHA(thread.ID))
 
-	fmt.Println("Successfully updated milestone changelog")
+	if resolve, _ := cmd.Flags().GetBool("resolve"); resolve {
+		if err := ResolveThread(projectID, mrIID, thread.ID, true); err != nil {
+			log.Fatalf("Failed to resolve thread: %v", err)
+		}
+		fmt.Printf("Resolved thread %s\n", shortSHA(thread.ID))
+	}
 }
 
-func runGetMRFromCommit(cmd *cobra.Command, args []string) {
-	var mrIID int
-	var err error
+func runThreadsResolve(cmd *cobra.Command, args []string) {
+	projectID, mrIID, thread := findThreadFromFlags(cmd)
+	resolve := cmd.Name() == "resolve"
 
-	if commitID, _ := cmd.Flags().GetString("commit"); commitID != "" {
-		projectID, _ := utils.GetProjectID(cmd)
-		mrIID, err = GetMRFromCommit(projectID, commitID)
-	} else if message, _ := cmd.Flags().GetString("message"); message != "" {
-		mrIID, err = GetMRFromCommitMessage(message)
+	if err := ResolveThread(projectID, mrIID, thread.ID, resolve); err != nil {
+		log.Fatalf("Failed to update thread: %v", err)
+	}
+	if resolve {
+		fmt.Printf("Resolved thread %s\n", shortSHA(thread.ID))
 	} else {
-		log.Fatal("Either --commit or --message flag is required")
+		fmt.Printf("Reopened thread %s\n", shortSHA(thread.ID))
 	}
+}
+
+func runCheckThreads(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+	block, _ := cmd.Flags().GetBool("block")
 
+	unresolved, err := CheckThreads(projectID, mrIID, block)
+	for _, t := range unresolved {
+		fmt.Printf("%s  %s  %s: %s\n", shortSHA(t.ID), threadLocation(t), t.Author, threadSummary(t.Body))
+	}
 	if err != nil {
-		log.Fatalf("Failed to get merge request IID: %v", err)
+		log.Fatalf("Thread check failed: %v", err)
 	}
-
-	fmt.Printf("%d\n", mrIID)
+	fmt.Println("All threads resolved")
 }
 
-func runAddCurrentMilestone(cmd *cobra.Command, args []string) {
+func runPipelines(cmd *cobra.Command, args []string) {
 	projectID, _ := utils.GetProjectID(cmd)
 	mrIID, _ := cmd.Flags().GetInt("mr")
+	limit, _ := cmd.Flags().GetInt("limit")
 
-	if err := AddCurrentMilestone(projectID, mrIID); err != nil {
-		log.Fatalf("Failed to add current milestone: %v", err)
+	list, err := ListPipelines(projectID, mrIID, limit)
+	if err != nil {
+		log.Fatalf("Failed to list pipelines: %v", err)
 	}
 
-	fmt.Printf("Successfully added Current milestone to MR #%d and its linked issues\n", mrIID)
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal pipelines: %v", err)
+		}
+		fmt.Println(string(output))
+		return
+	}
+
+	if len(list) == 0 {
+		fmt.Printf("No pipelines for merge request !%d\n", mrIID)
+		return
+	}
+	for _, p := range list {
+		created := ""
+		if p.CreatedAt != nil {
+			created = p.CreatedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("#%-10d %-10s %-9s %-10s %s\n", p.ID, p.Status, shortSHA(p.SHA), pipelines.FormatDuration(float64(p.Duration)), created)
+	}
+}
+
+func runCheckPipeline(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+
+	pipeline, err := CheckHeadPipeline(projectID, mrIID)
+	if err != nil {
+		log.Fatalf("Pipeline check failed: %v", err)
+	}
+	fmt.Printf("Pipeline #%d on %s succeeded\n", pipeline.ID, shortSHA(pipeline.SHA))
 }
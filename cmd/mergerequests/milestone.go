@@ -4,21 +4,46 @@ import (
 	"fmt"
 	"mpg-gitlab/cmd/utils"
 	"strings"
+	"time"
 
 	"github.com/xanzy/go-gitlab"
 )
 
 // CheckMilestone verifies if the MR and its linked issues have a milestone
 func CheckMilestone(projectID, mrIID int) error {
+	return CheckGroupMilestone(projectID, mrIID, "")
+}
+
+// CheckGroupMilestone verifies if the MR and its linked issues have the same milestone
+// With a group set, that milestone must also be a milestone of the group.
+func CheckGroupMilestone(projectID, mrIID int, group string) error {
+	_, err := checkedMilestone(projectID, mrIID, group)
+	return err
+}
+
+// checkedMilestone runs the milestone check on a freshly fetched MR and returns the
+// milestone it verified
+func checkedMilestone(projectID, mrIID int, group string) (*gitlab.Milestone, error) {
 	// Get the MR
 	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get merge request: %v", err)
+		return nil, fmt.Errorf("failed to get merge request: %v", err)
 	}
 
 	// Check if MR has milestone
 	if mr.Milestone == nil {
-		return fmt.Errorf("merge request #%d has no milestone assigned", mrIID)
+		return nil, fmt.Errorf("merge request #%d has no milestone assigned", mrIID)
+	}
+
+	if group != "" {
+		g, _, err := client.Groups.GetGroup(group, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group: %v", err)
+		}
+		if mr.Milestone.GroupID != g.ID {
+			return nil, fmt.Errorf("merge request #%d has %s, not a milestone of group %s",
+				mrIID, describeMilestone(mr.Milestone), g.FullPath)
+		}
 	}
 
 	// Get linked issues
@@ -29,44 +54,53 @@ func CheckMilestone(projectID, mrIID int) error {
 			continue // Skip issues we can't access
 		}
 		if issue.Milestone == nil {
-			return fmt.Errorf("linked issue #%d has no milestone assigned", issueID)
+			return nil, fmt.Errorf("linked issue #%d has no milestone assigned", issueID)
 		}
-		// Optionally: Check if issues have same milestone as MR
+		// Milestone IDs are unique across projects and groups, so a project milestone
+		// never matches a group milestone of the same title
 		if issue.Milestone.ID != mr.Milestone.ID {
-			return fmt.Errorf("linked issue #%d has different milestone (%s) than MR (%s)", 
-				issueID, issue.Milestone.Title, mr.Milestone.Title)
+			return nil, fmt.Errorf("linked issue #%d has different milestone (%s) than MR (%s)",
+				issueID, describeMilestone(issue.Milestone), describeMilestone(mr.Milestone))
 		}
 	}
 
-	return nil
+	return mr.Milestone, nil
+}
+
+// describeMilestone names a milestone, marking group milestones
+func describeMilestone(m *gitlab.Milestone) string {
+	if m.GroupID != 0 {
+		return fmt.Sprintf("group milestone %s", m.Title)
+	}
+	return m.Title
 }
 
 // AddCurrentMilestone adds the "Current" milestone to an MR and its linked issues
 func AddCurrentMilestone(projectID, mrIID int) error {
+	_, _, err := AddResolvedMilestone(projectID, mrIID, DefaultMilestoneStrategy(), false)
+	return err
+}
+
+// AddResolvedMilestone adds the current milestone, as resolved by a strategy, to an MR
+// and its linked issues. It returns the resolution and the linked issue IIDs; with
+// dryRun set nothing is updated.
+func AddResolvedMilestone(projectID, mrIID int, strategy MilestoneStrategy, dryRun bool) (*MilestoneResolution, []int, error) {
 	// Get the MR first
 	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get merge request: %v", err)
+		return nil, nil, fmt.Errorf("failed to get merge request: %v", err)
 	}
 
-	// Find the "Current" milestone
-	milestones, _, err := client.Milestones.ListMilestones(projectID, &gitlab.ListMilestonesOptions{
-		State: gitlab.String("active"),
-	})
+	// Find the current milestone
+	currentMilestone, err := ResolveCurrentMilestone(projectID, strategy, time.Now())
 	if err != nil {
-		return fmt.Errorf("failed to list milestones: %v", err)
-	}
-
-	var currentMilestone *gitlab.Milestone
-	for _, m := range milestones {
-		if strings.EqualFold(m.Title, "current") {
-			currentMilestone = m
-			break
-		}
+		return nil, nil, err
 	}
 
-	if currentMilestone == nil {
-		return fmt.Errorf("no active milestone named 'Current' found")
+	// Get linked issues
+	issueIDs := utils.GetIssueIDsFromDescription(mr.Description)
+	if dryRun {
+		return currentMilestone, issueIDs, nil
 	}
 
 	// Update MR milestone
@@ -74,20 +108,23 @@ func AddCurrentMilestone(projectID, mrIID int) error {
 		MilestoneID: gitlab.Int(currentMilestone.ID),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to update merge request milestone: %v", err)
+		return currentMilestone, issueIDs, fmt.Errorf("failed to update merge request milestone: %v", err)
 	}
 
-	// Get linked issues
-	issueIDs := utils.GetIssueIDsFromDescription(mr.Description)
+	// Every linked issue is attempted, so one inaccessible issue doesn't leave the rest behind
+	var failed []string
 	for _, issueID := range issueIDs {
-		// Update each issue's milestone
 		_, _, err = client.Issues.UpdateIssue(projectID, issueID, &gitlab.UpdateIssueOptions{
 			MilestoneID: gitlab.Int(currentMilestone.ID),
 		})
 		if err != nil {
-			return fmt.Errorf("failed to update issue #%d milestone: %v", issueID, err)
+			failed = append(failed, fmt.Sprintf("#%d (%v)", issueID, err))
 		}
 	}
+	if len(failed) > 0 {
+		return currentMilestone, issueIDs, fmt.Errorf("failed to update the milestone of %d of %d linked issue(s): %s",
+			len(failed), len(issueIDs), strings.Join(failed, ", "))
+	}
 
-	return nil
-} 
\ No newline at end of file
+	return currentMilestone, issueIDs, nil
+}
@@ -0,0 +1,53 @@
+package mergerequests
+
+import (
+	"fmt"
+
+	"mpg-gitlab/cmd/pipelines"
+	"mpg-gitlab/cmd/types"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// ListPipelines returns the most recent pipelines of a merge request, newest first
+// The list API carries no durations, so each pipeline is fetched; limit caps that.
+func ListPipelines(projectID, mrIID, limit int) ([]types.Pipeline, error) {
+	var infos []*gitlab.PipelineInfo
+	err := pipelines.WalkMergeRequestPipelines(projectID, mrIID, func(info *gitlab.PipelineInfo) bool {
+		infos = append(infos, info)
+		return limit <= 0 || len(infos) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []types.Pipeline{}
+	for _, info := range infos {
+		pipeline, _, err := client.Pipelines.GetPipeline(projectID, info.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pipeline #%d: %v", info.ID, err)
+		}
+		result = append(result, *pipelines.ConvertPipeline(pipeline))
+	}
+	return result, nil
+}
+
+// CheckHeadPipeline fails unless the latest pipeline on the head commit succeeded
+func CheckHeadPipeline(projectID, mrIID int) (*types.Pipeline, error) {
+	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request: %v", err)
+	}
+
+	pipeline, err := pipelines.MergeRequestHeadPipeline(projectID, mr)
+	if err != nil {
+		return nil, err
+	}
+	if pipeline == nil {
+		return nil, fmt.Errorf("no pipeline ran on head %s", shortSHA(mr.SHA))
+	}
+	if pipeline.Status != "success" {
+		return pipeline, fmt.Errorf("pipeline #%d on head %s is %s", pipeline.ID, shortSHA(mr.SHA), pipeline.Status)
+	}
+	return pipeline, nil
+}
@@ -0,0 +1,261 @@
+package mergerequests
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	// Queue item outcomes
+	queueMerged  = "merged"
+	queueRemoved = "removed"
+	queueSkipped = "skipped"
+	queuePending = "pending"
+)
+
+// QueueOptions configures a merge queue run
+type QueueOptions struct {
+	TargetBranch       string
+	Label              string // MRs carrying this label form the queue
+	IIDs               []int  // Explicit MRs to queue, used instead of the label
+	Order              string // created, updated or iid
+	Squash             bool
+	RemoveSourceBranch bool
+	Checks             []string
+	RebaseTimeout      time.Duration
+	PipelineTimeout    time.Duration
+	DryRun             bool
+}
+
+// QueueResult records what happened to one merge request in the queue
+type QueueResult struct {
+	IID    int    `json:"iid"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// CollectQueue returns the merge requests in the queue, in merge order
+// Already merged MRs are kept so a resumed run can report them as done
+func CollectQueue(projectID int, opts *QueueOptions) ([]*gitlab.MergeRequest, error) {
+	listOpts := &gitlab.ListProjectMergeRequestsOptions{
+		TargetBranch: gitlab.String(opts.TargetBranch),
+		ListOptions:  gitlab.ListOptions{PerPage: 100},
+	}
+	if len(opts.IIDs) > 0 {
+		listOpts.IIDs = &opts.IIDs
+		listOpts.State = gitlab.String("all")
+	} else {
+		if strings.TrimSpace(opts.Label) == "" {
+			return nil, fmt.Errorf("a queue label or explicit merge requests are required")
+		}
+		labels := gitlab.Labels{opts.Label}
+		listOpts.Labels = &labels
+		listOpts.State = gitlab.String("opened")
+	}
+
+	var mrs []*gitlab.MergeRequest
+	for {
+		page, resp, err := client.MergeRequests.ListProjectMergeRequests(projectID, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list merge requests: %v", err)
+		}
+		mrs = append(mrs, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	if missing := missingIIDs(mrs, opts.IIDs); len(missing) > 0 {
+		refs := make([]string, len(missing))
+		for i, iid := range missing {
+			refs[i] = fmt.Sprintf("!%d", iid)
+		}
+		return nil, fmt.Errorf("merge request(s) %s not found with target branch %s", strings.Join(refs, ", "), opts.TargetBranch)
+	}
+
+	if err := sortQueue(mrs, opts.Order, opts.IIDs); err != nil {
+		return nil, err
+	}
+	return mrs, nil
+}
+
+// missingIIDs returns the requested IIDs that are not among the merge requests
+func missingIIDs(mrs []*gitlab.MergeRequest, iids []int) []int {
+	found := make(map[int]bool)
+	for _, mr := range mrs {
+		found[mr.IID] = true
+	}
+	var missing []int
+	for _, iid := range iids {
+		if !found[iid] {
+			missing = append(missing, iid)
+		}
+	}
+	return missing
+}
+
+// sortQueue orders merge requests for merging
+// Explicit IIDs keep the order they were given in unless another order is requested
+func sortQueue(mrs []*gitlab.MergeRequest, order string, iids []int) error {
+	var less func(a, b *gitlab.MergeRequest) bool
+	switch order {
+	case "":
+		if len(iids) > 0 {
+			position := make(map[int]int)
+			for i, iid := range iids {
+				position[iid] = i
+			}
+			less = func(a, b *gitlab.MergeRequest) bool { return position[a.IID] < position[b.IID] }
+		} else {
+			less = func(a, b *gitlab.MergeRequest) bool { return a.CreatedAt.Before(*b.CreatedAt) }
+		}
+	case "created":
+		less = func(a, b *gitlab.MergeRequest) bool { return a.CreatedAt.Before(*b.CreatedAt) }
+	case "updated":
+		less = func(a, b *gitlab.MergeRequest) bool { return a.UpdatedAt.Before(*b.UpdatedAt) }
+	case "iid":
+		less = func(a, b *gitlab.MergeRequest) bool { return a.IID < b.IID }
+	default:
+		return fmt.Errorf("invalid queue order %q, expected created, updated or iid", order)
+	}
+
+	sort.SliceStable(mrs, func(i, j int) bool { return less(mrs[i], mrs[j]) })
+	return nil
+}
+
+// RunQueue merges the queued merge requests one at a time
+// Each MR is rebased onto the latest target, its pipeline awaited, then merged.
+// MRs that can't be merged are removed from the queue with a comment. A run that
+// stops on an error can simply be restarted: merged MRs are skipped and the
+// remaining labeled MRs are picked up again.
+func RunQueue(projectID int, opts *QueueOptions, progress io.Writer) ([]QueueResult, error) {
+	mrs, err := CollectQueue(projectID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []QueueResult
+	for _, mr := range mrs {
+		result := QueueResult{IID: mr.IID, Title: mr.Title}
+
+		switch {
+		case mr.State == "merged":
+			result.Status, result.Reason = queueSkipped, "already merged"
+		case mr.State != "opened":
+			result.Status, result.Reason = queueSkipped, "merge request is "+mr.State
+		case opts.DryRun:
+			result.Status = queuePending
+		default:
+			fmt.Fprintf(progress, "Processing !%d: %s\n", mr.IID, mr.Title)
+			status, reason, err := processQueueItem(projectID, mr, opts, progress)
+			if err != nil {
+				// Leave the MR in the queue so a rerun picks it up again
+				results = append(results, QueueResult{IID: mr.IID, Title: mr.Title, Status: queuePending, Reason: err.Error()})
+				return results, fmt.Errorf("queue stopped at !%d: %v", mr.IID, err)
+			}
+			result.Status, result.Reason = status, reason
+		}
+
+		fmt.Fprintf(progress, "!%d: %s %s\n", mr.IID, result.Status, result.Reason)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// processQueueItem rebases, waits for and merges a single merge request
+// An error means the queue must stop; a removed status means the MR was ejected
+func processQueueItem(projectID int, mr *gitlab.MergeRequest, opts *QueueOptions, progress io.Writer) (string, string, error) {
+	blocked, err := IsBlocked(projectID, mr.IID)
+	if err != nil {
+		return "", "", err
+	}
+	if blocked {
+		reason := "merge request is blocked"
+		if blockReason, _ := GetBlockReason(projectID, mr.IID); blockReason != "" {
+			reason += ": " + blockReason
+		}
+		return ejectFromQueue(projectID, mr, opts, reason)
+	}
+
+	current, _, err := client.MergeRequests.GetMergeRequest(projectID, mr.IID, &gitlab.GetMergeRequestsOptions{
+		IncludeDivergedCommitsCount: gitlab.Bool(true),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get merge request: %v", err)
+	}
+
+	if current.DivergedCommitsCount > 0 {
+		fmt.Fprintf(progress, "  rebasing onto %s (%d commits behind)\n", current.TargetBranch, current.DivergedCommitsCount)
+		current, err = RebaseAndWait(projectID, mr.IID, utils.WaitOptions{Timeout: opts.RebaseTimeout, Progress: progress})
+		if err != nil {
+			if errors.Is(err, errRebaseFailed) {
+				return ejectFromQueue(projectID, mr, opts, err.Error())
+			}
+			return "", "", err
+		}
+	}
+
+	fmt.Fprintf(progress, "  waiting for pipeline on %s\n", shortSHA(current.SHA))
+	pipeline, err := WaitHeadPipeline(projectID, mr.IID, current.SHA, utils.WaitOptions{Timeout: opts.PipelineTimeout, Progress: progress})
+	if err != nil {
+		return "", "", err
+	}
+	if pipeline.Status != "success" {
+		return ejectFromQueue(projectID, mr, opts, fmt.Sprintf("pipeline #%d %s", pipeline.ID, pipeline.Status))
+	}
+
+	merged, results, err := MergeMergeRequest(projectID, mr.IID, &MergeOptions{
+		SHA:                current.SHA,
+		Squash:             opts.Squash,
+		RemoveSourceBranch: opts.RemoveSourceBranch,
+		Checks:             opts.Checks,
+	})
+	if err != nil {
+		if failed := FailedChecks(results); len(failed) > 0 {
+			var reasons []string
+			for _, r := range results {
+				if !r.Passed {
+					reasons = append(reasons, fmt.Sprintf("%s (%s)", r.Name, r.Message))
+				}
+			}
+			return ejectFromQueue(projectID, mr, opts, "merge preconditions failed: "+strings.Join(reasons, ", "))
+		}
+		return ejectFromQueue(projectID, mr, opts, err.Error())
+	}
+
+	return queueMerged, shortSHA(merged.MergeCommitSHA), nil
+}
+
+// ejectFromQueue removes a merge request from the queue and explains why on the MR
+func ejectFromQueue(projectID int, mr *gitlab.MergeRequest, opts *QueueOptions, reason string) (string, string, error) {
+	noteOpts := &gitlab.CreateMergeRequestNoteOptions{
+		Body: gitlab.String(fmt.Sprintf("🚫 **Removed from merge queue**: %s", reason)),
+	}
+	if _, _, err := client.Notes.CreateMergeRequestNote(projectID, mr.IID, noteOpts); err != nil {
+		return "", "", fmt.Errorf("failed to comment on merge request: %v", err)
+	}
+
+	if opts.Label != "" && len(opts.IIDs) == 0 {
+		labels := gitlab.Labels{opts.Label}
+		_, _, err := client.MergeRequests.UpdateMergeRequest(projectID, mr.IID, &gitlab.UpdateMergeRequestOptions{
+			RemoveLabels: &labels,
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to remove queue label: %v", err)
+		}
+	}
+
+	return queueRemoved, reason, nil
+}
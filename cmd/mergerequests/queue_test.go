@@ -0,0 +1,87 @@
+package mergerequests
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestSortQueue(t *testing.T) {
+	day := func(d int) *time.Time {
+		return gitlab.Time(time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC))
+	}
+	newQueue := func() []*gitlab.MergeRequest {
+		return []*gitlab.MergeRequest{
+			{IID: 12, CreatedAt: day(3), UpdatedAt: day(4)},
+			{IID: 5, CreatedAt: day(1), UpdatedAt: day(9)},
+			{IID: 9, CreatedAt: day(2), UpdatedAt: day(2)},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		order   string
+		iids    []int
+		want    []int
+		wantErr bool
+	}{
+		{
+			name: "default is oldest first",
+			want: []int{5, 9, 12},
+		},
+		{
+			name: "explicit IIDs keep given order",
+			iids: []int{9, 12, 5},
+			want: []int{9, 12, 5},
+		},
+		{
+			name:  "by update time",
+			order: "updated",
+			want:  []int{9, 12, 5},
+		},
+		{
+			name:  "by IID",
+			order: "iid",
+			iids:  []int{12, 9, 5},
+			want:  []int{5, 9, 12},
+		},
+		{
+			name:    "invalid order",
+			order:   "priority",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mrs := newQueue()
+			err := sortQueue(mrs, tt.order, tt.iids)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("sortQueue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []int
+			for _, mr := range mrs {
+				got = append(got, mr.IID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortQueue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissingIIDs(t *testing.T) {
+	mrs := []*gitlab.MergeRequest{{IID: 5}, {IID: 9}}
+	if got := missingIIDs(mrs, []int{9, 12, 5, 3}); !reflect.DeepEqual(got, []int{12, 3}) {
+		t.Errorf("missingIIDs() = %v, want [12 3]", got)
+	}
+	if got := missingIIDs(mrs, nil); got != nil {
+		t.Errorf("missingIIDs() without requested IIDs = %v", got)
+	}
+}
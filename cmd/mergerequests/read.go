@@ -15,7 +15,6 @@ import (
 
 const (
 	changelogError = "ERROR"
-	noChangelogComment = "No changelog found, please add one of the [Feature] / [Improvement] / [Fix] / [Infra] / [No-Changelog-Entry] in the Issue mentioned in the MR description"
 )
 
 // ReadMergeRequest gets a merge request and returns it as a structured type
@@ -28,6 +27,21 @@ func ReadMergeRequest(projectID, mrIID int) (*types.MergeRequest, error) {
 	return convertGitLabMR(mr), nil
 }
 
+// ReadMergeRequestWithApprovals gets a merge request along with its approval state
+func ReadMergeRequestWithApprovals(projectID, mrIID int) (*types.MergeRequest, error) {
+	mr, err := ReadMergeRequest(projectID, mrIID)
+	if err != nil {
+		return nil, err
+	}
+
+	mr.Approvals, err = GetApprovals(projectID, mrIID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mr, nil
+}
+
 // ReadMergeRequests gets a list of merge requests and returns them as structured types
 func ReadMergeRequests(opts *gitlab.ListMergeRequestsOptions) ([]types.MergeRequest, error) {
 	mrs, _, err := client.MergeRequests.ListMergeRequests(opts)
@@ -58,9 +72,9 @@ func ReadMergeRequestsAsJSON(opts *gitlab.ListMergeRequestsOptions) (string, err
 	return string(jsonData), nil
 }
 
-// ReadMergeRequestAsJSON gets a merge request and returns it as formatted JSON
+// ReadMergeRequestAsJSON gets a merge request with its approval state and returns it as formatted JSON
 func ReadMergeRequestAsJSON(projectID, mrIID int) (string, error) {
-	mr, err := ReadMergeRequest(projectID, mrIID)
+	mr, err := ReadMergeRequestWithApprovals(projectID, mrIID)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,98 @@
+package mergerequests
+
+import (
+	"errors"
+	"fmt"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// errRebaseFailed marks a rebase that ended with a merge error, like a conflict
+var errRebaseFailed = errors.New("rebase failed")
+
+// RebaseAndWait triggers a rebase of the MR source branch onto its target and waits until it completes
+// It returns the refreshed merge request, or an error if the rebase failed or timed out.
+// An MR that is already up to date with its target is returned without a rebase.
+func RebaseAndWait(projectID, mrIID int, opts utils.WaitOptions) (*gitlab.MergeRequest, error) {
+	// Right after the rebase request GitLab may still report the old head and merge
+	// error, so the state from before is needed to tell when the rebase really ended
+	before, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, &gitlab.GetMergeRequestsOptions{
+		IncludeDivergedCommitsCount: gitlab.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request: %v", err)
+	}
+	if before.DivergedCommitsCount == 0 {
+		return before, nil
+	}
+
+	if _, err := client.MergeRequests.RebaseMergeRequest(projectID, mrIID); err != nil {
+		return nil, fmt.Errorf("failed to start rebase: %v", err)
+	}
+	return waitRebase(projectID, mrIID, before, opts)
+}
+
+// WaitRebase waits until a running rebase of the merge request completes
+func WaitRebase(projectID, mrIID int, opts utils.WaitOptions) (*gitlab.MergeRequest, error) {
+	return waitRebase(projectID, mrIID, nil, opts)
+}
+
+// waitRebase polls the merge request until its rebase ends
+// With before set, the rebase was just requested and only counts as ended once it
+// was seen running, the head moved, or a new merge error appeared.
+func waitRebase(projectID, mrIID int, before *gitlab.MergeRequest, opts utils.WaitOptions) (*gitlab.MergeRequest, error) {
+	var mr *gitlab.MergeRequest
+	var failure string
+	started := false
+	err := utils.Wait(opts, func() (bool, string, error) {
+		var err error
+		mr, _, err = client.MergeRequests.GetMergeRequest(projectID, mrIID, &gitlab.GetMergeRequestsOptions{
+			IncludeRebaseInProgress: gitlab.Bool(true),
+		})
+		if err != nil {
+			return false, "", fmt.Errorf("failed to get merge request: %v", err)
+		}
+		if mr.RebaseInProgress {
+			started = true
+			return false, "rebase in progress", nil
+		}
+
+		var done bool
+		done, failure = rebaseOutcome(before, mr, started)
+		if !done {
+			return false, "waiting for the rebase to start", nil
+		}
+		return true, "rebase finished", nil
+	})
+	if err != nil {
+		return mr, fmt.Errorf("rebase did not finish: %w", err)
+	}
+
+	if failure != "" {
+		return mr, fmt.Errorf("%w: %s", errRebaseFailed, failure)
+	}
+	return mr, nil
+}
+
+// rebaseOutcome tells whether a rebase that isn't running anymore has ended, and why it
+// failed if it did. before is the MR from before the rebase was requested, or nil when
+// waiting for a rebase started elsewhere; started is whether the rebase was seen running.
+func rebaseOutcome(before, mr *gitlab.MergeRequest, started bool) (bool, string) {
+	if before == nil {
+		return true, mr.MergeError
+	}
+	switch {
+	case mr.SHA != before.SHA:
+		// The branch was rewritten, so any merge error left is from before
+		return true, ""
+	case started:
+		// It ran without moving the head: with an error it failed, otherwise nothing changed
+		return true, mr.MergeError
+	case mr.MergeError != "" && mr.MergeError != before.MergeError:
+		// It failed before a poll saw it running
+		return true, mr.MergeError
+	}
+	return false, ""
+}
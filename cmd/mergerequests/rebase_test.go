@@ -0,0 +1,36 @@
+package mergerequests
+
+import (
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestRebaseOutcome(t *testing.T) {
+	before := &gitlab.MergeRequest{SHA: "old", MergeError: "stale conflict"}
+
+	tests := []struct {
+		name        string
+		before      *gitlab.MergeRequest
+		mr          *gitlab.MergeRequest
+		started     bool
+		wantDone    bool
+		wantFailure string
+	}{
+		{name: "not started yet", before: before, mr: &gitlab.MergeRequest{SHA: "old", MergeError: "stale conflict"}},
+		{name: "head moved", before: before, mr: &gitlab.MergeRequest{SHA: "new", MergeError: "stale conflict"}, wantDone: true},
+		{name: "ran and failed", before: before, mr: &gitlab.MergeRequest{SHA: "old", MergeError: "stale conflict"}, started: true, wantDone: true, wantFailure: "stale conflict"},
+		{name: "ran without change", before: &gitlab.MergeRequest{SHA: "old"}, mr: &gitlab.MergeRequest{SHA: "old"}, started: true, wantDone: true},
+		{name: "failed unseen", before: before, mr: &gitlab.MergeRequest{SHA: "old", MergeError: "conflict in a.go"}, wantDone: true, wantFailure: "conflict in a.go"},
+		{name: "running rebase started elsewhere", mr: &gitlab.MergeRequest{SHA: "old"}, wantDone: true},
+		{name: "failed rebase started elsewhere", mr: &gitlab.MergeRequest{MergeError: "conflict"}, wantDone: true, wantFailure: "conflict"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, failure := rebaseOutcome(tt.before, tt.mr, tt.started)
+			if done != tt.wantDone || failure != tt.wantFailure {
+				t.Errorf("rebaseOutcome() = %v, %q, want %v, %q", done, failure, tt.wantDone, tt.wantFailure)
+			}
+		})
+	}
+}
@@ -0,0 +1,432 @@
+package mergerequests
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	// DefaultReviewerConfigPath is where the reviewer configuration is looked up
+	DefaultReviewerConfigPath = ".gitlab/reviewers.json"
+
+	// Reviewer selection strategies
+	strategyRoundRobin = "round-robin"
+	strategyLoad       = "load"
+
+	// roundRobinHistory is how many recent merge requests are searched for the last assigned reviewer
+	roundRobinHistory = 50
+)
+
+// codeownersPaths are the locations GitLab reads CODEOWNERS from, in order
+var codeownersPaths = []string{"CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// ReviewerConfig configures reviewer assignment
+type ReviewerConfig struct {
+	Pool        []string `json:"pool"`                    // Candidate reviewer usernames, in rotation order
+	OutOfOffice []string `json:"out_of_office,omitempty"` // Usernames currently unavailable
+	Count       int      `json:"count,omitempty"`         // Number of reviewers an MR should have
+	Strategy    string   `json:"strategy,omitempty"`      // round-robin or load
+	CodeOwners  bool     `json:"codeowners,omitempty"`    // Prefer CODEOWNERS of the changed files
+}
+
+// CodeOwnerRule is one CODEOWNERS entry
+type CodeOwnerRule struct {
+	Section string
+	Pattern string
+	Owners  []string // Usernames; groups and emails are skipped
+}
+
+// ReviewerSelection explains which reviewers were picked and why
+type ReviewerSelection struct {
+	Existing []string          `json:"existing"`
+	Selected []string          `json:"selected"`
+	Reasons  map[string]string `json:"reasons"`
+	Skipped  map[string]string `json:"skipped,omitempty"`
+}
+
+// LoadReviewerConfig reads the reviewer configuration from a local file or the project repository
+// A missing default file yields an empty configuration so flags alone can be used.
+func LoadReviewerConfig(projectID int, configPath, ref string) (*ReviewerConfig, error) {
+	config := &ReviewerConfig{}
+
+	var data []byte
+	if configPath != "" {
+		var err error
+		data, err = os.ReadFile(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reviewer config: %v", err)
+		}
+	} else {
+		var found bool
+		var err error
+		data, found, err = utils.ReadConfigFile(projectID, DefaultReviewerConfigPath, ref)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return config, nil
+		}
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid reviewer config: %v", err)
+	}
+	return config, nil
+}
+
+// Validate fills in defaults and checks the configuration
+func (c *ReviewerConfig) Validate() error {
+	if c.Count == 0 {
+		c.Count = 1
+	}
+	if c.Strategy == "" {
+		c.Strategy = strategyRoundRobin
+	}
+	if c.Strategy != strategyRoundRobin && c.Strategy != strategyLoad {
+		return fmt.Errorf("invalid strategy %q, expected %s or %s", c.Strategy, strategyRoundRobin, strategyLoad)
+	}
+	if len(c.Pool) == 0 && !c.CodeOwners {
+		return fmt.Errorf("no reviewer pool configured: use --pool or %s", DefaultReviewerConfigPath)
+	}
+	for i, username := range c.Pool {
+		c.Pool[i] = strings.TrimPrefix(username, "@")
+	}
+	for i, username := range c.OutOfOffice {
+		c.OutOfOffice[i] = strings.TrimPrefix(username, "@")
+	}
+	return nil
+}
+
+// AssignReviewers picks reviewers for a merge request and adds them to it
+// With dryRun the selection is only computed.
+func AssignReviewers(projectID, mrIID int, config *ReviewerConfig, dryRun bool) (*ReviewerSelection, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request: %v", err)
+	}
+
+	var owners []string
+	if config.CodeOwners {
+		owners, err = changedFileOwners(projectID, mr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	candidates := mergeCandidates(owners, config.Pool)
+	ordered, err := orderCandidates(projectID, candidates, config.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	author := ""
+	if mr.Author != nil {
+		author = mr.Author.Username
+	}
+	var existing []string
+	for _, r := range mr.Reviewers {
+		existing = append(existing, r.Username)
+	}
+
+	selection := selectReviewers(ordered, owners, existing, author, config)
+	if dryRun || len(selection.Selected) == 0 {
+		return selection, nil
+	}
+
+	ids := make([]int, 0, len(mr.Reviewers)+len(selection.Selected))
+	for _, r := range mr.Reviewers {
+		ids = append(ids, r.ID)
+	}
+	newIDs, err := utils.ResolveUserIDs(selection.Selected)
+	if err != nil {
+		return nil, err
+	}
+	ids = append(ids, newIDs...)
+
+	_, _, err = client.MergeRequests.UpdateMergeRequest(projectID, mrIID, &gitlab.UpdateMergeRequestOptions{
+		ReviewerIDs: &ids,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update reviewers: %v", err)
+	}
+	return selection, nil
+}
+
+// mergeCandidates lists code owners first, then the pool, without duplicates
+func mergeCandidates(owners, pool []string) []string {
+	seen := make(map[string]bool)
+	var candidates []string
+	for _, list := range [][]string{owners, pool} {
+		for _, username := range list {
+			if !seen[username] {
+				seen[username] = true
+				candidates = append(candidates, username)
+			}
+		}
+	}
+	return candidates
+}
+
+// selectReviewers picks reviewers until the MR has config.Count of them
+// Code owners are preferred over the rest of the pool; the author, people out of
+// office and existing reviewers are never picked.
+func selectReviewers(ordered, owners, existing []string, author string, config *ReviewerConfig) *ReviewerSelection {
+	selection := &ReviewerSelection{
+		Existing: existing,
+		Selected: []string{},
+		Reasons:  make(map[string]string),
+		Skipped:  make(map[string]string),
+	}
+
+	needed := config.Count - len(existing)
+	pick := func(preferOwners bool) {
+		for _, username := range ordered {
+			if needed <= 0 {
+				return
+			}
+			isOwner := containsString(owners, username)
+			if preferOwners != isOwner || containsString(selection.Selected, username) {
+				continue
+			}
+
+			switch {
+			case strings.EqualFold(username, author):
+				selection.Skipped[username] = "author"
+			case containsString(config.OutOfOffice, username):
+				selection.Skipped[username] = "out of office"
+			case containsString(existing, username):
+				selection.Skipped[username] = "already a reviewer"
+			default:
+				selection.Selected = append(selection.Selected, username)
+				if isOwner {
+					selection.Reasons[username] = "code owner"
+				} else {
+					selection.Reasons[username] = config.Strategy
+				}
+				needed--
+			}
+		}
+	}
+	pick(true)
+	pick(false)
+
+	return selection
+}
+
+// orderCandidates orders candidates according to the selection strategy
+func orderCandidates(projectID int, candidates []string, strategy string) ([]string, error) {
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	if strategy == strategyLoad {
+		load := make(map[string]int)
+		for _, username := range candidates {
+			count, err := openReviewCount(username)
+			if err != nil {
+				return nil, err
+			}
+			load[username] = count
+		}
+		return orderByLoad(candidates, load), nil
+	}
+
+	last, err := lastAssignedReviewer(projectID, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return rotateAfter(candidates, last), nil
+}
+
+// orderByLoad sorts candidates by their number of open reviews, keeping pool order on ties
+func orderByLoad(candidates []string, load map[string]int) []string {
+	ordered := append([]string{}, candidates...)
+	sort.SliceStable(ordered, func(i, j int) bool { return load[ordered[i]] < load[ordered[j]] })
+	return ordered
+}
+
+// rotateAfter rotates candidates so the one following last comes first
+func rotateAfter(candidates []string, last string) []string {
+	for i, username := range candidates {
+		if username == last {
+			next := (i + 1) % len(candidates)
+			return append(append([]string{}, candidates[next:]...), candidates[:next]...)
+		}
+	}
+	return candidates
+}
+
+// openReviewCount returns the number of open merge requests a user is asked to review
+func openReviewCount(username string) (int, error) {
+	id, err := utils.ResolveUserID(username)
+	if err != nil {
+		return 0, err
+	}
+
+	_, resp, err := client.MergeRequests.ListMergeRequests(&gitlab.ListMergeRequestsOptions{
+		State:       gitlab.String("opened"),
+		Scope:       gitlab.String("all"),
+		ReviewerID:  gitlab.ReviewerID(id),
+		ListOptions: gitlab.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count reviews for %s: %v", username, err)
+	}
+	return resp.TotalItems, nil
+}
+
+// lastAssignedReviewer finds the candidate most recently asked to review in the project
+// Round-robin state lives in GitLab itself, so no local state needs to be kept.
+func lastAssignedReviewer(projectID int, candidates []string) (string, error) {
+	mrs, _, err := client.MergeRequests.ListProjectMergeRequests(projectID, &gitlab.ListProjectMergeRequestsOptions{
+		State:       gitlab.String("all"),
+		OrderBy:     gitlab.String("created_at"),
+		Sort:        gitlab.String("desc"),
+		ListOptions: gitlab.ListOptions{PerPage: roundRobinHistory},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list merge requests: %v", err)
+	}
+
+	return lastCandidateReviewer(mrs, candidates), nil
+}
+
+// lastCandidateReviewer returns the candidate assigned last on the newest merge request
+// that has one. Reviewers are listed in the order they were added, so that is the
+// last candidate in the list, not the first.
+func lastCandidateReviewer(mrs []*gitlab.MergeRequest, candidates []string) string {
+	for _, mr := range mrs {
+		last := ""
+		for _, r := range mr.Reviewers {
+			if containsString(candidates, r.Username) {
+				last = r.Username
+			}
+		}
+		if last != "" {
+			return last
+		}
+	}
+	return ""
+}
+
+// changedFileOwners returns the CODEOWNERS users of the files changed in a merge request
+func changedFileOwners(projectID int, mr *gitlab.MergeRequest) ([]string, error) {
+	var content []byte
+	for _, path := range codeownersPaths {
+		data, found, err := utils.ReadRepositoryFile(projectID, path, mr.TargetBranch)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			content = data
+			break
+		}
+	}
+	if content == nil {
+		return nil, nil
+	}
+
+	paths, err := ChangedPaths(projectID, mr.IID)
+	if err != nil {
+		return nil, err
+	}
+	return CodeOwnersFor(ParseCodeOwners(string(content)), paths), nil
+}
+
+// ParseCodeOwners parses a CODEOWNERS file
+func ParseCodeOwners(content string) []CodeOwnerRule {
+	var rules []CodeOwnerRule
+	section := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Section headers look like "[Docs]", "^[Optional]" or "[Backend][2] @default-owner"
+		if strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			if end := strings.Index(line, "]"); end > 0 {
+				section = strings.TrimPrefix(strings.TrimPrefix(line[:end], "^"), "[")
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		rule := CodeOwnerRule{Section: section, Pattern: strings.ReplaceAll(fields[0], `\ `, " ")}
+		for _, owner := range fields[1:] {
+			// Groups contain a slash and emails have no @ prefix; only users can be reviewers
+			if strings.HasPrefix(owner, "@") && !strings.Contains(owner, "/") {
+				rule.Owners = append(rule.Owners, strings.TrimPrefix(owner, "@"))
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// CodeOwnersFor returns the owners of a set of paths
+// As in GitLab, the last matching pattern wins within each section.
+func CodeOwnersFor(rules []CodeOwnerRule, paths []string) []string {
+	seen := make(map[string]bool)
+	var owners []string
+
+	for _, p := range paths {
+		matched := make(map[string]CodeOwnerRule)
+		var sections []string
+		for _, rule := range rules {
+			if matchCodeOwnersPattern(rule.Pattern, p) {
+				if _, ok := matched[rule.Section]; !ok {
+					sections = append(sections, rule.Section)
+				}
+				matched[rule.Section] = rule
+			}
+		}
+
+		for _, section := range sections {
+			for _, owner := range matched[section].Owners {
+				if !seen[owner] {
+					seen[owner] = true
+					owners = append(owners, owner)
+				}
+			}
+		}
+	}
+
+	return owners
+}
+
+// matchCodeOwnersPattern matches a CODEOWNERS (gitignore style) pattern against a path
+// A leading "/" anchors the pattern to the repository root; otherwise a pattern
+// without a slash matches at any depth.
+func matchCodeOwnersPattern(pattern, p string) bool {
+	if pattern == "*" {
+		return true
+	}
+
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !anchored && !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		pattern = "**/" + pattern
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	// A pattern naming a directory also owns everything below it
+	return MatchPath(pattern, p) || MatchPath(pattern+"/**", p)
+}
@@ -0,0 +1,161 @@
+package mergerequests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestRotateAfter(t *testing.T) {
+	pool := []string{"alice", "bob", "carol"}
+
+	tests := []struct {
+		last string
+		want []string
+	}{
+		{"", []string{"alice", "bob", "carol"}},
+		{"alice", []string{"bob", "carol", "alice"}},
+		{"carol", []string{"alice", "bob", "carol"}},
+		{"mallory", []string{"alice", "bob", "carol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.last, func(t *testing.T) {
+			if got := rotateAfter(pool, tt.last); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rotateAfter(%q) = %v, want %v", tt.last, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastCandidateReviewer(t *testing.T) {
+	reviewers := func(names ...string) []*gitlab.BasicUser {
+		var users []*gitlab.BasicUser
+		for _, n := range names {
+			users = append(users, &gitlab.BasicUser{Username: n})
+		}
+		return users
+	}
+	candidates := []string{"alice", "bob", "carol"}
+
+	mrs := []*gitlab.MergeRequest{
+		{IID: 9, Reviewers: reviewers("dave")},
+		{IID: 8, Reviewers: reviewers("alice", "dave", "bob")},
+		{IID: 7, Reviewers: reviewers("carol")},
+	}
+	if got := lastCandidateReviewer(mrs, candidates); got != "bob" {
+		t.Errorf("lastCandidateReviewer() = %q, want %q", got, "bob")
+	}
+	if got := lastCandidateReviewer(mrs[:1], candidates); got != "" {
+		t.Errorf("lastCandidateReviewer() without candidates = %q, want \"\"", got)
+	}
+}
+
+func TestOrderByLoad(t *testing.T) {
+	pool := []string{"alice", "bob", "carol", "dave"}
+	load := map[string]int{"alice": 5, "bob": 1, "carol": 1, "dave": 0}
+
+	want := []string{"dave", "bob", "carol", "alice"}
+	if got := orderByLoad(pool, load); !reflect.DeepEqual(got, want) {
+		t.Errorf("orderByLoad() = %v, want %v", got, want)
+	}
+}
+
+func TestSelectReviewers(t *testing.T) {
+	config := &ReviewerConfig{Count: 2, Strategy: strategyRoundRobin, OutOfOffice: []string{"bob"}}
+
+	tests := []struct {
+		name     string
+		ordered  []string
+		owners   []string
+		existing []string
+		author   string
+		want     []string
+		skipped  map[string]string
+	}{
+		{
+			name:    "skips author and out of office",
+			ordered: []string{"alice", "bob", "carol", "dave"},
+			author:  "alice",
+			want:    []string{"carol", "dave"},
+			skipped: map[string]string{"alice": "author", "bob": "out of office"},
+		},
+		{
+			name:    "code owners first",
+			ordered: []string{"alice", "carol", "erin"},
+			owners:  []string{"erin"},
+			want:    []string{"erin", "alice"},
+			skipped: map[string]string{},
+		},
+		{
+			name:     "existing reviewers count",
+			ordered:  []string{"alice", "carol"},
+			existing: []string{"carol"},
+			want:     []string{"alice"},
+			skipped:  map[string]string{},
+		},
+		{
+			name:     "already enough reviewers",
+			ordered:  []string{"alice"},
+			existing: []string{"carol", "dave"},
+			want:     []string{},
+			skipped:  map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectReviewers(tt.ordered, tt.owners, tt.existing, tt.author, config)
+			if !reflect.DeepEqual(got.Selected, tt.want) {
+				t.Errorf("Selected = %v, want %v", got.Selected, tt.want)
+			}
+			if !reflect.DeepEqual(got.Skipped, tt.skipped) {
+				t.Errorf("Skipped = %v, want %v", got.Skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestCodeOwners(t *testing.T) {
+	content := `# Owners
+* @lead
+*.md @docs-writer
+/db/migrations/ @dba @org/dba-team
+api/ @backend someone@example.com
+
+[Frontend]
+/web/ @frontend
+`
+	rules := ParseCodeOwners(content)
+	if len(rules) != 5 {
+		t.Fatalf("ParseCodeOwners() returned %d rules, want 5", len(rules))
+	}
+	if rules[4].Section != "Frontend" {
+		t.Errorf("Section = %q, want Frontend", rules[4].Section)
+	}
+	if !reflect.DeepEqual(rules[2].Owners, []string{"dba"}) {
+		t.Errorf("Owners = %v, want groups and emails skipped", rules[2].Owners)
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{"last match wins", []string{"README.md"}, []string{"docs-writer"}},
+		{"nested markdown", []string{"docs/guide.md"}, []string{"docs-writer"}},
+		{"anchored directory", []string{"db/migrations/001.sql"}, []string{"dba"}},
+		{"unanchored directory", []string{"internal/api/handler.go"}, []string{"backend"}},
+		{"sections add owners", []string{"web/app.js"}, []string{"lead", "frontend"}},
+		{"fallback", []string{"main.go"}, []string{"lead"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOwnersFor(rules, tt.paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CodeOwnersFor(%v) = %v, want %v", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package mergerequests
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// stickyMarker builds the hidden marker identifying a sticky note of a given kind
+func stickyMarker(kind string) string {
+	return fmt.Sprintf("<!-- mpg-gitlab:%s -->", kind)
+}
+
+// findStickyNote returns the sticky note of a given kind on a merge request, or nil
+func findStickyNote(projectID, mrIID int, kind string) (*gitlab.Note, error) {
+	marker := stickyMarker(kind)
+	opts := &gitlab.ListMergeRequestNotesOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+
+	for {
+		notes, resp, err := client.Notes.ListMergeRequestNotes(projectID, mrIID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get merge request notes: %v", err)
+		}
+		for _, note := range notes {
+			if strings.Contains(note.Body, marker) {
+				return note, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// UpsertStickyNote keeps a single note of a given kind on a merge request up to date
+// The note is edited in place on later runs instead of piling up new comments.
+// With create false, an existing note is updated but no new one is added.
+func UpsertStickyNote(projectID, mrIID int, kind, body string, create bool) error {
+	note, err := findStickyNote(projectID, mrIID, kind)
+	if err != nil {
+		return err
+	}
+
+	body = stickyMarker(kind) + "\n" + body
+	if note != nil {
+		if note.Body == body {
+			return nil
+		}
+		_, _, err := client.Notes.UpdateMergeRequestNote(projectID, mrIID, note.ID, &gitlab.UpdateMergeRequestNoteOptions{
+			Body: gitlab.String(body),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update merge request note: %v", err)
+		}
+		return nil
+	}
+
+	if !create {
+		return nil
+	}
+	_, _, err = client.Notes.CreateMergeRequestNote(projectID, mrIID, &gitlab.CreateMergeRequestNoteOptions{
+		Body: gitlab.String(body),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add merge request note: %v", err)
+	}
+	return nil
+}
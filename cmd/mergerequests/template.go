@@ -0,0 +1,247 @@
+package mergerequests
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mpg-gitlab/cmd/utils"
+)
+
+// templateRepoDir is where GitLab looks for merge request description templates
+const templateRepoDir = ".gitlab/merge_request_templates"
+
+var (
+	// templateVarPattern matches template variables like {{source_branch}}
+	templateVarPattern = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+	// requiredSectionPattern matches headings marked as required, like "## Testing <!-- required -->"
+	requiredSectionPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*<!--\s*required\s*-->\s*$`)
+
+	// htmlComment matches HTML comments used as hints inside templates
+	htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+// TemplateOptions controls where templates come from and how variables are filled
+type TemplateOptions struct {
+	Name     string    // Template name, with or without the .md extension
+	LocalDir string    // Local template directory; the project repository is used when empty
+	Category string    // Changelog category; prompted for when empty and input is a terminal
+	Input    io.Reader // Where to read prompt answers from
+	Output   io.Writer // Where to write prompts to
+}
+
+// ApplyTemplate renders a description template for the request and stores it as its description
+func ApplyTemplate(projectID int, req *CreateRequest, opts *TemplateOptions) error {
+	content, err := LoadTemplate(projectID, opts.Name, opts.LocalDir, req.TargetBranch)
+	if err != nil {
+		return err
+	}
+
+	vars, err := templateVars(projectID, req, content, opts)
+	if err != nil {
+		return err
+	}
+
+	description, err := RenderTemplate(content, vars)
+	if err != nil {
+		return fmt.Errorf("template %q: %v", opts.Name, err)
+	}
+	if err := ValidateDescription(description); err != nil {
+		return fmt.Errorf("template %q: %v", opts.Name, err)
+	}
+
+	req.Description = description
+	return nil
+}
+
+// LoadTemplate reads a template from a local directory or, like the other config files,
+// from .gitlab/merge_request_templates in the working directory, then in the repository at ref
+func LoadTemplate(projectID int, name, localDir, ref string) (string, error) {
+	if !strings.HasSuffix(name, ".md") {
+		name += ".md"
+	}
+
+	if localDir != "" {
+		content, err := os.ReadFile(filepath.Join(localDir, name))
+		if err != nil {
+			return "", fmt.Errorf("failed to read template: %v", err)
+		}
+		return string(content), nil
+	}
+
+	path := templateRepoDir + "/" + name
+	content, found, err := utils.ReadConfigFile(projectID, path, ref)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("template %s not found", path)
+	}
+	return string(content), nil
+}
+
+// templateVars computes the variables available to templates
+func templateVars(projectID int, req *CreateRequest, content string, opts *TemplateOptions) (map[string]string, error) {
+	vars := map[string]string{
+		"source_branch": req.SourceBranch,
+		"target_branch": req.TargetBranch,
+		"title":         req.Title,
+		"issue":         "",
+		"issue_title":   "",
+	}
+
+	// A branch number that isn't an issue leaves the issue variables empty
+	issue, err := branchIssue(projectID, req.SourceBranch)
+	if err != nil {
+		return nil, err
+	}
+	if issue != nil {
+		vars["issue"] = fmt.Sprintf("#%d", issue.IID)
+		vars["issue_title"] = issue.Title
+	}
+
+	// Only resolve the category when the template asks for it, so we don't prompt needlessly
+	if !usesVariable(content, "changelog_category") && !usesVariable(content, "changelog") {
+		return vars, nil
+	}
+
+	category := opts.Category
+	if category == "" && opts.Input != nil && isTerminal(opts.Input) {
+		category, err = promptCategory(opts.Input, opts.Output)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entryText := req.Title
+	if issue != nil {
+		entryText = issue.Title
+	}
+
+	if category == "" {
+		if issue == nil {
+			return nil, fmt.Errorf("changelog category required: use --category or a branch linked to an issue")
+		}
+		// Reuse the category the issue already declares or implies
+		line := issueChangelogLine(issue)
+		category = changelogPattern.FindStringSubmatch(line)[1]
+	}
+
+	normalized := normalizeChangelogCategory(category)
+	if normalized == "" {
+		return nil, fmt.Errorf("unknown changelog category %q", category)
+	}
+
+	vars["changelog_category"] = normalized
+	vars["changelog"] = fmt.Sprintf("[%s] %s", normalized, entryText)
+	return vars, nil
+}
+
+// RenderTemplate substitutes {{variable}} placeholders in a template
+// Unknown variables are an error so typos don't end up in descriptions
+func RenderTemplate(content string, vars map[string]string) (string, error) {
+	var unknown []string
+	rendered := templateVarPattern.ReplaceAllStringFunc(content, func(match string) string {
+		name := templateVarPattern.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			unknown = append(unknown, name)
+			return match
+		}
+		return value
+	})
+
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown template variables: %s", strings.Join(unknown, ", "))
+	}
+	return rendered, nil
+}
+
+// ValidateDescription checks a rendered description for required sections and a changelog entry
+// Required sections are headings marked with <!-- required --> and must have content
+func ValidateDescription(description string) error {
+	var missing []string
+
+	lines := strings.Split(description, "\n")
+	for i, line := range lines {
+		matches := requiredSectionPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			continue
+		}
+
+		// Collect the section body up to the next heading of the same or higher level
+		var body strings.Builder
+		for _, next := range lines[i+1:] {
+			trimmed := strings.TrimSpace(next)
+			if level := headingLevel(trimmed); level > 0 && level <= len(matches[1]) {
+				break
+			}
+			body.WriteString(next + "\n")
+		}
+		if strings.TrimSpace(htmlComment.ReplaceAllString(body.String(), "")) == "" {
+			missing = append(missing, matches[2])
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("required sections are empty: %s", strings.Join(missing, ", "))
+	}
+
+	if findChangelogEntry(cleanDescription(htmlComment.ReplaceAllString(description, ""))) == "" {
+		return fmt.Errorf("no changelog entry: add one of the [%s] / [%s] tags",
+			strings.Join(changelogCategories, "] / ["), noChangelogCategory)
+	}
+
+	return nil
+}
+
+// headingLevel returns the markdown heading level of a line, or 0 if it is not a heading
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ') {
+		return 0
+	}
+	return level
+}
+
+// usesVariable reports whether a template references the given variable
+func usesVariable(content, name string) bool {
+	for _, match := range templateVarPattern.FindAllStringSubmatch(content, -1) {
+		if match[1] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// promptCategory asks the user to pick a changelog category
+func promptCategory(in io.Reader, out io.Writer) (string, error) {
+	fmt.Fprintf(out, "Changelog category (%s): ", strings.Join(allChangelogCategories(), "/"))
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		return "", fmt.Errorf("failed to read changelog category: %v", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// isTerminal reports whether the reader is an interactive terminal
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
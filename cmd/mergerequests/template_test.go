@@ -0,0 +1,147 @@
+package mergerequests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	vars := map[string]string{
+		"source_branch": "123-add-login",
+		"issue":         "#123",
+		"changelog":     "[Feature] Add login",
+	}
+
+	tests := []struct {
+		name        string
+		content     string
+		want        string
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:    "substitutes variables",
+			content: "{{changelog}}\n\nCloses {{ issue }} from {{source_branch}}",
+			want:    "[Feature] Add login\n\nCloses #123 from 123-add-login",
+		},
+		{
+			name:    "no variables",
+			content: "Plain text",
+			want:    "Plain text",
+		},
+		{
+			name:        "unknown variable",
+			content:     "{{changelog}} {{reviewer}}",
+			wantErr:     true,
+			errContains: "reviewer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderTemplate(tt.content, vars)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RenderTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("RenderTemplate() error = %v, want error containing %v", err, tt.errContains)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("RenderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:        "valid description",
+			description: "## Summary <!-- required -->\nAdds login\n\n## Changelog\n[Feature] Add login\n",
+			wantErr:     false,
+		},
+		{
+			name:        "empty required section",
+			description: "## Summary <!-- required -->\n<!-- describe the change -->\n\n## Changelog\n[Feature] Add login\n",
+			wantErr:     true,
+			errContains: "Summary",
+		},
+		{
+			name:        "subsection counts as content",
+			description: "## Testing <!-- required -->\n### Manual\nClicked around\n\n[Fix] Logout\n",
+			wantErr:     false,
+		},
+		{
+			name:        "missing changelog entry",
+			description: "## Summary\nAdds login\n",
+			wantErr:     true,
+			errContains: "no changelog entry",
+		},
+		{
+			name:        "changelog only in comment",
+			description: "<!-- [Feature] example -->\nAdds login\n",
+			wantErr:     true,
+			errContains: "no changelog entry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDescription(tt.description)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDescription() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("ValidateDescription() error = %v, want error containing %v", err, tt.errContains)
+			}
+		})
+	}
+}
+
+func TestNormalizeChangelogCategory(t *testing.T) {
+	tests := []struct {
+		category string
+		want     string
+	}{
+		{"feature", "Feature"},
+		{"[Fix]", "Fix"},
+		{"no-changelog-entry", "No-Changelog-Entry"},
+		{"Docs", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.category, func(t *testing.T) {
+			if got := normalizeChangelogCategory(tt.category); got != tt.want {
+				t.Errorf("normalizeChangelogCategory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTemplateLocal(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "default.md"), []byte("{{changelog}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadTemplate(1, "default", dir, "")
+	if err != nil {
+		t.Fatalf("LoadTemplate() error = %v", err)
+	}
+	if got != "{{changelog}}" {
+		t.Errorf("LoadTemplate() = %q, want %q", got, "{{changelog}}")
+	}
+
+	if _, err := LoadTemplate(1, "missing", dir, ""); err == nil {
+		t.Errorf("LoadTemplate() expected error for missing template")
+	}
+}
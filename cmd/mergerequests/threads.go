@@ -0,0 +1,209 @@
+package mergerequests
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	// unresolvedThreadsReason is the block reason used by the unresolved thread gate
+	unresolvedThreadsReason = "Unresolved discussion threads"
+
+	// threadsGate tags the blocking notes of the unresolved thread gate
+	threadsGate = "threads-gate"
+)
+
+// Thread is a resolvable discussion thread on a merge request
+type Thread struct {
+	ID         string     `json:"id"`
+	Resolved   bool       `json:"resolved"`
+	Path       string     `json:"path,omitempty"` // File the thread is attached to, if any
+	Line       int        `json:"line,omitempty"`
+	Author     string     `json:"author"`
+	LastAuthor string     `json:"last_author"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+	Replies    int        `json:"replies"`
+	Body       string     `json:"body"` // First note of the thread
+}
+
+// ListThreads returns the resolvable discussion threads of a merge request
+// Plain comments and system notes can't be resolved and are left out.
+func ListThreads(projectID, mrIID int) ([]Thread, error) {
+	opts := &gitlab.ListMergeRequestDiscussionsOptions{PerPage: 100}
+
+	var threads []Thread
+	for {
+		discussions, resp, err := client.Discussions.ListMergeRequestDiscussions(projectID, mrIID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list discussions: %v", err)
+		}
+		for _, d := range discussions {
+			if thread, ok := convertDiscussion(d); ok {
+				threads = append(threads, thread)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return threads, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// convertDiscussion converts a GitLab discussion to a thread
+// It reports false for discussions that can't be resolved
+func convertDiscussion(d *gitlab.Discussion) (Thread, bool) {
+	if len(d.Notes) == 0 || !d.Notes[0].Resolvable || d.Notes[0].System {
+		return Thread{}, false
+	}
+
+	first, last := d.Notes[0], d.Notes[len(d.Notes)-1]
+	thread := Thread{
+		ID:         d.ID,
+		Resolved:   true,
+		Author:     first.Author.Username,
+		LastAuthor: last.Author.Username,
+		UpdatedAt:  last.UpdatedAt,
+		Replies:    len(d.Notes) - 1,
+		Body:       first.Body,
+	}
+	if last.UpdatedAt == nil {
+		thread.UpdatedAt = last.CreatedAt
+	}
+
+	// A thread is resolved once every resolvable note in it is
+	for _, n := range d.Notes {
+		if n.Resolvable && !n.Resolved {
+			thread.Resolved = false
+		}
+	}
+
+	if p := first.Position; p != nil {
+		thread.Path, thread.Line = p.NewPath, p.NewLine
+		if thread.Line == 0 {
+			// Comments on removed lines only have an old position
+			thread.Path, thread.Line = p.OldPath, p.OldLine
+		}
+	}
+
+	return thread, true
+}
+
+// UnresolvedThreads returns the threads that are not resolved yet
+func UnresolvedThreads(threads []Thread) []Thread {
+	var unresolved []Thread
+	for _, t := range threads {
+		if !t.Resolved {
+			unresolved = append(unresolved, t)
+		}
+	}
+	return unresolved
+}
+
+// FindThread finds a thread by its ID or a unique prefix of it, like a short commit SHA
+func FindThread(threads []Thread, id string) (*Thread, error) {
+	var matches []*Thread
+	for i, t := range threads {
+		if t.ID == id {
+			return &threads[i], nil
+		}
+		if strings.HasPrefix(t.ID, id) {
+			matches = append(matches, &threads[i])
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("thread %s not found", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("thread ID %s is ambiguous, %d threads match", id, len(matches))
+	}
+}
+
+// ReplyToThread adds a reply to a discussion thread
+func ReplyToThread(projectID, mrIID int, threadID, body string) error {
+	_, _, err := client.Discussions.AddMergeRequestDiscussionNote(projectID, mrIID, threadID, &gitlab.AddMergeRequestDiscussionNoteOptions{
+		Body: gitlab.String(body),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reply to thread: %v", err)
+	}
+	return nil
+}
+
+// ResolveThread resolves or reopens a discussion thread
+func ResolveThread(projectID, mrIID int, threadID string, resolved bool) error {
+	_, _, err := client.Discussions.ResolveMergeRequestDiscussion(projectID, mrIID, threadID, &gitlab.ResolveMergeRequestDiscussionOptions{
+		Resolved: gitlab.Bool(resolved),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update thread: %v", err)
+	}
+	return nil
+}
+
+// CheckThreads fails when a merge request still has unresolved threads
+// With block set, the merge request is also blocked, and unblocked again once
+// every thread is resolved if the gate was what blocked it.
+func CheckThreads(projectID, mrIID int, block bool) ([]Thread, error) {
+	threads, err := ListThreads(projectID, mrIID)
+	if err != nil {
+		return nil, err
+	}
+	unresolved := UnresolvedThreads(threads)
+
+	if block {
+		blocked, err := IsBlocked(projectID, mrIID)
+		if err != nil {
+			return unresolved, err
+		}
+		switch {
+		case len(unresolved) > 0 && !blocked:
+			reason := fmt.Sprintf("%s (%d)", unresolvedThreadsReason, len(unresolved))
+			if err := blockMergeRequest(projectID, mrIID, reason, threadsGate); err != nil {
+				return unresolved, err
+			}
+		case len(unresolved) == 0 && blocked:
+			// Only the gate's own block is lifted, never a later one for another reason
+			ours, err := blockedByGate(projectID, mrIID, threadsGate)
+			if err != nil {
+				return unresolved, err
+			}
+			if ours {
+				if err := UnblockMergeRequest(projectID, mrIID); err != nil {
+					return unresolved, err
+				}
+			}
+		}
+	}
+
+	if len(unresolved) > 0 {
+		return unresolved, fmt.Errorf("%d unresolved thread(s)", len(unresolved))
+	}
+	return nil, nil
+}
+
+// threadLocation describes where a thread is attached
+func threadLocation(t Thread) string {
+	if t.Path == "" {
+		return "overview"
+	}
+	if t.Line == 0 {
+		return t.Path
+	}
+	return fmt.Sprintf("%s:%d", t.Path, t.Line)
+}
+
+// threadSummary returns the first line of a thread, shortened for listings
+func threadSummary(body string) string {
+	line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(body), "\n", 2)[0])
+	if len(line) > 72 {
+		line = line[:69] + "..."
+	}
+	return line
+}
@@ -0,0 +1,100 @@
+package mergerequests
+
+import (
+	"testing"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestConvertDiscussion(t *testing.T) {
+	diffThread := utils.CreateMockDiscussion("abc123def456", false, "Why is this needed?", "Fixed, thanks")
+	diffThread.Notes[0].Author.Username = "alice"
+	diffThread.Notes[1].Author.Username = "bob"
+	diffThread.Notes[0].Position = &gitlab.NotePosition{NewPath: "main.go", NewLine: 42}
+
+	thread, ok := convertDiscussion(diffThread)
+	if !ok {
+		t.Fatal("convertDiscussion() skipped a resolvable thread")
+	}
+	if thread.Resolved || thread.Author != "alice" || thread.LastAuthor != "bob" || thread.Replies != 1 {
+		t.Errorf("convertDiscussion() = %+v", thread)
+	}
+	if got := threadLocation(thread); got != "main.go:42" {
+		t.Errorf("threadLocation() = %q, want main.go:42", got)
+	}
+
+	removedLine := utils.CreateMockDiscussion("r1", true, "Keep this?")
+	removedLine.Notes[0].Position = &gitlab.NotePosition{OldPath: "old.go", OldLine: 7}
+	if thread, _ := convertDiscussion(removedLine); threadLocation(thread) != "old.go:7" || !thread.Resolved {
+		t.Errorf("convertDiscussion() on a removed line = %+v", thread)
+	}
+
+	comment := utils.CreateMockDiscussion("c1", false, "LGTM")
+	comment.Notes[0].Resolvable = false
+	if _, ok := convertDiscussion(comment); ok {
+		t.Error("convertDiscussion() kept a plain comment")
+	}
+
+	// A thread with one open note is still unresolved
+	partial := utils.CreateMockDiscussion("p1", true, "first", "second")
+	partial.Notes[1].Resolved = false
+	if thread, _ := convertDiscussion(partial); thread.Resolved {
+		t.Error("convertDiscussion() marked a partially resolved thread as resolved")
+	}
+}
+
+func TestFindThread(t *testing.T) {
+	threads := []Thread{{ID: "abc111"}, {ID: "abc222"}, {ID: "def333"}}
+
+	tests := []struct {
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{"abc111", "abc111", false},
+		{"def", "def333", false},
+		{"abc", "", true},
+		{"xyz", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			got, err := FindThread(threads, tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindThread(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+			if err == nil && got.ID != tt.want {
+				t.Errorf("FindThread(%q) = %s, want %s", tt.id, got.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnresolvedThreads(t *testing.T) {
+	threads := []Thread{{ID: "a", Resolved: true}, {ID: "b"}, {ID: "c"}}
+	if got := UnresolvedThreads(threads); len(got) != 2 || got[0].ID != "b" {
+		t.Errorf("UnresolvedThreads() = %+v", got)
+	}
+}
+
+func TestThreadSummary(t *testing.T) {
+	if got := threadSummary("  First line\nsecond line"); got != "First line" {
+		t.Errorf("threadSummary() = %q", got)
+	}
+	long := "This comment is far too long to show in a listing because it goes on and on and on"
+	if got := threadSummary(long); len(got) != 72 {
+		t.Errorf("threadSummary() length = %d, want 72", len(got))
+	}
+}
+
+func TestBlockNoteReason(t *testing.T) {
+	if got := blockNoteReason("🚫 **Merge Blocked**: Needs review"); got != "Needs review" {
+		t.Errorf("blockNoteReason() = %q, want Needs review", got)
+	}
+	gate := "🚫 **Merge Blocked**: Unresolved discussion threads (2)\n" + stickyMarker(threadsGate)
+	if got := blockNoteReason(gate); got != "Unresolved discussion threads (2)" {
+		t.Errorf("blockNoteReason() of a gate note = %q", got)
+	}
+}
@@ -0,0 +1,86 @@
+package mergerequests
+
+import (
+	"fmt"
+	"time"
+
+	"mpg-gitlab/cmd/pipelines"
+	"mpg-gitlab/cmd/types"
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// mergeStatusTimeout bounds how long merge checks wait for GitLab to compute mergeability
+const mergeStatusTimeout = 2 * time.Minute
+
+// isMergeStatusPending reports whether GitLab is still computing whether an MR can be merged
+func isMergeStatusPending(mr *gitlab.MergeRequest) bool {
+	switch mr.MergeStatus {
+	case "unchecked", "checking", "cannot_be_merged_recheck":
+		return true
+	}
+	switch mr.DetailedMergeStatus {
+	case "unchecked", "checking", "preparing", "approvals_syncing":
+		return true
+	}
+	return false
+}
+
+// WaitMergeStatus waits until GitLab has finished computing the mergeability of an MR
+// It returns the refreshed merge request; whether it can be merged is up to the caller.
+func WaitMergeStatus(projectID, mrIID int, opts utils.WaitOptions) (*gitlab.MergeRequest, error) {
+	var mr *gitlab.MergeRequest
+	err := utils.Wait(opts, func() (bool, string, error) {
+		var err error
+		mr, _, err = client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to get merge request: %v", err)
+		}
+		return !isMergeStatusPending(mr), "merge status " + mergeStatusText(mr), nil
+	})
+	if err != nil {
+		return mr, fmt.Errorf("merge status not computed: %w", err)
+	}
+	return mr, nil
+}
+
+// mergeStatusText prefers the detailed merge status, which newer GitLab versions report
+func mergeStatusText(mr *gitlab.MergeRequest) string {
+	if mr.DetailedMergeStatus != "" {
+		return mr.DetailedMergeStatus
+	}
+	return mr.MergeStatus
+}
+
+// WaitHeadPipeline waits until a pipeline on the head commit of an MR has finished
+// With an empty sha it follows the MR head, so pushes while waiting are picked up.
+// The pipeline is looked up like merge and mr pipelines do, so a head pipeline GitLab
+// doesn't report as the MR's head_pipeline is still found.
+func WaitHeadPipeline(projectID, mrIID int, sha string, opts utils.WaitOptions) (*types.Pipeline, error) {
+	var pipeline *types.Pipeline
+	err := utils.Wait(opts, func() (bool, string, error) {
+		mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to get merge request: %v", err)
+		}
+
+		head := sha
+		if head == "" {
+			head = mr.SHA
+		}
+		p, err := pipelines.MergeRequestPipelineOn(projectID, mr, head)
+		if err != nil {
+			return false, "", err
+		}
+		if p == nil {
+			return false, "waiting for a pipeline on " + shortSHA(head), nil
+		}
+		pipeline = p
+		return pipelines.IsFinished(p.Status), fmt.Sprintf("pipeline #%d %s", p.ID, p.Status), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pipeline did not finish: %w", err)
+	}
+	return pipeline, nil
+}
@@ -0,0 +1,211 @@
+package milestones
+
+import (
+	"fmt"
+	"time"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Audit finding kinds
+const (
+	findingMissingMilestone = "missing-milestone"   // A merge request without a milestone
+	findingIssueMismatch    = "issue-mismatch"      // A linked issue in another milestone than its MR
+	findingClosingMismatch  = "closing-mr-mismatch" // A closed issue whose closing MR is in another milestone
+)
+
+// AuditOptions selects the merge requests to audit
+// Either Milestone or MergedAfter is set; MergedBefore optionally ends the range.
+type AuditOptions struct {
+	Milestone    string    // Milestone ID or title
+	MergedAfter  time.Time // Audit merge requests merged from this day
+	MergedBefore time.Time // Audit merge requests merged before this day; zero for now
+	Fix          bool      // Move mismatched issues to the right milestone
+}
+
+// AuditFinding is a milestone inconsistency between a merge request and an issue
+type AuditFinding struct {
+	Kind         string `json:"kind"`
+	ProjectID    int    `json:"project_id"`
+	MergeRequest int    `json:"merge_request"`
+	Issue        int    `json:"issue,omitempty"`
+	Expected     string `json:"expected,omitempty"` // Milestone the issue belongs in
+	Actual       string `json:"actual,omitempty"`   // Milestone the item is in
+	Message      string `json:"message"`
+	Fixed        bool   `json:"fixed"`
+	FixError     string `json:"fix_error,omitempty"`
+
+	fixMilestoneID int // Milestone that resolves the finding, 0 when it can't be fixed automatically
+}
+
+// AuditReport lists the milestone inconsistencies found by an audit
+type AuditReport struct {
+	Scope         string         `json:"scope"`
+	Milestone     string         `json:"milestone,omitempty"`
+	MergedAfter   string         `json:"merged_after,omitempty"`
+	MergedBefore  string         `json:"merged_before,omitempty"`
+	MergeRequests int            `json:"merge_requests"` // Merge requests scanned
+	Findings      []AuditFinding `json:"findings"`
+	Unresolved    int            `json:"unresolved"` // Findings that weren't fixed
+}
+
+// AuditMilestones checks the merge requests of a milestone, or those merged in a date range,
+// against their linked issues. For a milestone, its closed issues are also checked
+// against the merge requests that closed them. With Fix set, mismatched issues are
+// moved to the milestone of their merge request.
+func AuditMilestones(scope Scope, opts AuditOptions) (*AuditReport, error) {
+	report := &AuditReport{Scope: scope.String(), Findings: []AuditFinding{}}
+
+	var mrs []*gitlab.MergeRequest
+	var milestone *gitlab.Milestone
+	var err error
+	if opts.Milestone != "" {
+		if milestone, err = findMilestone(scope, opts.Milestone); err != nil {
+			return nil, err
+		}
+		report.Milestone = milestone.Title
+		// Closed merge requests never landed, so their issues can keep any milestone
+		for _, state := range []string{"opened", "merged"} {
+			var page []*gitlab.MergeRequest
+			if page, err = scope.milestoneMergeRequests(milestone, state); err != nil {
+				break
+			}
+			mrs = append(mrs, page...)
+		}
+	} else {
+		report.MergedAfter = opts.MergedAfter.Format(dateFormat)
+		if !opts.MergedBefore.IsZero() {
+			report.MergedBefore = opts.MergedBefore.Format(dateFormat)
+		}
+		mrs, err = scope.mergedMergeRequests(opts.MergedAfter, opts.MergedBefore)
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.MergeRequests = len(mrs)
+
+	for _, mr := range mrs {
+		var issues []*gitlab.Issue
+		for _, iid := range utils.GetIssueIDsFromDescription(mr.Description) {
+			issue, _, err := client.Issues.GetIssue(mr.ProjectID, iid)
+			if err != nil {
+				continue // Skip issues we can't access
+			}
+			issues = append(issues, issue)
+		}
+		report.Findings = append(report.Findings, auditMergeRequest(mr, issues)...)
+	}
+
+	if milestone != nil {
+		closed, err := scope.milestoneIssues(milestone, "closed")
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range closed {
+			closing, _, err := client.Issues.ListMergeRequestsClosingIssue(issue.ProjectID, issue.IID, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list merge requests closing issue #%d: %v", issue.IID, err)
+			}
+			if f := auditClosedIssue(milestone, issue, closing); f != nil {
+				report.Findings = append(report.Findings, *f)
+			}
+		}
+	}
+
+	for i := range report.Findings {
+		f := &report.Findings[i]
+		if opts.Fix && f.fixMilestoneID != 0 {
+			_, _, err := client.Issues.UpdateIssue(f.ProjectID, f.Issue, &gitlab.UpdateIssueOptions{MilestoneID: gitlab.Int(f.fixMilestoneID)})
+			if err != nil {
+				f.FixError = err.Error()
+			} else {
+				f.Fixed = true
+			}
+		}
+		if !f.Fixed {
+			report.Unresolved++
+		}
+	}
+
+	return report, nil
+}
+
+// auditMergeRequest finds a missing milestone on a merge request and linked issues in other milestones
+func auditMergeRequest(mr *gitlab.MergeRequest, issues []*gitlab.Issue) []AuditFinding {
+	if mr.Milestone == nil {
+		return []AuditFinding{{
+			Kind:         findingMissingMilestone,
+			ProjectID:    mr.ProjectID,
+			MergeRequest: mr.IID,
+			Message:      fmt.Sprintf("!%d has no milestone", mr.IID),
+		}}
+	}
+
+	var findings []AuditFinding
+	for _, issue := range issues {
+		if issue.Milestone != nil && issue.Milestone.ID == mr.Milestone.ID {
+			continue
+		}
+		findings = append(findings, AuditFinding{
+			Kind:           findingIssueMismatch,
+			ProjectID:      mr.ProjectID,
+			MergeRequest:   mr.IID,
+			Issue:          issue.IID,
+			Expected:       mr.Milestone.Title,
+			Actual:         titleOf(issue.Milestone),
+			Message:        fmt.Sprintf("#%d is in %s, but !%d linking it is in %q", issue.IID, milestoneTitle(issue.Milestone), mr.IID, mr.Milestone.Title),
+			fixMilestoneID: mr.Milestone.ID,
+		})
+	}
+	return findings
+}
+
+// auditClosedIssue finds a closed issue of a milestone whose closing merge request is in
+// another milestone. The most recently merged closing merge request counts; issues closed
+// without a merged merge request are left alone.
+func auditClosedIssue(milestone *gitlab.Milestone, issue *gitlab.Issue, closing []*gitlab.MergeRequest) *AuditFinding {
+	var latest *gitlab.MergeRequest
+	for _, mr := range closing {
+		if mr.State != "merged" || mr.MergedAt == nil {
+			continue
+		}
+		if latest == nil || mr.MergedAt.After(*latest.MergedAt) {
+			latest = mr
+		}
+	}
+	if latest == nil || (latest.Milestone != nil && latest.Milestone.ID == milestone.ID) {
+		return nil
+	}
+
+	finding := &AuditFinding{
+		Kind:         findingClosingMismatch,
+		ProjectID:    issue.ProjectID,
+		MergeRequest: latest.IID,
+		Issue:        issue.IID,
+		Actual:       milestone.Title,
+		Message: fmt.Sprintf("#%d is in %q, but !%d that closed it is in %s",
+			issue.IID, milestone.Title, latest.IID, milestoneTitle(latest.Milestone)),
+	}
+	if latest.Milestone != nil {
+		finding.Expected, finding.fixMilestoneID = latest.Milestone.Title, latest.Milestone.ID
+	}
+	return finding
+}
+
+// titleOf returns the title of a milestone, or "" without one
+func titleOf(m *gitlab.Milestone) string {
+	if m == nil {
+		return ""
+	}
+	return m.Title
+}
+
+// milestoneTitle names a milestone for audit messages
+func milestoneTitle(m *gitlab.Milestone) string {
+	if m == nil {
+		return "no milestone"
+	}
+	return fmt.Sprintf("%q", m.Title)
+}
@@ -0,0 +1,62 @@
+package milestones
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestAuditMergeRequest(t *testing.T) {
+	sprint := &gitlab.Milestone{ID: 4, Title: "Sprint 42"}
+	other := &gitlab.Milestone{ID: 3, Title: "Sprint 41"}
+
+	if got := auditMergeRequest(&gitlab.MergeRequest{IID: 7, ProjectID: 1}, nil); len(got) != 1 || got[0].Kind != findingMissingMilestone {
+		t.Errorf("auditMergeRequest() without a milestone = %+v", got)
+	}
+
+	mr := &gitlab.MergeRequest{IID: 8, ProjectID: 1, Milestone: sprint}
+	issues := []*gitlab.Issue{
+		{IID: 20, Milestone: sprint},
+		{IID: 21, Milestone: other},
+		{IID: 22},
+	}
+	got := auditMergeRequest(mr, issues)
+	if len(got) != 2 {
+		t.Fatalf("auditMergeRequest() = %+v, want findings for #21 and #22", got)
+	}
+	if f := got[0]; f.Kind != findingIssueMismatch || f.Issue != 21 || f.Actual != "Sprint 41" || f.Expected != "Sprint 42" || f.fixMilestoneID != 4 {
+		t.Errorf("finding for #21 = %+v", f)
+	}
+	if f := got[1]; f.Issue != 22 || f.Actual != "" || f.Message != `#22 is in no milestone, but !8 linking it is in "Sprint 42"` {
+		t.Errorf("finding for #22 = %+v", f)
+	}
+}
+
+func TestAuditClosedIssue(t *testing.T) {
+	sprint := &gitlab.Milestone{ID: 4, Title: "Sprint 42"}
+	next := &gitlab.Milestone{ID: 5, Title: "Sprint 43"}
+	merged := func(iid int, m *gitlab.Milestone, day string) *gitlab.MergeRequest {
+		at := date(day).Add(12 * time.Hour)
+		return &gitlab.MergeRequest{IID: iid, State: "merged", MergedAt: &at, Milestone: m}
+	}
+	issue := &gitlab.Issue{IID: 30, ProjectID: 1}
+
+	if f := auditClosedIssue(sprint, issue, []*gitlab.MergeRequest{merged(9, sprint, "2026-10-14")}); f != nil {
+		t.Errorf("auditClosedIssue() with a matching MR = %+v", f)
+	}
+	if f := auditClosedIssue(sprint, issue, []*gitlab.MergeRequest{{IID: 10, State: "opened", Milestone: next}}); f != nil {
+		t.Errorf("auditClosedIssue() without a merged MR = %+v", f)
+	}
+
+	// The latest merged MR decides
+	f := auditClosedIssue(sprint, issue, []*gitlab.MergeRequest{merged(9, sprint, "2026-10-14"), merged(11, next, "2026-10-27")})
+	if f == nil || f.Kind != findingClosingMismatch || f.MergeRequest != 11 || f.Expected != "Sprint 43" || f.fixMilestoneID != 5 {
+		t.Errorf("auditClosedIssue() = %+v, want a mismatch with !11", f)
+	}
+
+	f = auditClosedIssue(sprint, issue, []*gitlab.MergeRequest{merged(12, nil, "2026-10-14")})
+	if f == nil || f.fixMilestoneID != 0 || f.Message != `#30 is in "Sprint 42", but !12 that closed it is in no milestone` {
+		t.Errorf("auditClosedIssue() with an MR without milestone = %+v", f)
+	}
+}
@@ -0,0 +1,322 @@
+package milestones
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+
+	"mpg-gitlab/cmd/mergerequests"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Changelog output formats
+const (
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+	formatHTML     = "html"
+)
+
+// botPattern matches bot users: the users of project and group access tokens, like
+// project_12_bot_3f2a, and names ending in bot, like renovate-bot or dependabot[bot]
+var botPattern = regexp.MustCompile(`(?i)^(project|group)_\d+_bot(_\w+)?$|[-_]bot$|\[bot\]$`)
+
+// ChangelogItem is the release note of one merged merge request
+type ChangelogItem struct {
+	Category     string   `json:"category"`
+	Text         string   `json:"text"` // Entry text with its category tag, like "[Fix] Crash on start"
+	ProjectID    int      `json:"project_id"`
+	MergeRequest int      `json:"merge_request"`
+	URL          string   `json:"url,omitempty"`        // Web URL of the merge request
+	Source       string   `json:"source"`               // Where the entry was found: "!12" for the MR, "#34" for an issue
+	SourceURL    string   `json:"source_url,omitempty"` // Web URL of the source
+	Author       string   `json:"author,omitempty"`     // Username of the merge request author
+	Reviewers    []string `json:"reviewers,omitempty"`
+}
+
+// Changelog is the computed changelog of a milestone
+type Changelog struct {
+	MilestoneID  int             `json:"milestone_id"`
+	Milestone    string          `json:"milestone"`
+	Scope        string          `json:"scope"`
+	Items        []ChangelogItem `json:"items"`
+	Contributors []string        `json:"contributors"` // Authors of all merged merge requests, with or without an entry
+
+	group bool // Whether references need their project
+}
+
+// RenderOptions selects the extras rendered with a changelog
+type RenderOptions struct {
+	Attribution  bool     // Append the author and reviewer handles to each entry
+	Links        bool     // Link the merge request and issue of each entry
+	Contributors bool     // Add a Contributors section
+	HideBots     bool     // Leave bot accounts out of the handles and contributors
+	Bots         []string // Usernames to treat as bots besides the recognized ones
+}
+
+// MilestoneChangelog computes the changelog of a milestone from its merged merge requests
+// Each merge request contributes its primary entry, taken from its own description or
+// from a linked issue. Nothing is written to GitLab.
+func MilestoneChangelog(scope Scope, milestoneID int) (*Changelog, error) {
+	milestone, err := scope.getMilestone(milestoneID)
+	if err != nil {
+		return nil, err
+	}
+	mrs, err := scope.milestoneMergeRequests(milestone, "merged")
+	if err != nil {
+		return nil, err
+	}
+	return buildChangelog(scope, milestone, mrs), nil
+}
+
+// buildChangelog computes the changelog of a milestone from merge requests
+func buildChangelog(scope Scope, milestone *gitlab.Milestone, mrs []*gitlab.MergeRequest) *Changelog {
+	changelog := &Changelog{MilestoneID: milestone.ID, Milestone: milestone.Title, Scope: scope.String(), Items: []ChangelogItem{}, group: scope.IsGroup()}
+	for _, mr := range mrs {
+		entry := mergerequests.PrimaryChangelogEntry(mergerequests.CollectChangelogEntries(mr.ProjectID, mr))
+		if entry == nil {
+			continue
+		}
+		changelog.Items = append(changelog.Items, ChangelogItem{
+			Category:     entry.Category,
+			Text:         entry.Text,
+			ProjectID:    mr.ProjectID,
+			MergeRequest: mr.IID,
+			URL:          mr.WebURL,
+			Source:       entry.Source,
+			SourceURL:    entry.URL,
+			Author:       username(mr.Author),
+			Reviewers:    usernames(mr.Reviewers),
+		})
+	}
+	sortChangelogItems(changelog.Items)
+	changelog.Contributors = contributors(mrs)
+	return changelog
+}
+
+// contributors returns the unique authors of merge requests, sorted case-insensitively
+func contributors(mrs []*gitlab.MergeRequest) []string {
+	seen := map[string]bool{}
+	authors := []string{}
+	for _, mr := range mrs {
+		if name := username(mr.Author); name != "" && !seen[name] {
+			seen[name] = true
+			authors = append(authors, name)
+		}
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		return strings.ToLower(authors[i]) < strings.ToLower(authors[j])
+	})
+	return authors
+}
+
+// username returns the username of a user, or "" without one
+func username(u *gitlab.BasicUser) string {
+	if u == nil {
+		return ""
+	}
+	return u.Username
+}
+
+// usernames returns the usernames of users
+func usernames(users []*gitlab.BasicUser) []string {
+	var names []string
+	for _, u := range users {
+		if name := username(u); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// IsBotAccount reports whether a username belongs to a bot, either recognized by its
+// name or listed in extra
+func IsBotAccount(name string, extra []string) bool {
+	for _, bot := range extra {
+		if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(bot), "@"), name) {
+			return true
+		}
+	}
+	return botPattern.MatchString(name)
+}
+
+// withoutBots returns a copy of the changelog with bot accounts left out of the handles and contributors
+func (c *Changelog) withoutBots(extra []string) *Changelog {
+	humans := func(names []string) []string {
+		var kept []string
+		for _, name := range names {
+			if !IsBotAccount(name, extra) {
+				kept = append(kept, name)
+			}
+		}
+		return kept
+	}
+
+	filtered := *c
+	filtered.Items = make([]ChangelogItem, len(c.Items))
+	for i, item := range c.Items {
+		if IsBotAccount(item.Author, extra) {
+			item.Author = ""
+		}
+		item.Reviewers = humans(item.Reviewers)
+		filtered.Items[i] = item
+	}
+	filtered.Contributors = append([]string{}, humans(c.Contributors)...)
+	return &filtered
+}
+
+// sortChangelogItems orders items by category, in rendering order, then by text
+func sortChangelogItems(items []ChangelogItem) {
+	order := map[string]int{}
+	for i, c := range mergerequests.ChangelogCategories() {
+		order[c] = i
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if order[items[i].Category] != order[items[j].Category] {
+			return order[items[i].Category] < order[items[j].Category]
+		}
+		return items[i].Text < items[j].Text
+	})
+}
+
+// ValidateChangelogFormat verifies a changelog output format
+func ValidateChangelogFormat(format string) error {
+	switch format {
+	case formatMarkdown, formatJSON, formatHTML:
+		return nil
+	}
+	return fmt.Errorf("invalid format %q, expected %s, %s or %s", format, formatMarkdown, formatJSON, formatHTML)
+}
+
+// RenderChangelog renders a changelog as markdown, JSON or HTML
+// Markdown is also what the add-changelog commands write to milestone descriptions.
+// JSON always holds the attribution, links and contributors.
+func RenderChangelog(c *Changelog, format string, opts RenderOptions) (string, error) {
+	if err := ValidateChangelogFormat(format); err != nil {
+		return "", err
+	}
+	if opts.HideBots {
+		c = c.withoutBots(opts.Bots)
+	}
+
+	if format == formatJSON {
+		data, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to format JSON: %v", err)
+		}
+		return string(data) + "\n", nil
+	}
+
+	var b strings.Builder
+	if format == formatHTML {
+		b.WriteString("<h2>Changelog</h2>\n")
+	} else {
+		b.WriteString("## Changelog\n\n")
+	}
+	for _, group := range groupChangelogItems(c.Items) {
+		if format == formatHTML {
+			fmt.Fprintf(&b, "<h3>%s</h3>\n<ul>\n", html.EscapeString(group.category))
+			for _, item := range group.items {
+				fmt.Fprintf(&b, "<li>%s</li>\n", htmlChangelogLine(item, c.group, opts))
+			}
+			b.WriteString("</ul>\n")
+			continue
+		}
+		fmt.Fprintf(&b, "### [%s]\n", group.category)
+		for _, item := range group.items {
+			fmt.Fprintf(&b, "- %s\n", markdownChangelogLine(item, c.group, opts))
+		}
+		b.WriteString("\n")
+	}
+
+	if opts.Contributors && len(c.Contributors) > 0 {
+		if format == formatHTML {
+			b.WriteString("<h2>Contributors</h2>\n<ul>\n")
+			for _, name := range c.Contributors {
+				fmt.Fprintf(&b, "<li>@%s</li>\n", html.EscapeString(name))
+			}
+			b.WriteString("</ul>\n")
+		} else {
+			b.WriteString("## Contributors\n\n")
+			for _, name := range c.Contributors {
+				fmt.Fprintf(&b, "- @%s\n", name)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// markdownChangelogLine renders an entry like "[Fix] Crash ([!7](url)) by @alice, reviewed by @bob"
+func markdownChangelogLine(item ChangelogItem, group bool, opts RenderOptions) string {
+	ref := changelogItemRef(item, group)
+	if opts.Links && item.URL != "" {
+		ref = fmt.Sprintf("[!%d](%s)", item.MergeRequest, item.URL)
+		if strings.HasPrefix(item.Source, "#") && item.SourceURL != "" {
+			ref += fmt.Sprintf(", [%s](%s)", item.Source, item.SourceURL)
+		}
+	}
+	line := fmt.Sprintf("%s (%s)", item.Text, ref)
+	if opts.Attribution {
+		line += attribution(item)
+	}
+	return line
+}
+
+// htmlChangelogLine renders an entry as the escaped content of a list item
+func htmlChangelogLine(item ChangelogItem, group bool, opts RenderOptions) string {
+	ref := html.EscapeString(changelogItemRef(item, group))
+	if opts.Links && item.URL != "" {
+		ref = fmt.Sprintf(`<a href="%s">!%d</a>`, html.EscapeString(item.URL), item.MergeRequest)
+		if strings.HasPrefix(item.Source, "#") && item.SourceURL != "" {
+			ref += fmt.Sprintf(`, <a href="%s">%s</a>`, html.EscapeString(item.SourceURL), html.EscapeString(item.Source))
+		}
+	}
+	line := fmt.Sprintf("%s (%s)", html.EscapeString(item.Text), ref)
+	if opts.Attribution {
+		line += html.EscapeString(attribution(item))
+	}
+	return line
+}
+
+// attribution renders the handles of an entry, like " by @alice, reviewed by @bob"
+func attribution(item ChangelogItem) string {
+	var parts []string
+	if item.Author != "" {
+		parts = append(parts, "by @"+item.Author)
+	}
+	if len(item.Reviewers) > 0 {
+		parts = append(parts, "reviewed by @"+strings.Join(item.Reviewers, ", @"))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parts, ", ")
+}
+
+// changelogGroup holds the items of one category
+type changelogGroup struct {
+	category string
+	items    []ChangelogItem
+}
+
+// groupChangelogItems splits sorted items into their categories, keeping the order
+func groupChangelogItems(items []ChangelogItem) []changelogGroup {
+	var groups []changelogGroup
+	for _, item := range items {
+		if n := len(groups); n > 0 && groups[n-1].category == item.Category {
+			groups[n-1].items = append(groups[n-1].items, item)
+			continue
+		}
+		groups = append(groups, changelogGroup{category: item.Category, items: []ChangelogItem{item}})
+	}
+	return groups
+}
+
+// changelogItemRef renders the merge request of an item, like "!12"
+func changelogItemRef(item ChangelogItem, group bool) string {
+	return formatRefs("!", []ItemRef{{ProjectID: item.ProjectID, IID: item.MergeRequest}}, group)
+}
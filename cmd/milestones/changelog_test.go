@@ -0,0 +1,163 @@
+package milestones
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func testChangelog() *Changelog {
+	items := []ChangelogItem{
+		{Category: "Fix", Text: "[Fix] Crash on start", ProjectID: 1, MergeRequest: 7, URL: "https://gitlab.example.com/app/-/merge_requests/7",
+			Source: "!7", Author: "alice", Reviewers: []string{"bob", "project_1_bot_a1b2"}},
+		{Category: "Feature", Text: "[Feature] Dark <mode>", ProjectID: 1, MergeRequest: 9, URL: "https://gitlab.example.com/app/-/merge_requests/9",
+			Source: "#3", SourceURL: "https://gitlab.example.com/app/-/issues/3", Author: "renovate-bot"},
+		{Category: "Fix", Text: "[Fix] Broken & slow login", ProjectID: 1, MergeRequest: 8, Source: "!8"},
+	}
+	sortChangelogItems(items)
+	return &Changelog{MilestoneID: 5, Milestone: "Sprint 12", Scope: "project 1", Items: items,
+		Contributors: []string{"alice", "carol", "renovate-bot"}}
+}
+
+func TestSortChangelogItems(t *testing.T) {
+	c := testChangelog()
+	var got []int
+	for _, item := range c.Items {
+		got = append(got, item.MergeRequest)
+	}
+	if want := []int{9, 8, 7}; len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("sorted merge requests = %v, want %v", got, want)
+	}
+}
+
+func TestRenderChangelog(t *testing.T) {
+	c := testChangelog()
+
+	markdown, err := RenderChangelog(c, "markdown", RenderOptions{})
+	if err != nil {
+		t.Fatalf("RenderChangelog() error = %v", err)
+	}
+	wantMarkdown := strings.Join([]string{
+		"## Changelog",
+		"",
+		"### [Feature]",
+		"- [Feature] Dark <mode> (!9)",
+		"",
+		"### [Fix]",
+		"- [Fix] Broken & slow login (!8)",
+		"- [Fix] Crash on start (!7)",
+		"",
+		"",
+	}, "\n")
+	if markdown != wantMarkdown {
+		t.Errorf("markdown =\n%s\nwant\n%s", markdown, wantMarkdown)
+	}
+
+	html, err := RenderChangelog(c, "html", RenderOptions{})
+	if err != nil {
+		t.Fatalf("RenderChangelog() error = %v", err)
+	}
+	for _, want := range []string{"<h3>Feature</h3>", "<li>[Feature] Dark &lt;mode&gt; (!9)</li>", "<li>[Fix] Broken &amp; slow login (!8)</li>"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html missing %q:\n%s", want, html)
+		}
+	}
+
+	js, err := RenderChangelog(c, "json", RenderOptions{})
+	if err != nil {
+		t.Fatalf("RenderChangelog() error = %v", err)
+	}
+	if !strings.Contains(js, `"milestone": "Sprint 12"`) || !strings.Contains(js, `"source": "#3"`) {
+		t.Errorf("json output = %s", js)
+	}
+
+	if _, err := RenderChangelog(c, "pdf", RenderOptions{}); err == nil {
+		t.Error("RenderChangelog() with an unknown format should fail")
+	}
+}
+
+func TestRenderChangelogGroup(t *testing.T) {
+	c := &Changelog{Items: []ChangelogItem{{Category: "Fix", Text: "[Fix] Crash", ProjectID: 4, MergeRequest: 2}}, group: true}
+	got, _ := RenderChangelog(c, "markdown", RenderOptions{})
+	if !strings.Contains(got, "- [Fix] Crash (!2 (project 4))") {
+		t.Errorf("group markdown = %q", got)
+	}
+}
+
+func TestRenderChangelogAttribution(t *testing.T) {
+	c := testChangelog()
+	got, err := RenderChangelog(c, "markdown", RenderOptions{Attribution: true, Links: true, Contributors: true})
+	if err != nil {
+		t.Fatalf("RenderChangelog() error = %v", err)
+	}
+	want := strings.Join([]string{
+		"## Changelog",
+		"",
+		"### [Feature]",
+		"- [Feature] Dark <mode> ([!9](https://gitlab.example.com/app/-/merge_requests/9), [#3](https://gitlab.example.com/app/-/issues/3)) by @renovate-bot",
+		"",
+		"### [Fix]",
+		"- [Fix] Broken & slow login (!8)",
+		"- [Fix] Crash on start ([!7](https://gitlab.example.com/app/-/merge_requests/7)) by @alice, reviewed by @bob, @project_1_bot_a1b2",
+		"",
+		"## Contributors",
+		"",
+		"- @alice",
+		"- @carol",
+		"- @renovate-bot",
+		"",
+		"",
+	}, "\n")
+	if got != want {
+		t.Errorf("markdown =\n%s\nwant\n%s", got, want)
+	}
+
+	got, _ = RenderChangelog(c, "html", RenderOptions{Attribution: true, Links: true, Contributors: true, HideBots: true, Bots: []string{"@carol"}})
+	for _, want := range []string{
+		`<li>[Feature] Dark &lt;mode&gt; (<a href="https://gitlab.example.com/app/-/merge_requests/9">!9</a>, <a href="https://gitlab.example.com/app/-/issues/3">#3</a>)</li>`,
+		`(<a href="https://gitlab.example.com/app/-/merge_requests/7">!7</a>) by @alice, reviewed by @bob</li>`,
+		"<h2>Contributors</h2>\n<ul>\n<li>@alice</li>\n</ul>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("html missing %q:\n%s", want, got)
+		}
+	}
+	if c.Items[0].Author != "renovate-bot" {
+		t.Error("HideBots changed the original changelog")
+	}
+}
+
+func TestIsBotAccount(t *testing.T) {
+	tests := map[string]bool{
+		"project_12_bot_3f2a": true,
+		"group_4_bot":         true,
+		"renovate-bot":        true,
+		"dependabot[bot]":     true,
+		"release_bot":         true,
+		"abbot":               false,
+		"alice":               false,
+		"carol":               false,
+	}
+	for name, want := range tests {
+		if got := IsBotAccount(name, nil); got != want {
+			t.Errorf("IsBotAccount(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if !IsBotAccount("Carol", []string{"@carol"}) {
+		t.Error("IsBotAccount() should match usernames listed as bots")
+	}
+}
+
+func TestContributors(t *testing.T) {
+	mrs := []*gitlab.MergeRequest{
+		{Author: &gitlab.BasicUser{Username: "bob"}},
+		{Author: &gitlab.BasicUser{Username: "Alice"}},
+		{Author: &gitlab.BasicUser{Username: "bob"}},
+		{},
+	}
+	got := contributors(mrs)
+	if len(got) != 2 || got[0] != "Alice" || got[1] != "bob" {
+		t.Errorf("contributors() = %v, want [Alice bob]", got)
+	}
+}
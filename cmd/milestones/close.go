@@ -0,0 +1,264 @@
+package milestones
+
+import (
+	"fmt"
+	"strings"
+
+	"mpg-gitlab/cmd/mergerequests"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Milestone close checks
+const (
+	checkOpenIssues        = "open-issues"
+	checkOpenMergeRequests = "open-merge-requests"
+	checkChangelogEntries  = "changelog-entries"
+	checkChangelogSection  = "changelog-section"
+)
+
+// CloseOptions configures how a milestone is closed
+type CloseOptions struct {
+	Check      bool // Refuse to close when a close check fails
+	ReportOnly bool // Only run the checks; never move or close anything
+	MoveTo     int  // Move open issues and merge requests to this milestone first
+}
+
+// CloseCheck is the outcome of a single milestone close check
+type CloseCheck struct {
+	Name    string    `json:"name"`
+	Passed  bool      `json:"passed"`
+	Message string    `json:"message"`
+	Items   []ItemRef `json:"items,omitempty"` // Issues or merge requests that fail the check
+}
+
+// CloseResult describes what closing a milestone did, or would do
+type CloseResult struct {
+	MilestoneID   int          `json:"milestone_id"`
+	Title         string       `json:"title"`
+	MovedTo       int          `json:"moved_to,omitempty"`
+	Issues        []ItemRef    `json:"moved_issues,omitempty"`         // Open issues moved to MovedTo
+	MergeRequests []ItemRef    `json:"moved_merge_requests,omitempty"` // Open merge requests moved to MovedTo
+	Checks        []CloseCheck `json:"checks,omitempty"`
+	Closed        bool         `json:"closed"`
+}
+
+// MergedChangelog is the changelog state of a merged merge request
+type MergedChangelog struct {
+	Ref      ItemRef
+	Entry    string // Release note text, like "[Fix] Crash on start"; empty without one
+	Required bool   // Whether the changelog policy requires an entry
+}
+
+// CloseMilestone closes a milestone, optionally moving leftovers to another milestone first
+// With Check set the milestone is only closed when every close check passes; the
+// failing checks are returned in the result along with an error, and nothing is moved.
+func CloseMilestone(scope Scope, milestoneID int, opts CloseOptions) (*CloseResult, error) {
+	milestone, err := scope.getMilestone(milestoneID)
+	if err != nil {
+		return nil, err
+	}
+	result := &CloseResult{MilestoneID: milestone.ID, Title: milestone.Title}
+	if milestone.State == "closed" {
+		return result, fmt.Errorf("milestone %q is already closed", milestone.Title)
+	}
+
+	openIssues, err := scope.milestoneIssues(milestone, "opened")
+	if err != nil {
+		return result, err
+	}
+	openMRs, err := scope.milestoneMergeRequests(milestone, "opened")
+	if err != nil {
+		return result, err
+	}
+	issues, mrs := issueRefs(openIssues), mergeRequestRefs(openMRs)
+
+	var target *gitlab.Milestone
+	if opts.MoveTo != 0 {
+		target, err = scope.getMilestone(opts.MoveTo)
+		if err != nil {
+			return result, err
+		}
+		if target.ID == milestone.ID || target.State != "active" {
+			return result, fmt.Errorf("leftovers can only be moved to another active milestone, not %q", target.Title)
+		}
+		result.MovedTo, result.Issues, result.MergeRequests = target.ID, issues, mrs
+	}
+
+	// The checks run before anything is moved, so a failing check leaves the milestone untouched
+	if opts.Check || opts.ReportOnly {
+		merged, err := mergedChangelogs(scope, milestone)
+		if err != nil {
+			return result, err
+		}
+		leftIssues, leftMRs := issues, mrs
+		if target != nil {
+			// Items about to be moved no longer hold the milestone open
+			leftIssues, leftMRs = nil, nil
+		}
+		result.Checks = EvaluateCloseChecks(milestone.Description, leftIssues, leftMRs, merged)
+
+		if failed := failedCloseChecks(result.Checks); len(failed) > 0 && !opts.ReportOnly {
+			return result, fmt.Errorf("milestone %q not closed, failed checks: %s", milestone.Title, strings.Join(failed, ", "))
+		}
+	}
+	if opts.ReportOnly {
+		return result, nil
+	}
+
+	if target != nil {
+		if err := moveItems(issues, mrs, target.ID); err != nil {
+			return result, err
+		}
+	}
+
+	_, err = scope.updateMilestone(milestone.ID, &gitlab.UpdateMilestoneOptions{
+		StateEvent: gitlab.String("close"),
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to close milestone %q: %v", milestone.Title, err)
+	}
+	result.Closed = true
+	return result, nil
+}
+
+// mergedChangelogs returns the changelog state of the merged merge requests of a milestone
+// Policies are loaded once per project and target branch.
+func mergedChangelogs(scope Scope, milestone *gitlab.Milestone) ([]MergedChangelog, error) {
+	mrs, err := scope.milestoneMergeRequests(milestone, "merged")
+	if err != nil {
+		return nil, err
+	}
+
+	policies := map[string]*mergerequests.ChangelogPolicy{}
+	var merged []MergedChangelog
+	for _, mr := range mrs {
+		key := fmt.Sprintf("%d:%s", mr.ProjectID, mr.TargetBranch)
+		policy, ok := policies[key]
+		if !ok {
+			policy, _, err = mergerequests.LoadChangelogPolicy(mr.ProjectID, "", mr.TargetBranch)
+			if err != nil {
+				return nil, err
+			}
+			policies[key] = policy
+		}
+
+		result, err := mergerequests.CheckChangelogPolicy(mr.ProjectID, mr, policy)
+		if err != nil {
+			return nil, err
+		}
+		change := MergedChangelog{Ref: ItemRef{ProjectID: mr.ProjectID, IID: mr.IID}, Required: !result.Passed}
+		if entry := mergerequests.PrimaryChangelogEntry(result.Entries); entry != nil {
+			change.Entry = entry.Text
+		}
+		merged = append(merged, change)
+	}
+	return merged, nil
+}
+
+// EvaluateCloseChecks runs the close checks against the leftovers and merged changes of a milestone
+func EvaluateCloseChecks(description string, openIssues, openMRs []ItemRef, merged []MergedChangelog) []CloseCheck {
+	checks := []CloseCheck{
+		refsCheck(checkOpenIssues, "#", "open issue(s)", openIssues),
+		refsCheck(checkOpenMergeRequests, "!", "open merge request(s)", openMRs),
+	}
+
+	var missingEntries []ItemRef
+	for _, m := range merged {
+		if m.Required {
+			missingEntries = append(missingEntries, m.Ref)
+		}
+	}
+	checks = append(checks, refsCheck(checkChangelogEntries, "!", "merged merge request(s) without a changelog entry", missingEntries))
+
+	// Every release note must be in the changelog section, and every line there must belong to one
+	lines := changelogLines(description)
+	var missing []ItemRef
+	for _, m := range merged {
+		if m.Entry != "" && !lineContaining(lines, m.Entry) {
+			missing = append(missing, m.Ref)
+		}
+	}
+	var stale []string
+	for _, line := range lines {
+		if !entryForLine(merged, line) {
+			stale = append(stale, line)
+		}
+	}
+
+	section := CloseCheck{Name: checkChangelogSection, Passed: len(missing) == 0 && len(stale) == 0, Items: missing}
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("%d entry(s) missing: %s", len(missing), formatRefs("!", missing, false)))
+	}
+	if len(stale) > 0 {
+		problems = append(problems, fmt.Sprintf("%d line(s) match no merged merge request: %s", len(stale), strings.Join(stale, "; ")))
+	}
+	if section.Passed {
+		section.Message = fmt.Sprintf("%d line(s) match the merged merge requests", len(lines))
+	} else {
+		section.Message = strings.Join(problems, "; ")
+	}
+
+	return append(checks, section)
+}
+
+// refsCheck passes when there are no failing references
+func refsCheck(name, prefix, what string, refs []ItemRef) CloseCheck {
+	if len(refs) == 0 {
+		return CloseCheck{Name: name, Passed: true, Message: "no " + what}
+	}
+	return CloseCheck{
+		Name:    name,
+		Message: fmt.Sprintf("%d %s: %s", len(refs), what, formatRefs(prefix, refs, false)),
+		Items:   refs,
+	}
+}
+
+// changelogLines returns the entry lines of the "## Changelog" section of a description
+// The section runs until the next second-level heading; category headings inside it are skipped.
+func changelogLines(description string) []string {
+	var lines []string
+	inSection := false
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "## "):
+			inSection = strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(line, "## ")), "Changelog")
+		case inSection && strings.HasPrefix(line, "- "):
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// lineContaining reports whether a changelog line contains an entry
+func lineContaining(lines []string, entry string) bool {
+	for _, line := range lines {
+		if strings.Contains(line, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// entryForLine reports whether a changelog line holds the entry of a merged merge request
+func entryForLine(merged []MergedChangelog, line string) bool {
+	for _, m := range merged {
+		if m.Entry != "" && strings.Contains(line, m.Entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// failedCloseChecks returns the names of the close checks that did not pass
+func failedCloseChecks(checks []CloseCheck) []string {
+	var failed []string
+	for _, c := range checks {
+		if !c.Passed {
+			failed = append(failed, c.Name)
+		}
+	}
+	return failed
+}
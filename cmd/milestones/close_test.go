@@ -0,0 +1,85 @@
+package milestones
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const closeDescription = `Sprint goals
+
+## Changelog
+
+### [Feature]
+- [Feature] Dark mode (#12)
+
+### [Fix]
+- [Fix] Crash on start (#14)
+- [Fix] Reverted change (#9)
+
+## Notes
+- not a changelog line`
+
+func TestChangelogLines(t *testing.T) {
+	want := []string{"- [Feature] Dark mode (#12)", "- [Fix] Crash on start (#14)", "- [Fix] Reverted change (#9)"}
+	if got := changelogLines(closeDescription); !reflect.DeepEqual(got, want) {
+		t.Errorf("changelogLines() = %q, want %q", got, want)
+	}
+
+	// The format written by milestones add-changelog
+	got := changelogLines("## Changelog\n- MR #3: [Fix] Typo\n")
+	if !reflect.DeepEqual(got, []string{"- MR #3: [Fix] Typo"}) {
+		t.Errorf("changelogLines() = %q", got)
+	}
+	if got := changelogLines("No changelog yet"); got != nil {
+		t.Errorf("changelogLines() without a section = %q", got)
+	}
+}
+
+func TestEvaluateCloseChecks(t *testing.T) {
+	merged := []MergedChangelog{
+		{Ref: ItemRef{ProjectID: 1, IID: 12}, Entry: "[Feature] Dark mode"},
+		{Ref: ItemRef{ProjectID: 1, IID: 14}, Entry: "[Fix] Crash on start"},
+		{Ref: ItemRef{ProjectID: 1, IID: 15}, Entry: "[Fix] Slow search"},
+		{Ref: ItemRef{ProjectID: 1, IID: 16}, Required: true},
+		{Ref: ItemRef{ProjectID: 1, IID: 17}}, // Exempt by policy
+	}
+	checks := EvaluateCloseChecks(closeDescription, []ItemRef{{ProjectID: 1, IID: 3}}, nil, merged)
+
+	byName := map[string]CloseCheck{}
+	for _, c := range checks {
+		byName[c.Name] = c
+	}
+	if c := byName[checkOpenIssues]; c.Passed || c.Message != "1 open issue(s): #3" {
+		t.Errorf("open issues check = %+v", c)
+	}
+	if c := byName[checkOpenMergeRequests]; !c.Passed {
+		t.Errorf("open merge requests check = %+v", c)
+	}
+	if c := byName[checkChangelogEntries]; c.Passed || !reflect.DeepEqual(c.Items, []ItemRef{{ProjectID: 1, IID: 16}}) {
+		t.Errorf("changelog entries check = %+v", c)
+	}
+
+	c := byName[checkChangelogSection]
+	if c.Passed || !reflect.DeepEqual(c.Items, []ItemRef{{ProjectID: 1, IID: 15}}) {
+		t.Errorf("changelog section check = %+v, want !15 missing", c)
+	}
+	if !strings.Contains(c.Message, "- [Fix] Reverted change (#9)") {
+		t.Errorf("changelog section check message %q doesn't name the stale line", c.Message)
+	}
+	if got := failedCloseChecks(checks); !reflect.DeepEqual(got, []string{checkOpenIssues, checkChangelogEntries, checkChangelogSection}) {
+		t.Errorf("failedCloseChecks() = %v", got)
+	}
+}
+
+func TestEvaluateCloseChecksPassing(t *testing.T) {
+	merged := []MergedChangelog{
+		{Ref: ItemRef{ProjectID: 1, IID: 12}, Entry: "[Feature] Dark mode"},
+		{Ref: ItemRef{ProjectID: 1, IID: 14}, Entry: "[Fix] Crash on start"},
+		{Ref: ItemRef{ProjectID: 1, IID: 9}, Entry: "[Fix] Reverted change"},
+	}
+	checks := EvaluateCloseChecks(closeDescription, nil, nil, merged)
+	if failed := failedCloseChecks(checks); len(failed) != 0 {
+		t.Errorf("failedCloseChecks() = %v, want none: %+v", failed, checks)
+	}
+}
@@ -0,0 +1,189 @@
+package milestones
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"mpg-gitlab/cmd/types"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// dateHelp lists the accepted date expressions for error messages
+const dateHelp = "use YYYY-MM-DD, today, tomorrow, yesterday, +3d, +2w, -1m or next <weekday>"
+
+// relativeDatePattern matches offsets like "+2w" or "-3d"
+var relativeDatePattern = regexp.MustCompile(`^([+-])(\d+)([dwm])$`)
+
+// ParseDate parses a milestone date relative to today
+// It accepts YYYY-MM-DD, today, tomorrow, yesterday, offsets in days, weeks or months
+// like "+2w", and "next friday" for the first such weekday after today.
+func ParseDate(expr string, today time.Time) (time.Time, error) {
+	today = types.TruncateDay(today)
+	value := strings.ToLower(strings.Join(strings.Fields(expr), " "))
+
+	switch value {
+	case "":
+		return time.Time{}, fmt.Errorf("empty date, %s", dateHelp)
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if m := relativeDatePattern.FindStringSubmatch(value); m != nil {
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date offset %q: %v", expr, err)
+		}
+		if m[1] == "-" {
+			n = -n
+		}
+		switch m[3] {
+		case "d":
+			return today.AddDate(0, 0, n), nil
+		case "w":
+			return today.AddDate(0, 0, 7*n), nil
+		default:
+			return today.AddDate(0, n, 0), nil
+		}
+	}
+
+	if name, ok := strings.CutPrefix(value, "next "); ok {
+		weekday, ok := parseWeekday(name)
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid date %q: unknown weekday %q", expr, name)
+		}
+		days := (int(weekday)-int(today.Weekday())+6)%7 + 1
+		return today.AddDate(0, 0, days), nil
+	}
+
+	// time.Parse rejects impossible dates such as 2026-13-01 or 2026-02-30
+	parsed, err := time.Parse(dateFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, %s", expr, dateHelp)
+	}
+	return parsed, nil
+}
+
+// parseWeekday parses a weekday name, either in full or abbreviated like "fri"
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// parseDateFlag parses a date flag, returning nil when it isn't set
+func parseDateFlag(value, flag string, today time.Time) (*gitlab.ISOTime, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := ParseDate(value, today)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %v", flag, err)
+	}
+	iso := gitlab.ISOTime(parsed)
+	return &iso, nil
+}
+
+// dateChange holds the parsed start and due date flags of a create or update
+type dateChange struct {
+	Start *gitlab.ISOTime // nil when the start date isn't changed
+	Due   *gitlab.ISOTime // nil when the due date isn't changed
+}
+
+// parseDateChange parses the start and due date flags and validates the range the milestone
+// ends up with. For an update, current provides the dates that aren't changed; unless
+// allowOverlap is set, the range may not overlap another active milestone of the scope.
+func parseDateChange(scope Scope, startExpr, dueExpr string, current *gitlab.Milestone, allowOverlap bool, today time.Time) (*dateChange, error) {
+	change := &dateChange{}
+	var err error
+	if change.Start, err = parseDateFlag(startExpr, "start-date", today); err != nil {
+		return nil, err
+	}
+	if change.Due, err = parseDateFlag(dueExpr, "due-date", today); err != nil {
+		return nil, err
+	}
+	if change.Start == nil && change.Due == nil {
+		return change, nil
+	}
+
+	start, due, skip := change.Start, change.Due, 0
+	if current != nil {
+		skip = current.ID
+		if start == nil {
+			start = current.StartDate
+		}
+		if due == nil {
+			due = current.DueDate
+		}
+	}
+	if err := validateDateRange(start, due); err != nil {
+		return nil, err
+	}
+	if !allowOverlap {
+		if err := checkOverlaps(scope, skip, start, due); err != nil {
+			return nil, err
+		}
+	}
+	return change, nil
+}
+
+// validateDateRange fails when a milestone would be due before it starts
+func validateDateRange(start, due *gitlab.ISOTime) error {
+	if start != nil && due != nil && time.Time(*due).Before(time.Time(*start)) {
+		return fmt.Errorf("due date %s is before start date %s", due, start)
+	}
+	return nil
+}
+
+// overlappingMilestones returns the milestones whose start to due date range overlaps
+// the given one, skipping the milestone with ID skip
+// Milestones without both dates have no range and never overlap.
+func overlappingMilestones(milestones []*gitlab.Milestone, skip int, start, due *gitlab.ISOTime) []*gitlab.Milestone {
+	if start == nil || due == nil {
+		return nil
+	}
+
+	var overlaps []*gitlab.Milestone
+	for _, m := range milestones {
+		if m.ID == skip || m.StartDate == nil || m.DueDate == nil {
+			continue
+		}
+		if !time.Time(*m.StartDate).After(time.Time(*due)) && !time.Time(*start).After(time.Time(*m.DueDate)) {
+			overlaps = append(overlaps, m)
+		}
+	}
+	return overlaps
+}
+
+// checkOverlaps fails when the dates overlap another active milestone of the scope
+func checkOverlaps(scope Scope, skip int, start, due *gitlab.ISOTime) error {
+	if start == nil || due == nil {
+		return nil
+	}
+	active, err := scope.listMilestones("active", "")
+	if err != nil {
+		return err
+	}
+
+	overlaps := overlappingMilestones(active, skip, start, due)
+	if len(overlaps) == 0 {
+		return nil
+	}
+	names := make([]string, len(overlaps))
+	for i, m := range overlaps {
+		names[i] = fmt.Sprintf("#%d %q (%s to %s)", m.ID, m.Title, m.StartDate, m.DueDate)
+	}
+	return fmt.Errorf("%s to %s overlaps active milestone(s) %s; use --allow-overlap to save anyway",
+		start, due, strings.Join(names, ", "))
+}
@@ -0,0 +1,97 @@
+package milestones
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestParseDate(t *testing.T) {
+	today := date("2026-10-21") // A Wednesday
+	tests := []struct {
+		expr    string
+		want    string
+		wantErr string
+	}{
+		{expr: "2026-11-02", want: "2026-11-02"},
+		{expr: "today", want: "2026-10-21"},
+		{expr: "Tomorrow", want: "2026-10-22"},
+		{expr: "yesterday", want: "2026-10-20"},
+		{expr: "+3d", want: "2026-10-24"},
+		{expr: "+2w", want: "2026-11-04"},
+		{expr: "-1w", want: "2026-10-14"},
+		{expr: "+1m", want: "2026-11-21"},
+		{expr: "next friday", want: "2026-10-23"},
+		{expr: "next  Wed", want: "2026-10-28"},
+		{expr: "next tuesday", want: "2026-10-27"},
+		{expr: "2026-13-01", wantErr: `invalid date "2026-13-01"`},
+		{expr: "2026-02-30", wantErr: `invalid date "2026-02-30"`},
+		{expr: "21.10.2026", wantErr: "use YYYY-MM-DD"},
+		{expr: "+2y", wantErr: `invalid date "+2y"`},
+		{expr: "next someday", wantErr: `unknown weekday "someday"`},
+		{expr: " ", wantErr: "empty date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseDate(tt.expr, today.Add(15))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseDate(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got.Format(dateFormat) != tt.want {
+				t.Errorf("ParseDate(%q) = %s, %v, want %s", tt.expr, got.Format(dateFormat), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDateRange(t *testing.T) {
+	if err := validateDateRange(isoDate("2026-10-12"), isoDate("2026-10-25")); err != nil {
+		t.Errorf("validateDateRange() = %v", err)
+	}
+	if err := validateDateRange(isoDate("2026-10-12"), isoDate("2026-10-12")); err != nil {
+		t.Errorf("validateDateRange() of a single day = %v", err)
+	}
+	err := validateDateRange(isoDate("2026-10-12"), isoDate("2026-10-11"))
+	if err == nil || err.Error() != "due date 2026-10-11 is before start date 2026-10-12" {
+		t.Errorf("validateDateRange() = %v", err)
+	}
+	if err := validateDateRange(nil, isoDate("2026-10-11")); err != nil {
+		t.Errorf("validateDateRange() without a start date = %v", err)
+	}
+}
+
+func TestOverlappingMilestones(t *testing.T) {
+	milestones := []*gitlab.Milestone{
+		{ID: 1, Title: "Sprint 41", StartDate: isoDate("2026-09-28"), DueDate: isoDate("2026-10-11")},
+		{ID: 2, Title: "Sprint 42", StartDate: isoDate("2026-10-12"), DueDate: isoDate("2026-10-25")},
+		{ID: 3, Title: "Backlog"},
+		{ID: 4, Title: "Release", DueDate: isoDate("2026-10-20")},
+	}
+
+	ids := func(ms []*gitlab.Milestone) []int {
+		var out []int
+		for _, m := range ms {
+			out = append(out, m.ID)
+		}
+		return out
+	}
+
+	if got := ids(overlappingMilestones(milestones, 0, isoDate("2026-10-26"), isoDate("2026-11-08"))); got != nil {
+		t.Errorf("next sprint overlaps %v", got)
+	}
+	if got := ids(overlappingMilestones(milestones, 0, isoDate("2026-10-11"), isoDate("2026-10-12"))); len(got) != 2 {
+		t.Errorf("range touching both sprints overlaps %v, want [1 2]", got)
+	}
+	// A milestone doesn't overlap itself when its dates are updated
+	if got := ids(overlappingMilestones(milestones, 2, isoDate("2026-10-12"), isoDate("2026-10-26"))); got != nil {
+		t.Errorf("updated sprint overlaps %v", got)
+	}
+	if got := overlappingMilestones(milestones, 0, nil, isoDate("2026-10-20")); got != nil {
+		t.Errorf("range without a start date overlaps %v", ids(got))
+	}
+}
@@ -1,8 +1,10 @@
 package milestones
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"mpg-gitlab/cmd/utils"
@@ -49,83 +51,177 @@ var (
 		Run:   runDelete,
 	}
 
+	closeCmd = &cobra.Command{
+		Use:   "close",
+		Short: "Close a milestone, optionally checking it is finished first",
+		Run:   runClose,
+	}
+
+	moveCmd = &cobra.Command{
+		Use:   "move",
+		Short: "Move issues and merge requests from one milestone to another",
+		Run:   runMove,
+	}
+
+	auditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "Find merge requests and issues with mismatched milestones",
+		Run:   runAudit,
+	}
+
+	rotateCmd = &cobra.Command{
+		Use:   "rotate",
+		Short: "Close the current sprint milestone and open the next one",
+		Run:   runRotate,
+	}
+
+	reportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Show milestone progress and burndown",
+		Run:   runReport,
+	}
+
 	addChangelogCmd = &cobra.Command{
 		Use:   "add-changelog",
 		Short: "Add changelog entries from merge requests to milestone release notes",
 		Run:   runAddChangelog,
 	}
+
+	// MergeRequestAddChangelogCmd is mr add-changelog. It lives here so it writes the
+	// changelog like milestones add-changelog; main attaches it to the mr commands.
+	MergeRequestAddChangelogCmd = &cobra.Command{
+		Use:   "add-changelog",
+		Short: "Add changelog entry from merge request to its milestone",
+		Run:   runMergeRequestAddChangelog,
+	}
+
+	changelogCmd = &cobra.Command{
+		Use:   "changelog",
+		Short: "Work with milestone changelogs",
+	}
+
+	changelogShowCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Render the changelog computed from a milestone's merged merge requests",
+		Run:   runChangelogShow,
+	}
 )
 
 func init() {
 	client = utils.GetClient()
 
 	// Add subcommands
-	MilestonesCmd.AddCommand(listCmd, getCmd, createCmd, updateCmd, deleteCmd, addChangelogCmd)
+	MilestonesCmd.AddCommand(listCmd, getCmd, createCmd, updateCmd, deleteCmd, addChangelogCmd, rotateCmd, reportCmd, closeCmd, moveCmd, auditCmd, changelogCmd)
+	changelogCmd.AddCommand(changelogShowCmd)
+
+	// Project or group flags
+	for _, c := range []*cobra.Command{listCmd, getCmd, createCmd, updateCmd, deleteCmd, rotateCmd, reportCmd, closeCmd, moveCmd, auditCmd, changelogShowCmd} {
+		addScopeFlags(c)
+	}
 
 	// List flags
-	listCmd.Flags().IntP("project", "p", 0, "Project ID")
 	listCmd.Flags().StringP("state", "s", "", "Milestone state (active/closed)")
 
 	// Get flags
-	getCmd.Flags().IntP("project", "p", 0, "Project ID")
 	getCmd.Flags().IntP("milestone", "m", 0, "Milestone ID")
 	getCmd.MarkFlagRequired("milestone")
 
 	// Create flags
-	createCmd.Flags().IntP("project", "p", 0, "Project ID")
 	createCmd.Flags().StringP("title", "t", "", "Milestone title")
 	createCmd.Flags().StringP("description", "d", "", "Milestone description")
-	createCmd.Flags().StringP("due-date", "D", "", "Due date (YYYY-MM-DD)")
+	createCmd.Flags().StringP("start-date", "S", "", "Start date (YYYY-MM-DD, +2w, next monday, ...)")
+	createCmd.Flags().StringP("due-date", "D", "", "Due date (YYYY-MM-DD, +2w, next friday, ...)")
+	createCmd.Flags().Bool("allow-overlap", false, "Allow dates that overlap another active milestone")
 	createCmd.MarkFlagRequired("title")
 
 	// Update flags
-	updateCmd.Flags().IntP("project", "p", 0, "Project ID")
 	updateCmd.Flags().IntP("milestone", "m", 0, "Milestone ID")
 	updateCmd.Flags().StringP("title", "t", "", "New milestone title")
 	updateCmd.Flags().StringP("description", "d", "", "New milestone description")
-	updateCmd.Flags().StringP("due-date", "D", "", "Due date (YYYY-MM-DD)")
+	updateCmd.Flags().StringP("start-date", "S", "", "Start date (YYYY-MM-DD, +2w, next monday, ...)")
+	updateCmd.Flags().StringP("due-date", "D", "", "Due date (YYYY-MM-DD, +2w, next friday, ...)")
+	updateCmd.Flags().Bool("allow-overlap", false, "Allow dates that overlap another active milestone")
 	updateCmd.Flags().StringP("state", "s", "", "State event (close/activate)")
 	updateCmd.MarkFlagRequired("milestone")
 
 	// Delete flags
-	deleteCmd.Flags().IntP("project", "p", 0, "Project ID")
 	deleteCmd.Flags().IntP("milestone", "m", 0, "Milestone ID")
 	deleteCmd.MarkFlagRequired("milestone")
 
+	// Close flags
+	closeCmd.Flags().IntP("milestone", "m", 0, "Milestone ID")
+	closeCmd.Flags().Bool("check", false, "Refuse to close when open items or changelog problems remain")
+	closeCmd.Flags().Bool("report", false, "Only report the close checks; don't move or close anything")
+	closeCmd.Flags().Int("move-to", 0, "Move open issues and merge requests to this milestone ID first")
+	closeCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	closeCmd.MarkFlagRequired("milestone")
+
+	// Move flags
+	moveCmd.Flags().String("from", "", "Milestone to move items from (ID or title)")
+	moveCmd.Flags().String("to", "", "Milestone to move items to (ID or title), or none to remove the milestone")
+	moveCmd.Flags().StringP("state", "s", "opened", "Item state (opened/closed/merged/all)")
+	moveCmd.Flags().StringP("labels", "l", "", "Comma-separated labels the items must all carry")
+	moveCmd.Flags().String("assignee", "", "Assignee username (or none/any)")
+	moveCmd.Flags().String("type", moveAll, "Items to move (all/issues/merge-requests)")
+	moveCmd.Flags().Int("concurrency", DefaultMoveConcurrency, "Items updated at once")
+	moveCmd.Flags().Bool("dry-run", false, "Only list the items that would be moved")
+	moveCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	moveCmd.MarkFlagRequired("from")
+	moveCmd.MarkFlagRequired("to")
+
+	// Audit flags
+	auditCmd.Flags().StringP("milestone", "m", "", "Audit the merge requests of this milestone (ID or title)")
+	auditCmd.Flags().String("merged-after", "", "Audit merge requests merged from this date (YYYY-MM-DD, -2w, ...)")
+	auditCmd.Flags().String("merged-before", "", "Audit merge requests merged before this date (default now)")
+	auditCmd.Flags().Bool("fix", false, "Move mismatched issues to the milestone of their merge request")
+	auditCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	auditCmd.Flags().StringP("output", "o", "", "Also write the report as JSON to this file, e.g. a CI artifact")
+	auditCmd.MarkFlagsMutuallyExclusive("milestone", "merged-after")
+	auditCmd.MarkFlagsMutuallyExclusive("milestone", "merged-before")
+
 	// Add changelog flags
 	addChangelogCmd.Flags().IntP("merge-request", "r", 0, "Merge request IID")
 	addChangelogCmd.Flags().IntP("milestone", "m", 0, "Milestone ID")
 	addChangelogCmd.Flags().IntP("project", "p", 0, "Project ID")
+	addChangelogCmd.Flags().StringP("group", "g", "", "Group ID or path, for a group milestone spanning its projects")
+	addChangelogCmd.Flags().Bool("dry-run", false, "Print the updated description and a diff without saving it")
+	addRenderFlags(addChangelogCmd)
 	// Make one of them required
 	addChangelogCmd.MarkFlagsMutuallyExclusive("merge-request", "milestone")
-}
 
-func stringToISOTime(date string) *gitlab.ISOTime {
-	if date == "" {
-		return nil
-	}
-	// Parse the date string first
-	parsed, err := time.Parse("2006-01-02", date)
-	if err != nil {
-		return nil
-	}
-	// Convert time.Time to ISOTime
-	isoTime := gitlab.ISOTime(parsed)
-	return &isoTime
+	MergeRequestAddChangelogCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	MergeRequestAddChangelogCmd.Flags().IntP("project", "p", 0, "Project ID")
+	MergeRequestAddChangelogCmd.Flags().Bool("dry-run", false, "Print the updated description and a diff without saving it")
+	addRenderFlags(MergeRequestAddChangelogCmd)
+	MergeRequestAddChangelogCmd.MarkFlagRequired("mr")
+
+	// Changelog show flags
+	changelogShowCmd.Flags().IntP("milestone", "m", 0, "Milestone ID")
+	changelogShowCmd.Flags().StringP("format", "f", formatMarkdown, "Output format (markdown/json/html)")
+	addRenderFlags(changelogShowCmd)
+	changelogShowCmd.MarkFlagRequired("milestone")
+
+	// Rotate flags
+	rotateCmd.Flags().StringP("config", "c", "", "Cadence config file (default "+DefaultRotationConfigPath+", locally or in the repository)")
+	rotateCmd.Flags().StringP("closed-title", "t", "", "Title for the finished sprint, e.g. a version (overrides the config format)")
+	rotateCmd.Flags().Int("length-days", 0, "Sprint length in days (overrides the config)")
+	rotateCmd.Flags().Bool("dry-run", false, "Only show what would change")
+	rotateCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+
+	// Report flags
+	reportCmd.Flags().IntP("milestone", "m", 0, "Milestone ID")
+	reportCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	reportCmd.Flags().Bool("chart", false, "Draw the burndown as an ASCII chart")
+	reportCmd.Flags().Int("height", DefaultChartHeight, "Rows of the burndown chart")
+	reportCmd.MarkFlagRequired("milestone")
+	reportCmd.MarkFlagsMutuallyExclusive("json", "chart")
 }
 
 func runList(cmd *cobra.Command, args []string) {
-	projectID, _ := utils.GetProjectID(cmd)
-	if projectID == 0 {
-		log.Fatal("Project ID is required. Provide --project flag or run in GitLab CI")
-	}
+	scope := scopeFromFlags(cmd)
+	state, _ := cmd.Flags().GetString("state")
 
-	opts := &gitlab.ListMilestonesOptions{}
-	if state, _ := cmd.Flags().GetString("state"); state != "" {
-		opts.State = gitlab.String(state)
-	}
-
-	milestones, _, err := client.Milestones.ListMilestones(projectID, opts)
+	milestones, err := scope.listMilestones(state, "")
 	if err != nil {
 		log.Fatalf("Failed to list milestones: %v", err)
 	}
@@ -139,10 +235,10 @@ func runList(cmd *cobra.Command, args []string) {
 }
 
 func runGet(cmd *cobra.Command, args []string) {
-	projectID, _ := utils.GetProjectID(cmd)
+	scope := scopeFromFlags(cmd)
 	milestoneID, _ := cmd.Flags().GetInt("milestone")
 
-	milestone, _, err := client.Milestones.GetMilestone(projectID, milestoneID)
+	milestone, err := scope.getMilestone(milestoneID)
 	if err != nil {
 		log.Fatalf("Failed to get milestone: %v", err)
 	}
@@ -150,6 +246,9 @@ func runGet(cmd *cobra.Command, args []string) {
 	fmt.Printf("Milestone #%d\n", milestone.ID)
 	fmt.Printf("Title: %s\n", milestone.Title)
 	fmt.Printf("State: %s\n", milestone.State)
+	if milestone.GroupID != 0 {
+		fmt.Printf("Group: %d\n", milestone.GroupID)
+	}
 	if milestone.DueDate != nil {
 		fmt.Printf("Due Date: %s\n", milestone.DueDate.String())
 	}
@@ -159,27 +258,30 @@ func runGet(cmd *cobra.Command, args []string) {
 }
 
 func runCreate(cmd *cobra.Command, args []string) {
-	projectID, _ := utils.GetProjectID(cmd)
-	if projectID == 0 {
-		log.Fatal("Project ID is required. Provide --project flag or run in GitLab CI")
-	}
+	scope := scopeFromFlags(cmd)
 
 	title, _ := cmd.Flags().GetString("title")
 	description, _ := cmd.Flags().GetString("description")
+	startDate, _ := cmd.Flags().GetString("start-date")
 	dueDate, _ := cmd.Flags().GetString("due-date")
+	allowOverlap, _ := cmd.Flags().GetBool("allow-overlap")
+
+	dates, err := parseDateChange(scope, startDate, dueDate, nil, allowOverlap, time.Now())
+	if err != nil {
+		log.Fatalf("Invalid milestone dates: %v", err)
+	}
 
 	opts := &gitlab.CreateMilestoneOptions{
-		Title: gitlab.String(title),
+		Title:     gitlab.String(title),
+		StartDate: dates.Start,
+		DueDate:   dates.Due,
 	}
 
 	if description != "" {
 		opts.Description = gitlab.String(description)
 	}
-	if dueDate != "" {
-		opts.DueDate = stringToISOTime(dueDate)
-	}
 
-	milestone, _, err := client.Milestones.CreateMilestone(projectID, opts)
+	milestone, err := scope.createMilestone(opts)
 	if err != nil {
 		log.Fatalf("Failed to create milestone: %v", err)
 	}
@@ -188,7 +290,7 @@ func runCreate(cmd *cobra.Command, args []string) {
 }
 
 func runUpdate(cmd *cobra.Command, args []string) {
-	projectID, _ := utils.GetProjectID(cmd)
+	scope := scopeFromFlags(cmd)
 	milestoneID, _ := cmd.Flags().GetInt("milestone")
 
 	opts := &gitlab.UpdateMilestoneOptions{}
@@ -199,14 +301,26 @@ func runUpdate(cmd *cobra.Command, args []string) {
 	if description, _ := cmd.Flags().GetString("description"); description != "" {
 		opts.Description = gitlab.String(description)
 	}
-	if dueDate, _ := cmd.Flags().GetString("due-date"); dueDate != "" {
-		opts.DueDate = stringToISOTime(dueDate)
+	startDate, _ := cmd.Flags().GetString("start-date")
+	dueDate, _ := cmd.Flags().GetString("due-date")
+	if startDate != "" || dueDate != "" {
+		// The date that isn't changed still counts for the range checks
+		current, err := scope.getMilestone(milestoneID)
+		if err != nil {
+			log.Fatalf("Failed to update milestone: %v", err)
+		}
+		allowOverlap, _ := cmd.Flags().GetBool("allow-overlap")
+		dates, err := parseDateChange(scope, startDate, dueDate, current, allowOverlap, time.Now())
+		if err != nil {
+			log.Fatalf("Invalid milestone dates: %v", err)
+		}
+		opts.StartDate, opts.DueDate = dates.Start, dates.Due
 	}
 	if state, _ := cmd.Flags().GetString("state"); state != "" {
 		opts.StateEvent = gitlab.String(state)
 	}
 
-	milestone, _, err := client.Milestones.UpdateMilestone(projectID, milestoneID, opts)
+	milestone, err := scope.updateMilestone(milestoneID, opts)
 	if err != nil {
 		log.Fatalf("Failed to update milestone: %v", err)
 	}
@@ -215,11 +329,10 @@ func runUpdate(cmd *cobra.Command, args []string) {
 }
 
 func runDelete(cmd *cobra.Command, args []string) {
-	projectID, _ := utils.GetProjectID(cmd)
+	scope := scopeFromFlags(cmd)
 	milestoneID, _ := cmd.Flags().GetInt("milestone")
 
-	_, err := client.Milestones.DeleteMilestone(projectID, milestoneID)
-	if err != nil {
+	if err := scope.deleteMilestone(milestoneID); err != nil {
 		log.Fatalf("Failed to delete milestone: %v", err)
 	}
 
@@ -228,19 +341,362 @@ func runDelete(cmd *cobra.Command, args []string) {
 
 func runAddChangelog(cmd *cobra.Command, args []string) {
 	projectID, _ := utils.GetProjectID(cmd)
+	group, _ := cmd.Flags().GetString("group")
+
+	opts := renderOptionsFromFlags(cmd)
 
 	// Check which flag was provided
+	var update *ChangelogUpdate
+	var err error
 	if mrIID, _ := cmd.Flags().GetInt("merge-request"); mrIID != 0 {
-		if err := AddChangelogFromMR(projectID, mrIID); err != nil {
-			log.Fatalf("Failed to add changelog: %v", err)
-		}
+		update, err = PlanChangelogFromMR(projectID, mrIID, opts)
 	} else if milestoneID, _ := cmd.Flags().GetInt("milestone"); milestoneID != 0 {
-		if err := AddChangelogFromMilestone(projectID, milestoneID); err != nil {
-			log.Fatalf("Failed to add changelog: %v", err)
-		}
+		update, err = PlanChangelogFromScope(Scope{ProjectID: projectID, Group: group}, milestoneID, opts)
 	} else {
 		log.Fatal("Either --merge-request or --milestone flag is required")
 	}
+	if err != nil {
+		log.Fatalf("Failed to add changelog: %v", err)
+	}
+	writeChangelogUpdate(cmd, update)
+}
+
+func runMergeRequestAddChangelog(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+
+	update, err := PlanChangelogFromMR(projectID, mrIID, renderOptionsFromFlags(cmd))
+	if err != nil {
+		log.Fatalf("Failed to add changelog: %v", err)
+	}
+	writeChangelogUpdate(cmd, update)
+}
+
+// writeChangelogUpdate saves a changelog update, or prints it with --dry-run
+func writeChangelogUpdate(cmd *cobra.Command, update *ChangelogUpdate) {
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		fmt.Print(utils.DescriptionPreview(update.Description, update.Diff()))
+		return
+	}
+	if err := update.Apply(); err != nil {
+		log.Fatalf("Failed to add changelog: %v", err)
+	}
 
 	fmt.Println("Successfully updated milestone changelog")
 }
+
+// addRenderFlags adds the flags of the changelog RenderOptions to a command
+func addRenderFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("attribution", false, "Append the author and reviewer handles to each entry")
+	cmd.Flags().Bool("links", false, "Link the merge request and issue of each entry")
+	cmd.Flags().Bool("contributors", false, "Add a Contributors section listing the milestone's authors")
+	cmd.Flags().Bool("hide-bots", false, "Leave bot accounts out of the handles and contributors")
+	cmd.Flags().StringSlice("bots", nil, "Comma-separated usernames to treat as bots with --hide-bots")
+}
+
+// renderOptionsFromFlags reads the flags added by addRenderFlags
+func renderOptionsFromFlags(cmd *cobra.Command) RenderOptions {
+	var opts RenderOptions
+	opts.Attribution, _ = cmd.Flags().GetBool("attribution")
+	opts.Links, _ = cmd.Flags().GetBool("links")
+	opts.Contributors, _ = cmd.Flags().GetBool("contributors")
+	opts.HideBots, _ = cmd.Flags().GetBool("hide-bots")
+	opts.Bots, _ = cmd.Flags().GetStringSlice("bots")
+	return opts
+}
+
+func runChangelogShow(cmd *cobra.Command, args []string) {
+	scope := scopeFromFlags(cmd)
+	milestoneID, _ := cmd.Flags().GetInt("milestone")
+	format, _ := cmd.Flags().GetString("format")
+	opts := renderOptionsFromFlags(cmd)
+
+	if err := ValidateChangelogFormat(format); err != nil {
+		log.Fatalf("Failed to show changelog: %v", err)
+	}
+	changelog, err := MilestoneChangelog(scope, milestoneID)
+	if err != nil {
+		log.Fatalf("Failed to compute changelog: %v", err)
+	}
+	output, err := RenderChangelog(changelog, format, opts)
+	if err != nil {
+		log.Fatalf("Failed to render changelog: %v", err)
+	}
+	fmt.Print(output)
+}
+
+func runRotate(cmd *cobra.Command, args []string) {
+	scope := scopeFromFlags(cmd)
+
+	configPath, _ := cmd.Flags().GetString("config")
+	config, err := LoadRotationConfig(scope.ProjectID, configPath)
+	if err != nil {
+		log.Fatalf("Failed to load milestone config: %v", err)
+	}
+	if title, _ := cmd.Flags().GetString("closed-title"); title != "" {
+		config.ClosedTitle = title
+	}
+	if days, _ := cmd.Flags().GetInt("length-days"); days != 0 {
+		config.LengthDays = days
+	}
+	if err := config.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	plan, err := RotateMilestone(scope, config, time.Now(), dryRun)
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput && plan != nil {
+		output, merr := json.MarshalIndent(plan, "", "  ")
+		if merr != nil {
+			log.Fatalf("Failed to marshal rotation: %v", merr)
+		}
+		fmt.Println(string(output))
+	} else if plan != nil && err != nil && len(plan.Completed) > 0 {
+		fmt.Println("Rotation stopped after:")
+		for _, step := range plan.Completed {
+			fmt.Printf("- %s\n", step)
+		}
+		fmt.Println("Finish the remaining steps by hand; rerunning rotate would rotate the new milestone.")
+	} else if plan != nil && err == nil {
+		verb := "Rotated"
+		if dryRun {
+			verb = "Would rotate"
+		}
+		fmt.Printf("%s milestone #%d: %q -> %q (closed)\n", verb, plan.CurrentID, plan.NextTitle, plan.ClosedTitle)
+		fmt.Printf("Next %q: %s to %s\n", plan.NextTitle, plan.NextStart.Format(dateFormat), plan.NextDue.Format(dateFormat))
+		fmt.Printf("Open issues moved forward: %s\n", formatRefs("#", plan.Issues, scope.IsGroup()))
+		fmt.Printf("Open merge requests moved forward: %s\n", formatRefs("!", plan.MergeRequests, scope.IsGroup()))
+	}
+
+	if err != nil {
+		log.Fatalf("Failed to rotate milestone: %v", err)
+	}
+}
+
+func runReport(cmd *cobra.Command, args []string) {
+	scope := scopeFromFlags(cmd)
+	milestoneID, _ := cmd.Flags().GetInt("milestone")
+
+	report, err := MilestoneReport(scope, milestoneID, time.Now())
+	if err != nil {
+		log.Fatalf("Failed to build milestone report: %v", err)
+	}
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal report: %v", err)
+		}
+		fmt.Println(string(output))
+		return
+	}
+
+	if chart, _ := cmd.Flags().GetBool("chart"); chart {
+		height, _ := cmd.Flags().GetInt("height")
+		if height < 1 {
+			log.Fatal("--height must be at least 1")
+		}
+		fmt.Printf("Burndown of %s (open issues, . = ideal)\n", report.Milestone.Title)
+		fmt.Print(RenderBurndownChart(report.Burndown, height))
+		return
+	}
+
+	PrintReport(os.Stdout, report)
+}
+
+func runClose(cmd *cobra.Command, args []string) {
+	scope := scopeFromFlags(cmd)
+	milestoneID, _ := cmd.Flags().GetInt("milestone")
+
+	var opts CloseOptions
+	opts.Check, _ = cmd.Flags().GetBool("check")
+	opts.ReportOnly, _ = cmd.Flags().GetBool("report")
+	opts.MoveTo, _ = cmd.Flags().GetInt("move-to")
+
+	result, err := CloseMilestone(scope, milestoneID, opts)
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput && result != nil {
+		output, merr := json.MarshalIndent(result, "", "  ")
+		if merr != nil {
+			log.Fatalf("Failed to marshal close result: %v", merr)
+		}
+		fmt.Println(string(output))
+	} else if result != nil {
+		printCloseResult(result, opts.ReportOnly, scope.IsGroup())
+	}
+
+	if err != nil {
+		log.Fatalf("Failed to close milestone: %v", err)
+	}
+	if opts.ReportOnly && len(failedCloseChecks(result.Checks)) > 0 {
+		os.Exit(1)
+	}
+}
+
+// printCloseResult prints the moved leftovers, one line per close check and the outcome
+func printCloseResult(result *CloseResult, reportOnly, group bool) {
+	if result.MovedTo != 0 {
+		verb := "Moved"
+		if reportOnly {
+			verb = "Would move"
+		}
+		fmt.Printf("%s to milestone #%d: issues %s; merge requests %s\n", verb, result.MovedTo,
+			formatRefs("#", result.Issues, group), formatRefs("!", result.MergeRequests, group))
+	}
+	for _, c := range result.Checks {
+		status := "PASS"
+		if !c.Passed {
+			status = "FAIL"
+		}
+		fmt.Printf("[%s] %s: %s\n", status, c.Name, c.Message)
+	}
+	if result.Closed {
+		fmt.Printf("Closed milestone #%d: %s\n", result.MilestoneID, result.Title)
+	}
+}
+
+func runMove(cmd *cobra.Command, args []string) {
+	scope := scopeFromFlags(cmd)
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+
+	var opts MoveOptions
+	opts.Types, _ = cmd.Flags().GetString("type")
+	opts.Concurrency, _ = cmd.Flags().GetInt("concurrency")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	if state, _ := cmd.Flags().GetString("state"); state != "all" {
+		opts.Filter.State = state
+	}
+	if labels, _ := cmd.Flags().GetString("labels"); labels != "" {
+		opts.Filter.Labels = utils.SplitList(labels)
+	}
+	assignee, _ := cmd.Flags().GetString("assignee")
+	var err error
+	if opts.Filter.Assignee, err = ParseAssigneeFilter(assignee); err != nil {
+		log.Fatalf("Invalid --assignee: %v", err)
+	}
+
+	summary, err := MoveMilestoneItems(scope, from, to, opts)
+	if err != nil {
+		log.Fatalf("Failed to move milestone items: %v", err)
+	}
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal move results: %v", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		printMoveSummary(summary, scope.IsGroup())
+	}
+
+	if summary.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// printMoveSummary prints one line per item and a closing count
+func printMoveSummary(summary *MoveSummary, group bool) {
+	for _, r := range summary.Results {
+		ref := moveResultRef(r)
+		if group {
+			ref += fmt.Sprintf(" (project %d)", r.ProjectID)
+		}
+		fmt.Printf("%-8s %-11s %s\n", ref, r.Status, r.Title)
+		if r.Error != "" {
+			fmt.Printf("         error: %s\n", r.Error)
+		}
+	}
+
+	moved := len(summary.Results) - summary.Failed
+	verb := "Moved"
+	for _, r := range summary.Results {
+		if r.Status == moveStatusWouldMove {
+			verb = "Would move"
+			break
+		}
+	}
+	fmt.Printf("%s %d of %d item(s) from %q to %q", verb, moved, len(summary.Results), summary.From, summary.To)
+	if summary.Failed > 0 {
+		fmt.Printf(", %d failed", summary.Failed)
+	}
+	fmt.Println()
+}
+
+func runAudit(cmd *cobra.Command, args []string) {
+	scope := scopeFromFlags(cmd)
+
+	var opts AuditOptions
+	opts.Milestone, _ = cmd.Flags().GetString("milestone")
+	opts.Fix, _ = cmd.Flags().GetBool("fix")
+	now := time.Now()
+	if after, _ := cmd.Flags().GetString("merged-after"); after != "" {
+		parsed, err := ParseDate(after, now)
+		if err != nil {
+			log.Fatalf("Invalid --merged-after: %v", err)
+		}
+		opts.MergedAfter = parsed
+	}
+	if before, _ := cmd.Flags().GetString("merged-before"); before != "" {
+		parsed, err := ParseDate(before, now)
+		if err != nil {
+			log.Fatalf("Invalid --merged-before: %v", err)
+		}
+		opts.MergedBefore = parsed
+	}
+
+	if opts.Milestone == "" && opts.MergedAfter.IsZero() {
+		log.Fatal("Either --milestone or --merged-after flag is required")
+	}
+
+	report, err := AuditMilestones(scope, opts)
+	if err != nil {
+		log.Fatalf("Failed to audit milestones: %v", err)
+	}
+
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal audit report: %v", err)
+	}
+	if path, _ := cmd.Flags().GetString("output"); path != "" {
+		if err := os.WriteFile(path, append(output, '\n'), 0o644); err != nil {
+			log.Fatalf("Failed to write audit report: %v", err)
+		}
+	}
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		fmt.Println(string(output))
+	} else {
+		printAuditReport(report)
+	}
+
+	// Unresolved findings fail the job, so a scheduled pipeline surfaces them
+	if report.Unresolved > 0 {
+		os.Exit(1)
+	}
+}
+
+// printAuditReport prints one line per finding and a closing count
+func printAuditReport(report *AuditReport) {
+	for _, f := range report.Findings {
+		status := "FAIL"
+		switch {
+		case f.Fixed:
+			status = "FIXED"
+		case f.FixError != "":
+			f.Message += fmt.Sprintf(" (fix failed: %s)", f.FixError)
+		}
+		fmt.Printf("[%s] %s: %s\n", status, f.Kind, f.Message)
+	}
+
+	scanned := fmt.Sprintf("merged from %s", report.MergedAfter)
+	if report.Milestone != "" {
+		scanned = fmt.Sprintf("in %q", report.Milestone)
+	} else if report.MergedBefore != "" {
+		scanned += " before " + report.MergedBefore
+	}
+	fmt.Printf("Audited %d merge request(s) %s: %d finding(s), %d unresolved\n",
+		report.MergeRequests, scanned, len(report.Findings), report.Unresolved)
+}
@@ -0,0 +1,203 @@
+package milestones
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// DefaultMoveConcurrency is how many issues and merge requests are updated at once
+const DefaultMoveConcurrency = 4
+
+// Item types that can be moved between milestones
+const (
+	moveAll           = "all"
+	moveIssues        = "issues"
+	moveMergeRequests = "merge-requests"
+)
+
+// Move statuses of a single item
+const (
+	moveStatusMoved     = "moved"
+	moveStatusWouldMove = "would move"
+	moveStatusFailed    = "failed"
+)
+
+// MoveOptions configures a bulk milestone reassignment
+type MoveOptions struct {
+	Filter      ItemFilter
+	Types       string // all, issues or merge-requests
+	Concurrency int    // Updates running at once
+	DryRun      bool   // Only list the matching items
+}
+
+// MoveResult is the outcome of moving one issue or merge request
+type MoveResult struct {
+	Type      string `json:"type"` // issue or merge_request
+	ProjectID int    `json:"project_id"`
+	IID       int    `json:"iid"`
+	Title     string `json:"title"`
+	Status    string `json:"status"` // moved, would move or failed
+	Error     string `json:"error,omitempty"`
+}
+
+// MoveSummary describes a bulk milestone reassignment
+type MoveSummary struct {
+	FromID  int          `json:"from_id"`
+	From    string       `json:"from"`
+	ToID    int          `json:"to_id"` // 0 when the milestone is removed
+	To      string       `json:"to"`
+	Results []MoveResult `json:"results"`
+	Failed  int          `json:"failed"`
+}
+
+// ValidateMoveTypes verifies the item types to move
+func ValidateMoveTypes(types string) error {
+	switch types {
+	case moveAll, moveIssues, moveMergeRequests:
+		return nil
+	}
+	return fmt.Errorf("invalid type %q, expected %s, %s or %s", types, moveAll, moveIssues, moveMergeRequests)
+}
+
+// ParseAssigneeFilter turns an assignee username, or none/any, into a list filter
+func ParseAssigneeFilter(assignee string) (*gitlab.AssigneeIDValue, error) {
+	switch strings.ToLower(assignee) {
+	case "":
+		return nil, nil
+	case "none":
+		return gitlab.AssigneeID(gitlab.UserIDNone), nil
+	case "any":
+		return gitlab.AssigneeID(gitlab.UserIDAny), nil
+	}
+	id, err := utils.ResolveUserID(assignee)
+	if err != nil {
+		return nil, err
+	}
+	return gitlab.AssigneeID(id), nil
+}
+
+// MoveMilestoneItems reassigns the matching issues and merge requests of one milestone
+// to another, or removes their milestone when to is "none". Milestones are given by
+// ID or title. Every item is attempted; failures are reported per item and counted
+// in the summary instead of stopping the move.
+func MoveMilestoneItems(scope Scope, from, to string, opts MoveOptions) (*MoveSummary, error) {
+	if err := ValidateMoveTypes(opts.Types); err != nil {
+		return nil, err
+	}
+
+	source, err := findMilestone(scope, from)
+	if err != nil {
+		return nil, err
+	}
+	summary := &MoveSummary{FromID: source.ID, From: source.Title, To: "none"}
+	if !strings.EqualFold(to, "none") {
+		target, err := findMilestone(scope, to)
+		if err != nil {
+			return nil, err
+		}
+		if target.ID == source.ID {
+			return nil, fmt.Errorf("source and target are the same milestone %q", source.Title)
+		}
+		summary.ToID, summary.To = target.ID, target.Title
+	}
+
+	// Only merge requests can be merged, so a merged filter leaves issues out
+	if opts.Types != moveMergeRequests && opts.Filter.State != "merged" {
+		issues, err := scope.filterIssues(source, opts.Filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			summary.Results = append(summary.Results, MoveResult{Type: "issue", ProjectID: issue.ProjectID, IID: issue.IID, Title: issue.Title})
+		}
+	}
+	if opts.Types != moveIssues {
+		mrs, err := scope.filterMergeRequests(source, opts.Filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, mr := range mrs {
+			summary.Results = append(summary.Results, MoveResult{Type: "merge_request", ProjectID: mr.ProjectID, IID: mr.IID, Title: mr.Title})
+		}
+	}
+
+	if opts.DryRun {
+		for i := range summary.Results {
+			summary.Results[i].Status = moveStatusWouldMove
+		}
+		return summary, nil
+	}
+
+	// Each call only writes its own result, so no locking is needed
+	utils.ForEachConcurrently(len(summary.Results), opts.Concurrency, func(i int) {
+		r := &summary.Results[i]
+		if err := moveItem(r, summary.ToID); err != nil {
+			r.Status, r.Error = moveStatusFailed, err.Error()
+			return
+		}
+		r.Status = moveStatusMoved
+	})
+
+	for _, r := range summary.Results {
+		if r.Status == moveStatusFailed {
+			summary.Failed++
+		}
+	}
+	return summary, nil
+}
+
+// moveItem assigns an issue or merge request to a milestone, 0 removing its milestone
+func moveItem(r *MoveResult, milestoneID int) error {
+	if r.Type == "issue" {
+		_, _, err := client.Issues.UpdateIssue(r.ProjectID, r.IID, &gitlab.UpdateIssueOptions{MilestoneID: gitlab.Int(milestoneID)})
+		return err
+	}
+	_, _, err := client.MergeRequests.UpdateMergeRequest(r.ProjectID, r.IID, &gitlab.UpdateMergeRequestOptions{MilestoneID: gitlab.Int(milestoneID)})
+	return err
+}
+
+// findMilestone finds a milestone of the scope by ID or by title, compared case-insensitively
+func findMilestone(scope Scope, ref string) (*gitlab.Milestone, error) {
+	ref = strings.TrimSpace(ref)
+	// A numeric reference may also be the title of a milestone, like "2026"
+	if id, err := strconv.Atoi(ref); err == nil {
+		m, resp, err := scope.fetchMilestone(id)
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return m, err
+		}
+	}
+
+	milestones, err := scope.listMilestones("", ref)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*gitlab.Milestone
+	for _, m := range milestones {
+		if strings.EqualFold(m.Title, ref) {
+			matches = append(matches, m)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no milestone named %q found in %s", ref, scope)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%d milestones are named %q in %s, use the milestone ID", len(matches), ref, scope)
+	}
+}
+
+// moveResultRef renders the reference of a moved item, like "#12" or "!7"
+func moveResultRef(r MoveResult) string {
+	if r.Type == "merge_request" {
+		return fmt.Sprintf("!%d", r.IID)
+	}
+	return fmt.Sprintf("#%d", r.IID)
+}
@@ -0,0 +1,45 @@
+package milestones
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestValidateMoveTypes(t *testing.T) {
+	for _, types := range []string{"all", "issues", "merge-requests"} {
+		if err := ValidateMoveTypes(types); err != nil {
+			t.Errorf("ValidateMoveTypes(%q) = %v", types, err)
+		}
+	}
+	if err := ValidateMoveTypes("epics"); err == nil {
+		t.Error("ValidateMoveTypes(epics) succeeded, want an error")
+	}
+}
+
+func TestParseAssigneeFilter(t *testing.T) {
+	tests := []struct {
+		assignee string
+		want     *gitlab.AssigneeIDValue
+	}{
+		{assignee: "", want: nil},
+		{assignee: "none", want: gitlab.AssigneeID(gitlab.UserIDNone)},
+		{assignee: "Any", want: gitlab.AssigneeID(gitlab.UserIDAny)},
+	}
+	for _, tt := range tests {
+		got, err := ParseAssigneeFilter(tt.assignee)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAssigneeFilter(%q) = %v, %v, want %v", tt.assignee, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoveResultRef(t *testing.T) {
+	if got := moveResultRef(MoveResult{Type: "issue", IID: 12}); got != "#12" {
+		t.Errorf("moveResultRef(issue) = %q", got)
+	}
+	if got := moveResultRef(MoveResult{Type: "merge_request", IID: 7}); got != "!7" {
+		t.Errorf("moveResultRef(merge request) = %q", got)
+	}
+}
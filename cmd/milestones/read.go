@@ -84,5 +84,6 @@ func convertGitLabMilestone(milestone *gitlab.Milestone) *types.Milestone {
 		DueDate:     isoTimeToTime(milestone.DueDate),
 		StartDate:   isoTimeToTime(milestone.StartDate),
 		WebURL:      milestone.WebURL,
+		GroupID:     milestone.GroupID,
 	}
 }
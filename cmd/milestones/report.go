@@ -0,0 +1,350 @@
+package milestones
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"mpg-gitlab/cmd/types"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// DefaultChartHeight is the number of rows of the burndown chart
+const DefaultChartHeight = 10
+
+// Report is the progress of a milestone
+type Report struct {
+	Milestone       *types.Milestone `json:"milestone"`
+	Scope           string           `json:"scope"`
+	Issues          map[string]int   `json:"issues"`         // Issue counts by state
+	MergeRequests   map[string]int   `json:"merge_requests"` // Merge request counts by state
+	Weight          WeightTotals     `json:"weight"`
+	TimeEstimate    int              `json:"time_estimate"`            // Estimated seconds over all issues
+	TimeSpent       int              `json:"time_spent"`               // Spent seconds over all issues
+	PercentComplete float64          `json:"percent_complete"`         // Closed share of the issues
+	CompleteBy      string           `json:"complete_by"`              // What the percentage is based on (weight/issues)
+	DaysRemaining   *int             `json:"days_remaining,omitempty"` // Days until the due date, negative once passed
+	Overdue         []OverdueItem    `json:"overdue"`
+	Burndown        []BurndownDay    `json:"burndown"`
+}
+
+// WeightTotals sums the weight of the issues of a milestone
+type WeightTotals struct {
+	Total  int `json:"total"`
+	Closed int `json:"closed"`
+}
+
+// OverdueItem is an open issue or merge request whose due date has passed
+type OverdueItem struct {
+	Type      string    `json:"type"` // issue or merge_request
+	ProjectID int       `json:"project_id"`
+	IID       int       `json:"iid"`
+	Title     string    `json:"title"`
+	DueDate   time.Time `json:"due_date"`
+	WebURL    string    `json:"web_url"`
+}
+
+// BurndownDay is the remaining work at the end of a day
+type BurndownDay struct {
+	Date       string  `json:"date"`
+	Open       int     `json:"open"`        // Open issues
+	OpenWeight int     `json:"open_weight"` // Weight of the open issues
+	Ideal      float64 `json:"ideal"`       // Open issues on a straight line to zero at the due date
+}
+
+// MilestoneReport fetches a milestone with its issues and merge requests and reports its progress
+func MilestoneReport(scope Scope, milestoneID int, now time.Time) (*Report, error) {
+	milestone, err := scope.getMilestone(milestoneID)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := scope.milestoneIssues(milestone, "")
+	if err != nil {
+		return nil, err
+	}
+	mrs, err := scope.milestoneMergeRequests(milestone, "")
+	if err != nil {
+		return nil, err
+	}
+
+	report := BuildReport(milestone, issues, mrs, now)
+	report.Scope = scope.String()
+	return report, nil
+}
+
+// BuildReport computes the progress of a milestone from its issues and merge requests
+func BuildReport(milestone *gitlab.Milestone, issues []*gitlab.Issue, mrs []*gitlab.MergeRequest, now time.Time) *Report {
+	today := types.TruncateDay(now)
+	report := &Report{
+		Milestone:     convertGitLabMilestone(milestone),
+		Issues:        map[string]int{},
+		MergeRequests: map[string]int{},
+		Overdue:       []OverdueItem{},
+	}
+
+	var due *time.Time
+	if milestone.DueDate != nil {
+		d := types.TruncateDay(time.Time(*milestone.DueDate))
+		due = &d
+		days := int(d.Sub(today).Hours() / 24)
+		report.DaysRemaining = &days
+	}
+	milestoneOverdue := due != nil && due.Before(today)
+
+	closedIssues := 0
+	for _, issue := range issues {
+		report.Issues[issue.State]++
+		report.Weight.Total += issue.Weight
+		if issue.TimeStats != nil {
+			report.TimeEstimate += issue.TimeStats.TimeEstimate
+			report.TimeSpent += issue.TimeStats.TotalTimeSpent
+		}
+		if issue.State == "closed" {
+			closedIssues++
+			report.Weight.Closed += issue.Weight
+			continue
+		}
+
+		// An open issue is overdue past its own due date, or else past the milestone's
+		itemDue := due
+		if issue.DueDate != nil {
+			d := types.TruncateDay(time.Time(*issue.DueDate))
+			itemDue = &d
+		}
+		if itemDue != nil && itemDue.Before(today) {
+			report.Overdue = append(report.Overdue, OverdueItem{
+				Type: "issue", ProjectID: issue.ProjectID, IID: issue.IID,
+				Title: issue.Title, DueDate: *itemDue, WebURL: issue.WebURL,
+			})
+		}
+	}
+
+	for _, mr := range mrs {
+		report.MergeRequests[mr.State]++
+		// Merge requests have no due date of their own
+		if mr.State == "opened" && milestoneOverdue {
+			report.Overdue = append(report.Overdue, OverdueItem{
+				Type: "merge_request", ProjectID: mr.ProjectID, IID: mr.IID,
+				Title: mr.Title, DueDate: *due, WebURL: mr.WebURL,
+			})
+		}
+	}
+
+	// Without weights every issue counts the same
+	switch {
+	case report.Weight.Total > 0:
+		report.CompleteBy = "weight"
+		report.PercentComplete = percent(report.Weight.Closed, report.Weight.Total)
+	default:
+		report.CompleteBy = "issues"
+		report.PercentComplete = percent(closedIssues, len(issues))
+	}
+
+	report.Burndown = burndown(milestone, issues, today)
+	return report
+}
+
+// burndown counts the open issues at the end of each day from the milestone start up
+// to today or the due date, whichever comes first
+// Issues count from the day they were created and stop counting the day they were closed.
+func burndown(milestone *gitlab.Milestone, issues []*gitlab.Issue, today time.Time) []BurndownDay {
+	var start time.Time
+	switch {
+	case milestone.StartDate != nil:
+		start = types.TruncateDay(time.Time(*milestone.StartDate))
+	case milestone.CreatedAt != nil:
+		start = types.TruncateDay(*milestone.CreatedAt)
+	default:
+		return []BurndownDay{}
+	}
+
+	end := today
+	var due time.Time
+	if milestone.DueDate != nil {
+		due = types.TruncateDay(time.Time(*milestone.DueDate))
+		if due.Before(end) {
+			end = due
+		}
+	}
+
+	days := []BurndownDay{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		endOfDay := day.AddDate(0, 0, 1)
+		point := BurndownDay{Date: day.Format(dateFormat)}
+		for _, issue := range issues {
+			if issue.CreatedAt != nil && !issue.CreatedAt.Before(endOfDay) {
+				continue
+			}
+			if issue.ClosedAt != nil && issue.ClosedAt.Before(endOfDay) {
+				continue
+			}
+			point.Open++
+			point.OpenWeight += issue.Weight
+		}
+		days = append(days, point)
+	}
+
+	// The ideal line runs from the open issues of the first day down to zero on the due date
+	if len(days) > 0 && !due.IsZero() && due.After(start) {
+		total := due.Sub(start).Hours() / 24
+		for i := range days {
+			left := total - float64(i)
+			days[i].Ideal = math.Round(float64(days[0].Open)*left/total*10) / 10
+		}
+	}
+
+	return days
+}
+
+// percent returns part of total as a percentage with one decimal
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
+
+// PrintReport writes a report as a table
+func PrintReport(w io.Writer, r *Report) {
+	m := r.Milestone
+	fmt.Fprintf(w, "Milestone #%d: %s [%s] (%s)\n", m.ID, m.Title, m.State, r.Scope)
+	if m.StartDate != nil || m.DueDate != nil {
+		fmt.Fprintf(w, "Dates: %s to %s\n", formatOptionalDate(m.StartDate), formatOptionalDate(m.DueDate))
+	}
+	switch {
+	case r.DaysRemaining == nil:
+		fmt.Fprintln(w, "Days remaining: no due date")
+	case *r.DaysRemaining < 0:
+		fmt.Fprintf(w, "Days remaining: %d (due date passed)\n", *r.DaysRemaining)
+	default:
+		fmt.Fprintf(w, "Days remaining: %d\n", *r.DaysRemaining)
+	}
+	fmt.Fprintf(w, "Complete: %.1f%% (by %s)\n", r.PercentComplete, r.CompleteBy)
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "%-16s %s\n", "Issues:", formatCounts(r.Issues, "opened", "closed"))
+	fmt.Fprintf(w, "%-16s %s\n", "Merge requests:", formatCounts(r.MergeRequests, "opened", "merged", "closed"))
+	fmt.Fprintf(w, "%-16s %d of %d closed\n", "Weight:", r.Weight.Closed, r.Weight.Total)
+	fmt.Fprintf(w, "%-16s %s spent of %s estimated\n", "Time tracking:", formatTrackedTime(r.TimeSpent), formatTrackedTime(r.TimeEstimate))
+
+	fmt.Fprintln(w)
+	if len(r.Overdue) == 0 {
+		fmt.Fprintln(w, "Overdue: none")
+	} else {
+		fmt.Fprintf(w, "Overdue (%d):\n", len(r.Overdue))
+		for _, item := range r.Overdue {
+			prefix := "#"
+			if item.Type == "merge_request" {
+				prefix = "!"
+			}
+			fmt.Fprintf(w, "  %s%d (due %s) %s\n", prefix, item.IID, item.DueDate.Format(dateFormat), item.Title)
+		}
+	}
+
+	if len(r.Burndown) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "%-10s  %5s  %6s  %5s\n", "Date", "Open", "Weight", "Ideal")
+		for _, d := range r.Burndown {
+			fmt.Fprintf(w, "%-10s  %5d  %6d  %5.1f\n", d.Date, d.Open, d.OpenWeight, d.Ideal)
+		}
+	}
+}
+
+// RenderBurndownChart draws the open issues per day as bars, with the ideal line as dots
+func RenderBurndownChart(days []BurndownDay, height int) string {
+	if len(days) == 0 {
+		return "No burndown data: the milestone has no start date or hasn't started yet\n"
+	}
+
+	top := 1
+	for _, d := range days {
+		top = max(top, d.Open, int(math.Ceil(d.Ideal)))
+	}
+	height = min(height, top)
+
+	var b strings.Builder
+	for row := height; row > 0; row-- {
+		// A cell is filled when the value reaches the middle of its row
+		level := float64(top) * (float64(row) - 0.5) / float64(height)
+		switch row {
+		case height:
+			fmt.Fprintf(&b, "%4d |", top)
+		default:
+			b.WriteString("     |")
+		}
+		for _, d := range days {
+			switch {
+			case float64(d.Open) >= level:
+				b.WriteByte('#')
+			// The ideal line gets a dot in the one row whose middle it reaches
+			case d.Ideal >= level && d.Ideal < level+float64(top)/float64(height):
+				b.WriteByte('.')
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "%4d +%s\n", 0, strings.Repeat("-", len(days)))
+
+	first, last := days[0].Date, days[len(days)-1].Date
+	axis := "      " + first
+	if len(days) > 1 {
+		axis += strings.Repeat(" ", max(1, len(days)-len(first)-len(last))) + last
+	}
+	b.WriteString(axis + "\n")
+	return b.String()
+}
+
+// formatCounts renders counts by state, listing the given states first and others after them
+func formatCounts(counts map[string]int, states ...string) string {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+
+	parts := []string{}
+	seen := map[string]bool{}
+	for _, s := range states {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+		seen[s] = true
+	}
+	var others []string
+	for s := range counts {
+		if !seen[s] {
+			others = append(others, s)
+		}
+	}
+	sort.Strings(others)
+	for _, s := range others {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+	}
+
+	return fmt.Sprintf("%d (%s)", total, strings.Join(parts, ", "))
+}
+
+// formatTrackedTime renders tracked seconds in hours and minutes, like "12h30m"
+func formatTrackedTime(seconds int) string {
+	d := (time.Duration(seconds) * time.Second).Round(time.Minute)
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+}
+
+// formatOptionalDate renders a date, or "-" when it isn't set
+func formatOptionalDate(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(dateFormat)
+}
@@ -0,0 +1,169 @@
+package milestones
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func reportFixture() (*gitlab.Milestone, []*gitlab.Issue, []*gitlab.MergeRequest) {
+	created := date("2026-10-01")
+	milestone := &gitlab.Milestone{
+		ID: 4, Title: "Sprint 42", State: "active",
+		StartDate: isoDate("2026-10-12"), DueDate: isoDate("2026-10-16"),
+		CreatedAt: &created, UpdatedAt: &created,
+	}
+
+	closedAt := func(s string) *time.Time {
+		t := date(s).Add(15 * time.Hour)
+		return &t
+	}
+	late := date("2026-10-13").Add(10 * time.Hour)
+	issues := []*gitlab.Issue{
+		{IID: 1, State: "closed", Weight: 3, CreatedAt: &created, ClosedAt: closedAt("2026-10-13"),
+			TimeStats: &gitlab.TimeStats{TimeEstimate: 7200, TotalTimeSpent: 5400}},
+		{IID: 2, State: "closed", Weight: 2, CreatedAt: &created, ClosedAt: closedAt("2026-10-15")},
+		{IID: 3, State: "opened", Weight: 5, CreatedAt: &created, DueDate: isoDate("2026-10-14"),
+			TimeStats: &gitlab.TimeStats{TimeEstimate: 3600}},
+		{IID: 4, State: "opened", CreatedAt: &late},
+	}
+	mrs := []*gitlab.MergeRequest{
+		{IID: 10, State: "merged"},
+		{IID: 11, State: "opened"},
+	}
+	return milestone, issues, mrs
+}
+
+func TestBuildReport(t *testing.T) {
+	milestone, issues, mrs := reportFixture()
+	r := BuildReport(milestone, issues, mrs, date("2026-10-15").Add(9*time.Hour))
+
+	if !reflect.DeepEqual(r.Issues, map[string]int{"opened": 2, "closed": 2}) {
+		t.Errorf("Issues = %v", r.Issues)
+	}
+	if !reflect.DeepEqual(r.MergeRequests, map[string]int{"opened": 1, "merged": 1}) {
+		t.Errorf("MergeRequests = %v", r.MergeRequests)
+	}
+	if r.Weight != (WeightTotals{Total: 10, Closed: 5}) {
+		t.Errorf("Weight = %+v", r.Weight)
+	}
+	if r.TimeEstimate != 10800 || r.TimeSpent != 5400 {
+		t.Errorf("time tracking = %d estimated, %d spent", r.TimeEstimate, r.TimeSpent)
+	}
+	if r.PercentComplete != 50 || r.CompleteBy != "weight" {
+		t.Errorf("complete = %v by %s, want 50 by weight", r.PercentComplete, r.CompleteBy)
+	}
+	if r.DaysRemaining == nil || *r.DaysRemaining != 1 {
+		t.Errorf("DaysRemaining = %v, want 1", r.DaysRemaining)
+	}
+	if len(r.Overdue) != 1 || r.Overdue[0].IID != 3 || r.Overdue[0].Type != "issue" {
+		t.Errorf("Overdue = %+v, want issue #3", r.Overdue)
+	}
+
+	var open []int
+	for _, d := range r.Burndown {
+		open = append(open, d.Open)
+	}
+	// #4 joins on the 13th, the day #1 is closed; #2 is closed on the 15th
+	if want := []int{3, 3, 3, 2}; !reflect.DeepEqual(open, want) {
+		t.Errorf("burndown open = %v, want %v", open, want)
+	}
+	if r.Burndown[0].Date != "2026-10-12" || r.Burndown[0].OpenWeight != 10 || r.Burndown[3].OpenWeight != 5 {
+		t.Errorf("burndown = %+v", r.Burndown)
+	}
+	if r.Burndown[0].Ideal != 3 || r.Burndown[2].Ideal != 1.5 {
+		t.Errorf("ideal line = %v, %v, want 3, 1.5", r.Burndown[0].Ideal, r.Burndown[2].Ideal)
+	}
+}
+
+func TestBuildReportPastDue(t *testing.T) {
+	milestone, issues, mrs := reportFixture()
+	r := BuildReport(milestone, issues, mrs, date("2026-10-20"))
+
+	if r.DaysRemaining == nil || *r.DaysRemaining != -4 {
+		t.Errorf("DaysRemaining = %v, want -4", r.DaysRemaining)
+	}
+	// Open items without a due date of their own are overdue with the milestone
+	var refs []string
+	for _, item := range r.Overdue {
+		refs = append(refs, item.Type+":"+item.DueDate.Format(dateFormat))
+	}
+	want := []string{"issue:2026-10-14", "issue:2026-10-16", "merge_request:2026-10-16"}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("Overdue = %v, want %v", refs, want)
+	}
+	// The burndown stops at the due date
+	if n := len(r.Burndown); n != 5 || r.Burndown[n-1].Ideal != 0 {
+		t.Errorf("burndown = %+v, want 5 days ending at zero", r.Burndown)
+	}
+}
+
+func TestBuildReportWithoutWeights(t *testing.T) {
+	milestone, issues, _ := reportFixture()
+	for _, issue := range issues {
+		issue.Weight = 0
+	}
+	milestone.DueDate = nil
+	r := BuildReport(milestone, issues, nil, date("2026-10-15"))
+
+	if r.PercentComplete != 50 || r.CompleteBy != "issues" {
+		t.Errorf("complete = %v by %s, want 50 by issues", r.PercentComplete, r.CompleteBy)
+	}
+	if r.DaysRemaining != nil {
+		t.Errorf("DaysRemaining = %d, want none without a due date", *r.DaysRemaining)
+	}
+	if r.Burndown[0].Ideal != 0 {
+		t.Errorf("ideal line without a due date = %v", r.Burndown[0].Ideal)
+	}
+}
+
+func TestRenderBurndownChart(t *testing.T) {
+	days := []BurndownDay{
+		{Date: "2026-10-12", Open: 4, Ideal: 4},
+		{Date: "2026-10-13", Open: 1, Ideal: 2},
+		{Date: "2026-10-14", Open: 1, Ideal: 0},
+	}
+	// The ideal line only shows where it is above the bars
+	want := strings.Join([]string{
+		"   4 |#  ",
+		"     |#  ",
+		"     |#. ",
+		"     |###",
+		"   0 +---",
+		"      2026-10-12 2026-10-14",
+		"",
+	}, "\n")
+	if got := RenderBurndownChart(days, 10); got != want {
+		t.Errorf("RenderBurndownChart() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := RenderBurndownChart(nil, 10); !strings.HasPrefix(got, "No burndown data") {
+		t.Errorf("RenderBurndownChart(nil) = %q", got)
+	}
+}
+
+func TestPrintReport(t *testing.T) {
+	milestone, issues, mrs := reportFixture()
+	r := BuildReport(milestone, issues, mrs, date("2026-10-15"))
+	r.Scope = "project 1"
+
+	var b bytes.Buffer
+	PrintReport(&b, r)
+	for _, want := range []string{
+		"Milestone #4: Sprint 42 [active] (project 1)",
+		"Days remaining: 1",
+		"Complete: 50.0% (by weight)",
+		"Issues:          4 (2 opened, 2 closed)",
+		"Merge requests:  2 (1 opened, 1 merged, 0 closed)",
+		"Time tracking:   1h30m spent of 3h estimated",
+		"#3 (due 2026-10-14)",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, b.String())
+		}
+	}
+}
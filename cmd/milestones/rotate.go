@@ -0,0 +1,245 @@
+package milestones
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"mpg-gitlab/cmd/types"
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	// DefaultRotationConfigPath is where the sprint cadence is looked up, locally or in the project repository
+	DefaultRotationConfigPath = ".gitlab/milestones.json"
+
+	// dateFormat is how milestone dates are written in titles and flags
+	dateFormat = "2006-01-02"
+)
+
+// RotationConfig describes the sprint cadence used by milestones rotate
+type RotationConfig struct {
+	// Current is the title of the milestone that holds the running sprint
+	Current string `json:"current,omitempty"`
+	// LengthDays is the length of a sprint in days
+	LengthDays int `json:"length_days,omitempty"`
+	// ClosedTitle is the title given to a finished sprint; {start}, {due},
+	// {year} and {week} are replaced with the sprint's dates
+	ClosedTitle string `json:"closed_title,omitempty"`
+}
+
+// DefaultRotationConfig is used when a project has no cadence config
+func DefaultRotationConfig() *RotationConfig {
+	return &RotationConfig{Current: "Current", LengthDays: 14, ClosedTitle: "Sprint {due}"}
+}
+
+// ParseRotationConfig parses a JSON cadence config, filling in defaults
+func ParseRotationConfig(data []byte) (*RotationConfig, error) {
+	config := DefaultRotationConfig()
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid milestone config: %v", err)
+	}
+	return config, config.Validate()
+}
+
+// Validate checks that the cadence config can produce distinct milestones
+func (c *RotationConfig) Validate() error {
+	if strings.TrimSpace(c.Current) == "" {
+		return fmt.Errorf("milestone config: current title is empty")
+	}
+	if c.LengthDays < 1 {
+		return fmt.Errorf("milestone config: length_days must be at least 1, got %d", c.LengthDays)
+	}
+	if strings.EqualFold(strings.TrimSpace(c.ClosedTitle), c.Current) {
+		return fmt.Errorf("milestone config: closed_title must differ from the current title")
+	}
+	return nil
+}
+
+// LoadRotationConfig reads the cadence config from a local file or the project repository
+// An empty path looks for DefaultRotationConfigPath locally, then in the repository,
+// and falls back to DefaultRotationConfig when neither exists. Without a project
+// (group milestones) only the local file is used.
+func LoadRotationConfig(projectID int, configPath string) (*RotationConfig, error) {
+	if configPath != "" {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read milestone config: %v", err)
+		}
+		return ParseRotationConfig(data)
+	}
+
+	if projectID == 0 {
+		configPath = DefaultRotationConfigPath
+		if _, err := os.Stat(configPath); err != nil {
+			return DefaultRotationConfig(), nil
+		}
+		return LoadRotationConfig(projectID, configPath)
+	}
+
+	data, found, err := utils.ReadConfigFile(projectID, DefaultRotationConfigPath, "")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return DefaultRotationConfig(), nil
+	}
+	return ParseRotationConfig(data)
+}
+
+// RotationPlan describes what milestones rotate does, or would do in a dry run
+type RotationPlan struct {
+	Scope         string    `json:"scope"`
+	CurrentID     int       `json:"current_id"`
+	ClosedTitle   string    `json:"closed_title"` // New title of the finished sprint
+	NextTitle     string    `json:"next_title"`
+	NextID        int       `json:"next_id,omitempty"` // Set once the next milestone exists
+	NextStart     time.Time `json:"next_start"`
+	NextDue       time.Time `json:"next_due"`
+	Issues        []ItemRef `json:"issues"`              // Open issues moved forward
+	MergeRequests []ItemRef `json:"merge_requests"`      // Open merge requests moved forward
+	Completed     []string  `json:"completed,omitempty"` // Steps done so far, to finish a failed rotation by hand
+}
+
+// PlanRotation works out how the current sprint milestone is rotated on a given day
+func PlanRotation(scope Scope, config *RotationConfig, today time.Time) (*RotationPlan, *gitlab.Milestone, error) {
+	current, err := findActiveMilestone(scope, config.Current)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	start, due := nextSprintDates(current, config.LengthDays, today)
+	plan := &RotationPlan{
+		Scope:       scope.String(),
+		CurrentID:   current.ID,
+		ClosedTitle: closedTitle(config.ClosedTitle, current, today),
+		NextTitle:   current.Title,
+		NextStart:   start,
+		NextDue:     due,
+	}
+
+	issues, err := scope.milestoneIssues(current, "opened")
+	if err != nil {
+		return nil, nil, err
+	}
+	mrs, err := scope.milestoneMergeRequests(current, "opened")
+	if err != nil {
+		return nil, nil, err
+	}
+	plan.Issues, plan.MergeRequests = issueRefs(issues), mergeRequestRefs(mrs)
+	return plan, current, nil
+}
+
+// RotateMilestone closes the current sprint milestone and opens the next one
+// The finished milestone is retitled, a milestone with the current title is created
+// for the next sprint, and open issues and merge requests are moved to it before
+// the old milestone is closed. Rotating a group milestone moves items in every project of the group.
+// When the next milestone can't be created the old title is restored, so the rotation
+// can simply be rerun. Later failures leave both milestones in place, and the plan lists
+// the steps completed so the rest can be finished by hand.
+func RotateMilestone(scope Scope, config *RotationConfig, today time.Time, dryRun bool) (*RotationPlan, error) {
+	plan, current, err := PlanRotation(scope, config, today)
+	if err != nil || dryRun {
+		return plan, err
+	}
+	done := func(format string, args ...interface{}) {
+		plan.Completed = append(plan.Completed, fmt.Sprintf(format, args...))
+	}
+
+	// Free the current title before creating the next milestone under it
+	_, err = scope.updateMilestone(current.ID, &gitlab.UpdateMilestoneOptions{
+		Title: gitlab.String(plan.ClosedTitle),
+	})
+	if err != nil {
+		return plan, fmt.Errorf("failed to retitle milestone %q: %v", current.Title, err)
+	}
+	done("retitled milestone #%d to %q", current.ID, plan.ClosedTitle)
+
+	start, due := gitlab.ISOTime(plan.NextStart), gitlab.ISOTime(plan.NextDue)
+	next, err := scope.createMilestone(&gitlab.CreateMilestoneOptions{
+		Title:     gitlab.String(plan.NextTitle),
+		StartDate: &start,
+		DueDate:   &due,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to create milestone %q: %v", plan.NextTitle, err)
+		_, rerr := scope.updateMilestone(current.ID, &gitlab.UpdateMilestoneOptions{
+			Title: gitlab.String(current.Title),
+		})
+		if rerr != nil {
+			return plan, fmt.Errorf("%v; restoring the title of milestone #%d also failed: %v", err, current.ID, rerr)
+		}
+		plan.Completed = nil
+		return plan, err
+	}
+	plan.NextID = next.ID
+	done("created milestone #%d %q", next.ID, plan.NextTitle)
+
+	if err := moveItems(plan.Issues, plan.MergeRequests, next.ID); err != nil {
+		return plan, err
+	}
+	done("moved %d issue(s) and %d merge request(s) to milestone #%d", len(plan.Issues), len(plan.MergeRequests), next.ID)
+
+	_, err = scope.updateMilestone(current.ID, &gitlab.UpdateMilestoneOptions{
+		StateEvent: gitlab.String("close"),
+	})
+	if err != nil {
+		return plan, fmt.Errorf("failed to close milestone %q: %v", plan.ClosedTitle, err)
+	}
+	done("closed milestone #%d", current.ID)
+	return plan, nil
+}
+
+// findActiveMilestone returns the active milestone with a title, compared case-insensitively
+func findActiveMilestone(scope Scope, title string) (*gitlab.Milestone, error) {
+	milestones, err := scope.listMilestones("active", title)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range milestones {
+		if strings.EqualFold(m.Title, title) {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("no active milestone named %q found in %s", title, scope)
+}
+
+// nextSprintDates returns the start and due date of the sprint after the current one
+// The next sprint starts the day after the current one is due, skipping sprints
+// that already passed entirely, or today when the current milestone has no due date.
+func nextSprintDates(current *gitlab.Milestone, lengthDays int, today time.Time) (time.Time, time.Time) {
+	today = types.TruncateDay(today)
+	start := today
+	if current.DueDate != nil {
+		start = types.TruncateDay(time.Time(*current.DueDate)).AddDate(0, 0, 1)
+		for !start.AddDate(0, 0, lengthDays).After(today) {
+			start = start.AddDate(0, 0, lengthDays)
+		}
+	}
+	return start, start.AddDate(0, 0, lengthDays-1)
+}
+
+// closedTitle fills the date placeholders of the closed title format
+func closedTitle(format string, m *gitlab.Milestone, today time.Time) string {
+	due := types.TruncateDay(today)
+	if m.DueDate != nil {
+		due = time.Time(*m.DueDate)
+	}
+	start := ""
+	if m.StartDate != nil {
+		start = time.Time(*m.StartDate).Format(dateFormat)
+	}
+	year, week := due.ISOWeek()
+
+	return strings.NewReplacer(
+		"{start}", start,
+		"{due}", due.Format(dateFormat),
+		"{year}", fmt.Sprint(year),
+		"{week}", fmt.Sprintf("%02d", week),
+	).Replace(format)
+}
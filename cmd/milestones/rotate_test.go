@@ -0,0 +1,88 @@
+package milestones
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(dateFormat, s)
+	return t
+}
+
+func isoDate(s string) *gitlab.ISOTime {
+	t := gitlab.ISOTime(date(s))
+	return &t
+}
+
+func TestNextSprintDates(t *testing.T) {
+	tests := []struct {
+		name      string
+		due       *gitlab.ISOTime
+		today     string
+		wantStart string
+		wantDue   string
+	}{
+		{"rotated on the due date", isoDate("2026-10-16"), "2026-10-16", "2026-10-17", "2026-10-30"},
+		{"rotated a few days late keeps the cadence", isoDate("2026-10-16"), "2026-10-20", "2026-10-17", "2026-10-30"},
+		{"sprints that passed entirely are skipped", isoDate("2026-10-16"), "2026-11-05", "2026-10-31", "2026-11-13"},
+		{"no due date starts today", nil, "2026-10-19", "2026-10-19", "2026-11-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, due := nextSprintDates(&gitlab.Milestone{DueDate: tt.due}, 14, date(tt.today).Add(9*time.Hour))
+			if got := start.Format(dateFormat); got != tt.wantStart {
+				t.Errorf("start = %s, want %s", got, tt.wantStart)
+			}
+			if got := due.Format(dateFormat); got != tt.wantDue {
+				t.Errorf("due = %s, want %s", got, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestClosedTitle(t *testing.T) {
+	m := &gitlab.Milestone{StartDate: isoDate("2026-10-05"), DueDate: isoDate("2026-10-16")}
+
+	tests := map[string]string{
+		"Sprint {due}":    "Sprint 2026-10-16",
+		"{start} – {due}": "2026-10-05 – 2026-10-16",
+		"{year}.{week}":   "2026.42",
+		"Release 2026.10": "Release 2026.10",
+	}
+	for format, want := range tests {
+		if got := closedTitle(format, m, date("2026-10-19")); got != want {
+			t.Errorf("closedTitle(%q) = %q, want %q", format, got, want)
+		}
+	}
+
+	// Without a due date the rotation day is used
+	if got := closedTitle("Sprint {due}", &gitlab.Milestone{}, date("2026-10-19")); got != "Sprint 2026-10-19" {
+		t.Errorf("closedTitle() without due date = %q", got)
+	}
+}
+
+func TestParseRotationConfig(t *testing.T) {
+	config, err := ParseRotationConfig([]byte(`{"length_days": 7}`))
+	if err != nil {
+		t.Fatalf("ParseRotationConfig() error = %v", err)
+	}
+	if config.Current != "Current" || config.LengthDays != 7 || config.ClosedTitle != "Sprint {due}" {
+		t.Errorf("ParseRotationConfig() = %+v", config)
+	}
+
+	invalid := []string{
+		`{"length_days": 0}`,
+		`{"current": ""}`,
+		`{"current": "Sprint", "closed_title": "sprint"}`,
+		`{"length_days": "two weeks"}`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseRotationConfig([]byte(data)); err == nil {
+			t.Errorf("ParseRotationConfig(%s) accepted an invalid config", data)
+		}
+	}
+}
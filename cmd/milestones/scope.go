@@ -0,0 +1,423 @@
+package milestones
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"mpg-gitlab/cmd/types"
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// Scope is the project or group whose milestones a command works on
+type Scope struct {
+	ProjectID int
+	Group     string // Group ID or full path; when set, group milestones are used
+}
+
+// String describes the scope, like "project 12" or "group platform"
+func (s Scope) String() string {
+	if s.IsGroup() {
+		return "group " + s.Group
+	}
+	return fmt.Sprintf("project %d", s.ProjectID)
+}
+
+// IsGroup reports whether the scope holds group milestones
+func (s Scope) IsGroup() bool {
+	return s.Group != ""
+}
+
+// ItemRef points at an issue or merge request, which in a group can live in any project
+type ItemRef struct {
+	ProjectID int `json:"project_id"`
+	IID       int `json:"iid"`
+}
+
+// addScopeFlags registers the flags that select the milestone scope
+func addScopeFlags(cmd *cobra.Command) {
+	cmd.Flags().IntP("project", "p", 0, "Project ID")
+	cmd.Flags().StringP("group", "g", "", "Group ID or path, to work on group milestones")
+	cmd.MarkFlagsMutuallyExclusive("project", "group")
+}
+
+// scopeFromFlags returns the scope selected by --group, --project or the CI project
+func scopeFromFlags(cmd *cobra.Command) Scope {
+	if group, _ := cmd.Flags().GetString("group"); group != "" {
+		return Scope{Group: group}
+	}
+
+	projectID, _ := utils.GetProjectID(cmd)
+	if projectID == 0 {
+		log.Fatal("Project ID is required. Provide --project or --group flag, or run in GitLab CI")
+	}
+	return Scope{ProjectID: projectID}
+}
+
+// milestoneScope returns the scope a milestone belongs to
+func milestoneScope(projectID int, m *gitlab.Milestone) Scope {
+	if m.GroupID != 0 {
+		return Scope{Group: fmt.Sprint(m.GroupID)}
+	}
+	return Scope{ProjectID: projectID}
+}
+
+// getMilestone returns a milestone of the scope by ID
+func (s Scope) getMilestone(milestoneID int) (*gitlab.Milestone, error) {
+	m, _, err := s.fetchMilestone(milestoneID)
+	return m, err
+}
+
+// fetchMilestone is getMilestone with the API response, so callers can tell a missing milestone apart
+func (s Scope) fetchMilestone(milestoneID int) (*gitlab.Milestone, *gitlab.Response, error) {
+	if s.IsGroup() {
+		m, resp, err := client.GroupMilestones.GetGroupMilestone(s.Group, milestoneID)
+		if err != nil {
+			return nil, resp, fmt.Errorf("failed to get group milestone: %v", err)
+		}
+		return types.FromGroupMilestone(m), resp, nil
+	}
+
+	m, resp, err := client.Milestones.GetMilestone(s.ProjectID, milestoneID)
+	if err != nil {
+		return nil, resp, fmt.Errorf("failed to get milestone: %v", err)
+	}
+	return m, resp, nil
+}
+
+// listMilestones returns the milestones of the scope, optionally by state and title search
+func (s Scope) listMilestones(state, search string) ([]*gitlab.Milestone, error) {
+	var milestones []*gitlab.Milestone
+	listOpts := gitlab.ListOptions{PerPage: 100}
+	for {
+		var resp *gitlab.Response
+		if s.IsGroup() {
+			opts := &gitlab.ListGroupMilestonesOptions{ListOptions: listOpts}
+			if state != "" {
+				opts.State = gitlab.String(state)
+			}
+			if search != "" {
+				opts.Search = gitlab.String(search)
+			}
+			page, r, err := client.GroupMilestones.ListGroupMilestones(s.Group, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list group milestones: %v", err)
+			}
+			for _, m := range page {
+				milestones = append(milestones, types.FromGroupMilestone(m))
+			}
+			resp = r
+		} else {
+			opts := &gitlab.ListMilestonesOptions{ListOptions: listOpts}
+			if state != "" {
+				opts.State = gitlab.String(state)
+			}
+			if search != "" {
+				opts.Search = gitlab.String(search)
+			}
+			page, r, err := client.Milestones.ListMilestones(s.ProjectID, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list milestones: %v", err)
+			}
+			milestones = append(milestones, page...)
+			resp = r
+		}
+
+		if resp.NextPage == 0 {
+			return milestones, nil
+		}
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// createMilestone creates a milestone in the scope
+func (s Scope) createMilestone(opts *gitlab.CreateMilestoneOptions) (*gitlab.Milestone, error) {
+	if s.IsGroup() {
+		m, _, err := client.GroupMilestones.CreateGroupMilestone(s.Group, &gitlab.CreateGroupMilestoneOptions{
+			Title:       opts.Title,
+			Description: opts.Description,
+			StartDate:   opts.StartDate,
+			DueDate:     opts.DueDate,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create group milestone: %v", err)
+		}
+		return types.FromGroupMilestone(m), nil
+	}
+
+	m, _, err := client.Milestones.CreateMilestone(s.ProjectID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create milestone: %v", err)
+	}
+	return m, nil
+}
+
+// updateMilestone updates a milestone of the scope
+func (s Scope) updateMilestone(milestoneID int, opts *gitlab.UpdateMilestoneOptions) (*gitlab.Milestone, error) {
+	if s.IsGroup() {
+		m, _, err := client.GroupMilestones.UpdateGroupMilestone(s.Group, milestoneID, &gitlab.UpdateGroupMilestoneOptions{
+			Title:       opts.Title,
+			Description: opts.Description,
+			StartDate:   opts.StartDate,
+			DueDate:     opts.DueDate,
+			StateEvent:  opts.StateEvent,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update group milestone: %v", err)
+		}
+		return types.FromGroupMilestone(m), nil
+	}
+
+	m, _, err := client.Milestones.UpdateMilestone(s.ProjectID, milestoneID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update milestone: %v", err)
+	}
+	return m, nil
+}
+
+// deleteMilestone deletes a milestone of the scope
+func (s Scope) deleteMilestone(milestoneID int) error {
+	if !s.IsGroup() {
+		if _, err := client.Milestones.DeleteMilestone(s.ProjectID, milestoneID); err != nil {
+			return fmt.Errorf("failed to delete milestone: %v", err)
+		}
+		return nil
+	}
+
+	// The client has no call for deleting group milestones, so the request is built by hand
+	u := fmt.Sprintf("groups/%s/milestones/%d", gitlab.PathEscape(s.Group), milestoneID)
+	req, err := client.NewRequest(http.MethodDelete, u, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete group milestone: %v", err)
+	}
+	if _, err := client.Do(req, nil); err != nil {
+		return fmt.Errorf("failed to delete group milestone: %v", err)
+	}
+	return nil
+}
+
+// ItemFilter narrows down the issues and merge requests of a milestone
+type ItemFilter struct {
+	State    string                  // "" for any state
+	Labels   []string                // Items must carry all of these labels
+	Assignee *gitlab.AssigneeIDValue // Assigned user, or UserIDNone/UserIDAny
+}
+
+// milestoneIssues returns the issues of a milestone with a state ("" for all)
+// For group milestones the issues of every project in the group are included.
+func (s Scope) milestoneIssues(milestone *gitlab.Milestone, state string) ([]*gitlab.Issue, error) {
+	return s.filterIssues(milestone, ItemFilter{State: state})
+}
+
+// filterIssues returns the issues of a milestone that match a filter
+// GitLab filters by title, which in a group also matches project milestones of the same
+// title, so only the items of the milestone itself are kept.
+func (s Scope) filterIssues(milestone *gitlab.Milestone, filter ItemFilter) ([]*gitlab.Issue, error) {
+	var labels *gitlab.Labels
+	if len(filter.Labels) > 0 {
+		labels = (*gitlab.Labels)(&filter.Labels)
+	}
+
+	var issues []*gitlab.Issue
+	listOpts := gitlab.ListOptions{PerPage: 100}
+	for {
+		var page []*gitlab.Issue
+		var resp *gitlab.Response
+		var err error
+		if s.IsGroup() {
+			opts := &gitlab.ListGroupIssuesOptions{
+				Milestone:   gitlab.String(milestone.Title),
+				Labels:      labels,
+				AssigneeID:  filter.Assignee,
+				ListOptions: listOpts,
+			}
+			if filter.State != "" {
+				opts.State = gitlab.String(filter.State)
+			}
+			page, resp, err = client.Issues.ListGroupIssues(s.Group, opts)
+		} else {
+			opts := &gitlab.ListProjectIssuesOptions{
+				Milestone:   gitlab.String(milestone.Title),
+				Labels:      labels,
+				AssigneeID:  filter.Assignee,
+				ListOptions: listOpts,
+			}
+			if filter.State != "" {
+				opts.State = gitlab.String(filter.State)
+			}
+			page, resp, err = client.Issues.ListProjectIssues(s.ProjectID, opts)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list issues: %v", err)
+		}
+		for _, issue := range page {
+			if sameMilestone(issue.Milestone, milestone) {
+				issues = append(issues, issue)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return issues, nil
+		}
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// milestoneMergeRequests returns the merge requests of a milestone with a state ("" for all)
+// For group milestones the merge requests of every project in the group are included.
+func (s Scope) milestoneMergeRequests(milestone *gitlab.Milestone, state string) ([]*gitlab.MergeRequest, error) {
+	return s.filterMergeRequests(milestone, ItemFilter{State: state})
+}
+
+// filterMergeRequests returns the merge requests of a milestone that match a filter
+// Like filterIssues, it keeps only the items of the milestone itself.
+func (s Scope) filterMergeRequests(milestone *gitlab.Milestone, filter ItemFilter) ([]*gitlab.MergeRequest, error) {
+	var labels *gitlab.Labels
+	if len(filter.Labels) > 0 {
+		labels = (*gitlab.Labels)(&filter.Labels)
+	}
+
+	var mrs []*gitlab.MergeRequest
+	listOpts := gitlab.ListOptions{PerPage: 100}
+	for {
+		var page []*gitlab.MergeRequest
+		var resp *gitlab.Response
+		var err error
+		if s.IsGroup() {
+			opts := &gitlab.ListGroupMergeRequestsOptions{
+				Milestone:   gitlab.String(milestone.Title),
+				Labels:      labels,
+				AssigneeID:  filter.Assignee,
+				ListOptions: listOpts,
+			}
+			if filter.State != "" {
+				opts.State = gitlab.String(filter.State)
+			}
+			page, resp, err = client.MergeRequests.ListGroupMergeRequests(s.Group, opts)
+		} else {
+			opts := &gitlab.ListProjectMergeRequestsOptions{
+				Milestone:   gitlab.String(milestone.Title),
+				Labels:      labels,
+				AssigneeID:  filter.Assignee,
+				ListOptions: listOpts,
+			}
+			if filter.State != "" {
+				opts.State = gitlab.String(filter.State)
+			}
+			page, resp, err = client.MergeRequests.ListProjectMergeRequests(s.ProjectID, opts)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list merge requests: %v", err)
+		}
+		for _, mr := range page {
+			if sameMilestone(mr.Milestone, milestone) {
+				mrs = append(mrs, mr)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return mrs, nil
+		}
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// sameMilestone reports whether an item's milestone is the given milestone
+func sameMilestone(m, milestone *gitlab.Milestone) bool {
+	return m != nil && m.ID == milestone.ID
+}
+
+// mergedMergeRequests returns the merge requests of the scope merged from after up to before
+// GitLab can't filter on the merge time, so merge requests updated since after are
+// listed and narrowed down here; a zero before leaves the range open.
+func (s Scope) mergedMergeRequests(after, before time.Time) ([]*gitlab.MergeRequest, error) {
+	var mrs []*gitlab.MergeRequest
+	listOpts := gitlab.ListOptions{PerPage: 100}
+	for {
+		var page []*gitlab.MergeRequest
+		var resp *gitlab.Response
+		var err error
+		if s.IsGroup() {
+			page, resp, err = client.MergeRequests.ListGroupMergeRequests(s.Group, &gitlab.ListGroupMergeRequestsOptions{
+				State:        gitlab.String("merged"),
+				UpdatedAfter: &after,
+				ListOptions:  listOpts,
+			})
+		} else {
+			page, resp, err = client.MergeRequests.ListProjectMergeRequests(s.ProjectID, &gitlab.ListProjectMergeRequestsOptions{
+				State:        gitlab.String("merged"),
+				UpdatedAfter: &after,
+				ListOptions:  listOpts,
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list merge requests: %v", err)
+		}
+		for _, mr := range page {
+			if mr.MergedAt == nil || mr.MergedAt.Before(after) || (!before.IsZero() && !mr.MergedAt.Before(before)) {
+				continue
+			}
+			mrs = append(mrs, mr)
+		}
+
+		if resp.NextPage == 0 {
+			return mrs, nil
+		}
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// moveItems assigns issues and merge requests to another milestone
+func moveItems(issues, mrs []ItemRef, milestoneID int) error {
+	for _, ref := range issues {
+		_, _, err := client.Issues.UpdateIssue(ref.ProjectID, ref.IID, &gitlab.UpdateIssueOptions{MilestoneID: gitlab.Int(milestoneID)})
+		if err != nil {
+			return fmt.Errorf("failed to move issue #%d: %v", ref.IID, err)
+		}
+	}
+	for _, ref := range mrs {
+		_, _, err := client.MergeRequests.UpdateMergeRequest(ref.ProjectID, ref.IID, &gitlab.UpdateMergeRequestOptions{MilestoneID: gitlab.Int(milestoneID)})
+		if err != nil {
+			return fmt.Errorf("failed to move merge request !%d: %v", ref.IID, err)
+		}
+	}
+	return nil
+}
+
+// issueRefs returns references to issues
+func issueRefs(issues []*gitlab.Issue) []ItemRef {
+	refs := []ItemRef{}
+	for _, issue := range issues {
+		refs = append(refs, ItemRef{ProjectID: issue.ProjectID, IID: issue.IID})
+	}
+	return refs
+}
+
+// mergeRequestRefs returns references to merge requests
+func mergeRequestRefs(mrs []*gitlab.MergeRequest) []ItemRef {
+	refs := []ItemRef{}
+	for _, mr := range mrs {
+		refs = append(refs, ItemRef{ProjectID: mr.ProjectID, IID: mr.IID})
+	}
+	return refs
+}
+
+// formatRefs renders references like "#1, #2", naming the project of each in group scope
+func formatRefs(prefix string, refs []ItemRef, group bool) string {
+	if len(refs) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(refs))
+	for i, r := range refs {
+		parts[i] = fmt.Sprintf("%s%d", prefix, r.IID)
+		if group {
+			parts[i] += fmt.Sprintf(" (project %d)", r.ProjectID)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
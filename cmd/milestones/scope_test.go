@@ -0,0 +1,45 @@
+package milestones
+
+import (
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestMilestoneScope(t *testing.T) {
+	project := milestoneScope(12, &gitlab.Milestone{ID: 1, ProjectID: 12})
+	if project.IsGroup() || project.ProjectID != 12 || project.String() != "project 12" {
+		t.Errorf("milestoneScope() of a project milestone = %+v", project)
+	}
+
+	group := milestoneScope(12, &gitlab.Milestone{ID: 2, GroupID: 7})
+	if !group.IsGroup() || group.Group != "7" || group.String() != "group 7" {
+		t.Errorf("milestoneScope() of a group milestone = %+v", group)
+	}
+}
+
+func TestSameMilestone(t *testing.T) {
+	group := &gitlab.Milestone{ID: 5, GroupID: 7, Title: "Sprint 42"}
+	if !sameMilestone(&gitlab.Milestone{ID: 5, Title: "Sprint 42"}, group) {
+		t.Error("sameMilestone() rejected the milestone itself")
+	}
+	if sameMilestone(&gitlab.Milestone{ID: 9, ProjectID: 3, Title: "Sprint 42"}, group) {
+		t.Error("sameMilestone() accepted a project milestone of the same title")
+	}
+	if sameMilestone(nil, group) {
+		t.Error("sameMilestone() accepted an item without milestone")
+	}
+}
+
+func TestFormatRefs(t *testing.T) {
+	refs := []ItemRef{{ProjectID: 3, IID: 10}, {ProjectID: 4, IID: 11}}
+	if got := formatRefs("#", refs, false); got != "#10, #11" {
+		t.Errorf("formatRefs() = %q", got)
+	}
+	if got := formatRefs("!", refs, true); got != "!10 (project 3), !11 (project 4)" {
+		t.Errorf("formatRefs() in a group = %q", got)
+	}
+	if got := formatRefs("#", nil, false); got != "none" {
+		t.Errorf("formatRefs() without refs = %q", got)
+	}
+}
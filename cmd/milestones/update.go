@@ -4,121 +4,185 @@ import (
 	"fmt"
 	"strings"
 
-	"mpg-gitlab/cmd/mergerequests"
+	"mpg-gitlab/cmd/utils"
 
 	"github.com/xanzy/go-gitlab"
 )
 
+// ChangelogUpdate is a milestone description with changelog entries added, not yet saved
+type ChangelogUpdate struct {
+	Scope       Scope
+	Milestone   *gitlab.Milestone
+	Description string // The description with the entries added
+}
+
+// Changed reports whether the update changes the milestone description
+func (u *ChangelogUpdate) Changed() bool {
+	return u.Description != u.Milestone.Description
+}
+
+// Diff returns a unified diff from the current to the updated description
+func (u *ChangelogUpdate) Diff() string {
+	name := fmt.Sprintf("milestone #%d", u.Milestone.ID)
+	return utils.UnifiedDiff(name+" (current)", name+" (updated)", u.Milestone.Description, u.Description)
+}
+
+// Apply saves the updated description, unless it is unchanged
+func (u *ChangelogUpdate) Apply() error {
+	if !u.Changed() {
+		return nil
+	}
+	_, err := u.Scope.updateMilestone(u.Milestone.ID, &gitlab.UpdateMilestoneOptions{
+		Description: gitlab.String(u.Description),
+	})
+	return err
+}
+
 // AddChangelogFromMR adds changelog entry from a single merge request to its milestone
 func AddChangelogFromMR(projectID, mrIID int) error {
+	update, err := PlanChangelogFromMR(projectID, mrIID, RenderOptions{})
+	if err != nil {
+		return err
+	}
+	return update.Apply()
+}
+
+// PlanChangelogFromMR works out the milestone description with the changelog entry of a merge request
+// The changelog section is rendered again from the merged merge requests of the milestone,
+// with this one added when it isn't merged yet.
+func PlanChangelogFromMR(projectID, mrIID int, opts RenderOptions) (*ChangelogUpdate, error) {
 	// Get the MR first
 	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get merge request: %v", err)
+		return nil, fmt.Errorf("failed to get merge request: %v", err)
 	}
 
 	// Check if MR has milestone
 	if mr.Milestone == nil {
-		return fmt.Errorf("merge request #%d has no milestone assigned", mrIID)
+		return nil, fmt.Errorf("merge request #%d has no milestone assigned", mrIID)
 	}
 
-	milestoneID := mr.Milestone.ID
-
-	// Get milestone
-	milestone, _, err := client.Milestones.GetMilestone(projectID, milestoneID, nil)
+	// Get milestone, which may be a group milestone shared by several projects
+	scope := milestoneScope(projectID, mr.Milestone)
+	milestone, err := scope.getMilestone(mr.Milestone.ID)
 	if err != nil {
-		return fmt.Errorf("failed to get milestone: %v", err)
+		return nil, err
 	}
 
-	// Get changelog entry from MR
-	entry, err := mergerequests.GetChangelogEntries(projectID, mrIID)
+	mrs, err := scope.milestoneMergeRequests(milestone, "merged")
 	if err != nil {
-		return fmt.Errorf("failed to get changelog entry: %v", err)
+		return nil, err
 	}
-	if entry == mergerequests.ChangelogError {
-		return fmt.Errorf("no changelog entry found in MR #%d", mrIID)
+	if !containsMergeRequest(mrs, mr) {
+		mrs = append(mrs, mr)
 	}
 
-	return addEntryToMilestone(projectID, milestone, entry)
+	changelog := buildChangelog(scope, milestone, mrs)
+	if !changelog.has(mr) {
+		return nil, fmt.Errorf("no changelog entry found in MR #%d", mrIID)
+	}
+	return planChangelog(scope, milestone, changelog, opts)
 }
 
 // AddChangelogFromMilestone adds changelog entries from all merge requests in a milestone
 func AddChangelogFromMilestone(projectID, milestoneID int) error {
-	// Get milestone
-	milestone, _, err := client.Milestones.GetMilestone(projectID, milestoneID, nil)
+	return AddChangelogFromScope(Scope{ProjectID: projectID}, milestoneID)
+}
+
+// AddChangelogFromScope adds changelog entries from all merged merge requests in a
+// project or group milestone. For a group milestone the entries of every project
+// in the group are collected.
+func AddChangelogFromScope(scope Scope, milestoneID int) error {
+	update, err := PlanChangelogFromScope(scope, milestoneID, RenderOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get milestone: %v", err)
+		return err
 	}
+	return update.Apply()
+}
 
-	// Get all MRs for this milestone
-	mrs, _, err := client.MergeRequests.ListProjectMergeRequests(projectID, &gitlab.ListProjectMergeRequestsOptions{
-		Milestone: gitlab.String(fmt.Sprint(milestoneID)),
-		State:     gitlab.String("merged"),
-	})
+// PlanChangelogFromScope works out the milestone description with the changelog entries
+// of all its merged merge requests
+func PlanChangelogFromScope(scope Scope, milestoneID int, opts RenderOptions) (*ChangelogUpdate, error) {
+	milestone, err := scope.getMilestone(milestoneID)
 	if err != nil {
-		return fmt.Errorf("failed to list merge requests: %v", err)
+		return nil, err
 	}
+	mrs, err := scope.milestoneMergeRequests(milestone, "merged")
+	if err != nil {
+		return nil, err
+	}
+	return planChangelog(scope, milestone, buildChangelog(scope, milestone, mrs), opts)
+}
 
-	// Collect changelog entries
-	var entries []string
-	for _, mr := range mrs {
-		entry, err := mergerequests.GetChangelogEntries(projectID, mr.IID)
-		if err != nil || entry == mergerequests.ChangelogError {
-			continue
-		}
-		entries = append(entries, entry)
+// planChangelog renders a changelog as markdown into the description of its milestone
+func planChangelog(scope Scope, milestone *gitlab.Milestone, changelog *Changelog, opts RenderOptions) (*ChangelogUpdate, error) {
+	section, err := RenderChangelog(changelog, formatMarkdown, opts)
+	if err != nil {
+		return nil, err
 	}
+	return &ChangelogUpdate{Scope: scope, Milestone: milestone, Description: withChangelogSection(milestone.Description, section)}, nil
+}
 
-	// Update milestone description if we have new entries
-	if len(entries) > 0 {
-		description := milestone.Description
-		changelogSection := "\n\n## Changelog\n"
-		for _, entry := range entries {
-			// Check if entry already exists
-			if !strings.Contains(description, entry) {
-				changelogSection += "- " + entry + "\n"
-			}
+// containsMergeRequest reports whether a merge request is in a list
+func containsMergeRequest(mrs []*gitlab.MergeRequest, mr *gitlab.MergeRequest) bool {
+	for _, m := range mrs {
+		if m.ProjectID == mr.ProjectID && m.IID == mr.IID {
+			return true
 		}
+	}
+	return false
+}
 
-		// Only update if we have new entries
-		if !strings.Contains(description, changelogSection) {
-			description += changelogSection
-			_, _, err = client.Milestones.UpdateMilestone(projectID, milestoneID, &gitlab.UpdateMilestoneOptions{
-				Description: gitlab.String(description),
-			})
-			if err != nil {
-				return fmt.Errorf("failed to update milestone: %v", err)
-			}
+// has reports whether a merge request has an entry in the changelog
+func (c *Changelog) has(mr *gitlab.MergeRequest) bool {
+	for _, item := range c.Items {
+		if item.ProjectID == mr.ProjectID && item.MergeRequest == mr.IID {
+			return true
 		}
 	}
-
-	return nil
+	return false
 }
 
-// Helper function to add an entry to milestone description
-func addEntryToMilestone(projectID int, milestone *gitlab.Milestone, entry string) error {
-	description := milestone.Description
-	if description == "" {
-		description = "## Changelog\n"
+// withChangelogSection replaces the changelog section of a description, along with a
+// Contributors section following it, or appends the section when there is none
+// The rest of the description is kept as written.
+func withChangelogSection(description, section string) string {
+	section = strings.TrimRight(section, "\n") + "\n"
+	lines := strings.Split(description, "\n")
+
+	start, end := -1, len(lines)
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "## ") {
+			continue
+		}
+		title := strings.TrimSpace(strings.TrimPrefix(line, "## "))
+		if start < 0 {
+			if strings.EqualFold(title, "Changelog") {
+				start = i
+			}
+			continue
+		}
+		if !strings.EqualFold(title, "Contributors") {
+			end = i
+			break
+		}
 	}
 
-	// Check if entry already exists
-	if !strings.Contains(description, entry) {
-		// Add changelog section if it doesn't exist
-		if !strings.Contains(description, "## Changelog") {
-			description += "\n\n## Changelog\n"
-		}
-		// Add the new entry
-		description += "- " + entry + "\n"
-
-		// Update milestone
-		_, _, err := client.Milestones.UpdateMilestone(projectID, milestone.ID, &gitlab.UpdateMilestoneOptions{
-			Description: gitlab.String(description),
-		})
-		if err != nil {
-			return fmt.Errorf("failed to update milestone: %v", err)
+	if start < 0 {
+		if strings.TrimSpace(description) == "" {
+			return section
 		}
+		return strings.TrimRight(description, "\n") + "\n\n" + section
 	}
 
-	return nil
+	updated := strings.Join(lines[:start], "\n")
+	if start > 0 {
+		updated += "\n"
+	}
+	updated += section
+	if end < len(lines) {
+		updated += "\n" + strings.Join(lines[end:], "\n")
+	}
+	return updated
 }
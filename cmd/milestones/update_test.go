@@ -0,0 +1,70 @@
+package milestones
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestWithChangelogSection(t *testing.T) {
+	section := "## Changelog\n\n### [Fix]\n- [Fix] Crash (!7)\n\n"
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{
+			name: "empty description",
+			want: "## Changelog\n\n### [Fix]\n- [Fix] Crash (!7)\n",
+		},
+		{
+			name:        "appended after the goals",
+			description: "Sprint goals\n",
+			want:        "Sprint goals\n\n## Changelog\n\n### [Fix]\n- [Fix] Crash (!7)\n",
+		},
+		{
+			name:        "replaces the old section and contributors",
+			description: "Sprint goals\n\n## Changelog\n- [Fix] Crash\n\n## Contributors\n\n- @alice\n\n## Notes\nShip on Friday",
+			want:        "Sprint goals\n\n## Changelog\n\n### [Fix]\n- [Fix] Crash (!7)\n\n## Notes\nShip on Friday",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withChangelogSection(tt.description, section); got != tt.want {
+				t.Errorf("withChangelogSection() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangelogHas(t *testing.T) {
+	c := &Changelog{Items: []ChangelogItem{{ProjectID: 3, MergeRequest: 7}}}
+	if !c.has(&gitlab.MergeRequest{ProjectID: 3, IID: 7}) {
+		t.Error("has() missed the entry of !7")
+	}
+	if c.has(&gitlab.MergeRequest{ProjectID: 4, IID: 7}) {
+		t.Error("has() matched !7 of another project")
+	}
+}
+
+func TestChangelogUpdateDiff(t *testing.T) {
+	u := &ChangelogUpdate{
+		Milestone:   &gitlab.Milestone{ID: 3, Description: "## Changelog\n"},
+		Description: "## Changelog\n- [Fix] Crash\n",
+	}
+	if !u.Changed() {
+		t.Error("Changed() = false, want true")
+	}
+	diff := u.Diff()
+	for _, want := range []string{"--- milestone #3 (current)", "+++ milestone #3 (updated)", "+- [Fix] Crash"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Diff() missing %q:\n%s", want, diff)
+		}
+	}
+
+	u.Description = u.Milestone.Description
+	if u.Changed() || u.Diff() != "" {
+		t.Errorf("unchanged update: Changed() = %v, Diff() = %q", u.Changed(), u.Diff())
+	}
+}
@@ -0,0 +1,180 @@
+package notes
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+var (
+	client *gitlab.Client
+	// Command groups
+	NotesCmd = &cobra.Command{
+		Use:   "notes",
+		Short: "Manage notes on GitLab merge requests and issues",
+	}
+
+	listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List notes",
+		Run:   runList,
+	}
+
+	addCmd = &cobra.Command{
+		Use:   "add",
+		Short: "Add a note",
+		Run:   runAdd,
+	}
+
+	editCmd = &cobra.Command{
+		Use:   "edit",
+		Short: "Edit a note",
+		Run:   runEdit,
+	}
+
+	deleteCmd = &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a note",
+		Run:   runDelete,
+	}
+)
+
+func init() {
+	client = utils.GetClient()
+
+	// Add subcommands
+	NotesCmd.AddCommand(listCmd, addCmd, editCmd, deleteCmd)
+
+	// Target flags shared by every subcommand
+	for _, c := range []*cobra.Command{listCmd, addCmd, editCmd, deleteCmd} {
+		c.Flags().IntP("project", "p", 0, "Project ID")
+		c.Flags().IntP("mr", "m", 0, "Merge request IID")
+		c.Flags().IntP("issue", "i", 0, "Issue IID")
+		c.MarkFlagsMutuallyExclusive("mr", "issue")
+	}
+
+	// List flags
+	listCmd.Flags().String("system", systemExclude, "System notes to show (exclude/include/only)")
+	listCmd.Flags().String("order-by", "created_at", "Order by (created_at/updated_at)")
+	listCmd.Flags().String("sort", "asc", "Sort direction (asc/desc)")
+	listCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+
+	// Body flags
+	for _, c := range []*cobra.Command{addCmd, editCmd} {
+		c.Flags().StringP("body", "b", "", "Note content")
+		c.Flags().StringP("body-file", "F", "", "Read the note content from a file (- for stdin)")
+		c.MarkFlagsMutuallyExclusive("body", "body-file")
+	}
+
+	// Note ID flags
+	for _, c := range []*cobra.Command{editCmd, deleteCmd} {
+		c.Flags().IntP("note", "n", 0, "Note ID")
+		c.MarkFlagRequired("note")
+	}
+}
+
+// targetFromFlags returns the project and note target selected by the flags
+func targetFromFlags(cmd *cobra.Command) (int, Target) {
+	projectID, _ := utils.GetProjectID(cmd)
+	if projectID == 0 {
+		log.Fatal("Project ID is required. Provide --project flag or run in GitLab CI")
+	}
+
+	target := Target{}
+	target.MergeRequest, _ = cmd.Flags().GetInt("mr")
+	target.Issue, _ = cmd.Flags().GetInt("issue")
+	if err := target.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	return projectID, target
+}
+
+// bodyFromFlags reads the note body from --body or --body-file
+func bodyFromFlags(cmd *cobra.Command) string {
+	body, _ := cmd.Flags().GetString("body")
+	file, _ := cmd.Flags().GetString("body-file")
+
+	content, err := ReadBody(body, file, os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return content
+}
+
+func runList(cmd *cobra.Command, args []string) {
+	projectID, target := targetFromFlags(cmd)
+
+	opts := &ListOptions{}
+	opts.System, _ = cmd.Flags().GetString("system")
+	opts.OrderBy, _ = cmd.Flags().GetString("order-by")
+	opts.Sort, _ = cmd.Flags().GetString("sort")
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := ListNotesAsJSON(projectID, target, opts)
+		if err != nil {
+			log.Fatalf("Failed to list notes: %v", err)
+		}
+		fmt.Println(output)
+		return
+	}
+
+	notes, err := ListNotes(projectID, target, opts)
+	if err != nil {
+		log.Fatalf("Failed to list notes: %v", err)
+	}
+
+	if len(notes) == 0 {
+		fmt.Printf("No notes on %s\n", target)
+		return
+	}
+	for _, note := range notes {
+		kind := ""
+		if note.System {
+			kind = " (system)"
+		}
+		fmt.Printf("Note %d by %s on %s%s\n", note.ID, note.Author, note.CreatedAt.Format("2006-01-02 15:04"), kind)
+		for _, line := range strings.Split(note.Body, "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+		fmt.Println()
+	}
+}
+
+func runAdd(cmd *cobra.Command, args []string) {
+	projectID, target := targetFromFlags(cmd)
+
+	note, err := AddNote(projectID, target, bodyFromFlags(cmd))
+	if err != nil {
+		log.Fatalf("Failed to add note: %v", err)
+	}
+
+	fmt.Printf("Added note %d to %s\n", note.ID, target)
+}
+
+func runEdit(cmd *cobra.Command, args []string) {
+	projectID, target := targetFromFlags(cmd)
+	noteID, _ := cmd.Flags().GetInt("note")
+
+	if _, err := EditNote(projectID, target, noteID, bodyFromFlags(cmd)); err != nil {
+		log.Fatalf("Failed to edit note: %v", err)
+	}
+
+	fmt.Printf("Updated note %d on %s\n", noteID, target)
+}
+
+func runDelete(cmd *cobra.Command, args []string) {
+	projectID, target := targetFromFlags(cmd)
+	noteID, _ := cmd.Flags().GetInt("note")
+
+	if err := DeleteNote(projectID, target, noteID); err != nil {
+		log.Fatalf("Failed to delete note: %v", err)
+	}
+
+	fmt.Printf("Deleted note %d from %s\n", noteID, target)
+}
@@ -0,0 +1,241 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"mpg-gitlab/cmd/types"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	// System note filters
+	systemExclude = "exclude"
+	systemInclude = "include"
+	systemOnly    = "only"
+)
+
+// Target identifies the merge request or issue notes belong to
+type Target struct {
+	MergeRequest int
+	Issue        int
+}
+
+// String describes the target, like "merge request !12" or "issue #34"
+func (t Target) String() string {
+	if t.MergeRequest != 0 {
+		return fmt.Sprintf("merge request !%d", t.MergeRequest)
+	}
+	return fmt.Sprintf("issue #%d", t.Issue)
+}
+
+// Validate checks that exactly one of merge request and issue is set
+func (t Target) Validate() error {
+	if (t.MergeRequest == 0) == (t.Issue == 0) {
+		return fmt.Errorf("exactly one of --mr or --issue is required")
+	}
+	return nil
+}
+
+// ListOptions controls which notes are listed and in what order
+type ListOptions struct {
+	System  string // exclude (default), include or only
+	OrderBy string // created_at or updated_at
+	Sort    string // asc or desc
+}
+
+// ListNotes returns the notes of a merge request or issue
+func ListNotes(projectID int, target Target, opts *ListOptions) ([]types.Note, error) {
+	if err := validateListOptions(opts); err != nil {
+		return nil, err
+	}
+
+	listOpts := gitlab.ListOptions{PerPage: 100}
+	var orderBy, sort *string
+	if opts.OrderBy != "" {
+		orderBy = gitlab.String(opts.OrderBy)
+	}
+	if opts.Sort != "" {
+		sort = gitlab.String(opts.Sort)
+	}
+
+	var notes []*gitlab.Note
+	for {
+		var page []*gitlab.Note
+		var resp *gitlab.Response
+		var err error
+		if target.MergeRequest != 0 {
+			page, resp, err = client.Notes.ListMergeRequestNotes(projectID, target.MergeRequest, &gitlab.ListMergeRequestNotesOptions{
+				ListOptions: listOpts, OrderBy: orderBy, Sort: sort,
+			})
+		} else {
+			page, resp, err = client.Notes.ListIssueNotes(projectID, target.Issue, &gitlab.ListIssueNotesOptions{
+				ListOptions: listOpts, OrderBy: orderBy, Sort: sort,
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list notes: %v", err)
+		}
+		notes = append(notes, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	return filterNotes(notes, opts.System), nil
+}
+
+// validateListOptions checks list options against the values GitLab accepts
+func validateListOptions(opts *ListOptions) error {
+	switch opts.System {
+	case "", systemExclude, systemInclude, systemOnly:
+	default:
+		return fmt.Errorf("invalid system filter %q, expected exclude, include or only", opts.System)
+	}
+	switch opts.OrderBy {
+	case "", "created_at", "updated_at":
+	default:
+		return fmt.Errorf("invalid order %q, expected created_at or updated_at", opts.OrderBy)
+	}
+	switch opts.Sort {
+	case "", "asc", "desc":
+	default:
+		return fmt.Errorf("invalid sort %q, expected asc or desc", opts.Sort)
+	}
+	return nil
+}
+
+// filterNotes applies the system note filter and converts notes to our type
+func filterNotes(notes []*gitlab.Note, system string) []types.Note {
+	result := []types.Note{}
+	for _, n := range notes {
+		switch {
+		case system == systemOnly && !n.System:
+			continue
+		case (system == "" || system == systemExclude) && n.System:
+			continue
+		}
+		result = append(result, *convertGitLabNote(n))
+	}
+	return result
+}
+
+// ListNotesAsJSON returns the notes of a merge request or issue as formatted JSON
+func ListNotesAsJSON(projectID int, target Target, opts *ListOptions) (string, error) {
+	notes, err := ListNotes(projectID, target, opts)
+	if err != nil {
+		return "", err
+	}
+
+	jsonData, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal notes: %v", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// AddNote adds a note to a merge request or issue
+func AddNote(projectID int, target Target, body string) (*types.Note, error) {
+	var note *gitlab.Note
+	var err error
+	if target.MergeRequest != 0 {
+		note, _, err = client.Notes.CreateMergeRequestNote(projectID, target.MergeRequest, &gitlab.CreateMergeRequestNoteOptions{
+			Body: gitlab.String(body),
+		})
+	} else {
+		note, _, err = client.Notes.CreateIssueNote(projectID, target.Issue, &gitlab.CreateIssueNoteOptions{
+			Body: gitlab.String(body),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add note: %v", err)
+	}
+
+	return convertGitLabNote(note), nil
+}
+
+// EditNote replaces the body of a note
+func EditNote(projectID int, target Target, noteID int, body string) (*types.Note, error) {
+	var note *gitlab.Note
+	var err error
+	if target.MergeRequest != 0 {
+		note, _, err = client.Notes.UpdateMergeRequestNote(projectID, target.MergeRequest, noteID, &gitlab.UpdateMergeRequestNoteOptions{
+			Body: gitlab.String(body),
+		})
+	} else {
+		note, _, err = client.Notes.UpdateIssueNote(projectID, target.Issue, noteID, &gitlab.UpdateIssueNoteOptions{
+			Body: gitlab.String(body),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit note: %v", err)
+	}
+
+	return convertGitLabNote(note), nil
+}
+
+// DeleteNote deletes a note
+func DeleteNote(projectID int, target Target, noteID int) error {
+	var err error
+	if target.MergeRequest != 0 {
+		_, err = client.Notes.DeleteMergeRequestNote(projectID, target.MergeRequest, noteID)
+	} else {
+		_, err = client.Notes.DeleteIssueNote(projectID, target.Issue, noteID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete note: %v", err)
+	}
+	return nil
+}
+
+// ReadBody returns the note body from the --body value or, if set, a file
+// A file named "-" reads the body from standard input
+func ReadBody(body, file string, stdin io.Reader) (string, error) {
+	if file == "" {
+		if strings.TrimSpace(body) == "" {
+			return "", fmt.Errorf("note body is empty: use --body or --body-file")
+		}
+		return body, nil
+	}
+
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read note body: %v", err)
+	}
+
+	content := strings.TrimRight(string(data), "\n")
+	if strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("note body is empty")
+	}
+	return content, nil
+}
+
+// Helper function to convert GitLab note to our type
+func convertGitLabNote(note *gitlab.Note) *types.Note {
+	result := &types.Note{
+		ID:     note.ID,
+		Body:   note.Body,
+		Author: note.Author.Username,
+		System: note.System,
+	}
+	if note.CreatedAt != nil {
+		result.CreatedAt = *note.CreatedAt
+	}
+	if note.UpdatedAt != nil {
+		result.UpdatedAt = *note.UpdatedAt
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestTargetValidate(t *testing.T) {
+	tests := []struct {
+		target  Target
+		wantErr bool
+	}{
+		{Target{MergeRequest: 12}, false},
+		{Target{Issue: 34}, false},
+		{Target{}, true},
+		{Target{MergeRequest: 12, Issue: 34}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.target.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() error = %v, wantErr %v", tt.target, err, tt.wantErr)
+		}
+	}
+
+	if got := (Target{MergeRequest: 12}).String(); got != "merge request !12" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (Target{Issue: 34}).String(); got != "issue #34" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestFilterNotes(t *testing.T) {
+	notes := []*gitlab.Note{
+		{ID: 1, Body: "Looks good"},
+		{ID: 2, Body: "added 1 commit", System: true},
+		{ID: 3, Body: "Thanks"},
+	}
+
+	ids := func(system string) []int {
+		var result []int
+		for _, n := range filterNotes(notes, system) {
+			result = append(result, n.ID)
+		}
+		return result
+	}
+
+	for system, want := range map[string][]int{
+		"":            {1, 3},
+		systemExclude: {1, 3},
+		systemInclude: {1, 2, 3},
+		systemOnly:    {2},
+	} {
+		if got := ids(system); !reflect.DeepEqual(got, want) {
+			t.Errorf("filterNotes(%q) = %v, want %v", system, got, want)
+		}
+	}
+
+	if err := validateListOptions(&ListOptions{System: "all"}); err == nil {
+		t.Error("validateListOptions() accepted an unknown system filter")
+	}
+	if err := validateListOptions(&ListOptions{Sort: "up"}); err == nil {
+		t.Error("validateListOptions() accepted an unknown sort")
+	}
+}
+
+func TestReadBody(t *testing.T) {
+	if got, err := ReadBody("Hello", "", nil); err != nil || got != "Hello" {
+		t.Errorf("ReadBody() = %q, %v", got, err)
+	}
+	if _, err := ReadBody("  ", "", nil); err == nil {
+		t.Error("ReadBody() accepted an empty body")
+	}
+
+	got, err := ReadBody("", "-", strings.NewReader("From stdin\n"))
+	if err != nil || got != "From stdin" {
+		t.Errorf("ReadBody() from stdin = %q, %v", got, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "note.md")
+	if err := os.WriteFile(file, []byte("## Review\n\nLooks good\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ReadBody("", file, nil)
+	if err != nil || got != "## Review\n\nLooks good" {
+		t.Errorf("ReadBody() from file = %q, %v", got, err)
+	}
+
+	if _, err := ReadBody("", filepath.Join(t.TempDir(), "missing.md"), nil); err == nil {
+		t.Error("ReadBody() accepted a missing file")
+	}
+}
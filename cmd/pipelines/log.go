@@ -0,0 +1,110 @@
+package pipelines
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// followInterval is how often a running job's trace is polled
+var followInterval = 3 * time.Second
+
+// JobLog returns the trace of a job
+func JobLog(projectID, jobID int) (string, error) {
+	trace, _, err := client.Jobs.GetTraceFile(projectID, jobID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get job log: %v", err)
+	}
+
+	data, err := io.ReadAll(trace)
+	if err != nil {
+		return "", fmt.Errorf("failed to read job log: %v", err)
+	}
+	return string(data), nil
+}
+
+// TailLines returns the last n lines of a log, or all of it when n is not positive
+func TailLines(log string, n int) string {
+	if n <= 0 {
+		return log
+	}
+
+	trimmed := strings.TrimSuffix(log, "\n")
+	lines := strings.Split(trimmed, "\n")
+	if len(lines) <= n {
+		return log
+	}
+	return strings.Join(lines[len(lines)-n:], "\n") + log[len(trimmed):]
+}
+
+// StreamJobLog writes a job's trace to w, starting with its last tail lines
+// With follow set it keeps polling and writing new output until the job finishes,
+// and returns the final job status; without it the status is left empty.
+func StreamJobLog(projectID, jobID, tail int, follow bool, w io.Writer) (string, error) {
+	log, err := JobLog(projectID, jobID)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(w, TailLines(log, tail))
+	if !follow {
+		return "", nil
+	}
+	offset := len(log)
+
+	// Job logs are polled at a steady pace rather than backing off, and without a timeout
+	status := ""
+	err = utils.Wait(utils.WaitOptions{Interval: followInterval, MaxInterval: followInterval}, func() (bool, string, error) {
+		job, _, err := client.Jobs.GetJob(projectID, jobID)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to get job: %v", err)
+		}
+		status = job.Status
+
+		// Read the trace once more after the job finished, so the end isn't lost
+		if offset, err = writeNewOutput(projectID, jobID, offset, w); err != nil {
+			return false, "", err
+		}
+		return IsJobFinished(status), status, nil
+	})
+	return status, err
+}
+
+// writeNewOutput writes the part of a job trace past offset and returns the new offset
+// Only the bytes past offset are requested with a Range header. Servers that ignore
+// it send the whole trace, which costs a full download on every poll.
+func writeNewOutput(projectID, jobID, offset int, w io.Writer) (int, error) {
+	trace, resp, err := client.Jobs.GetTraceFile(projectID, jobID, gitlab.WithHeader("Range", fmt.Sprintf("bytes=%d-", offset)))
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return offset, nil // Nothing new yet
+		}
+		return offset, fmt.Errorf("failed to get job log: %v", err)
+	}
+	data, err := io.ReadAll(trace)
+	if err != nil {
+		return offset, fmt.Errorf("failed to read job log: %v", err)
+	}
+
+	output, next := newOutput(string(data), offset, resp.StatusCode == http.StatusPartialContent)
+	fmt.Fprint(w, output)
+	return next, nil
+}
+
+// newOutput returns the output past offset in a trace response and the new offset
+// partial is whether the response holds only the requested range rather than the full trace.
+func newOutput(data string, offset int, partial bool) (string, int) {
+	if partial {
+		return data, offset + len(data)
+	}
+	if len(data) < offset {
+		// The trace was erased or the job restarted
+		offset = 0
+	}
+	return data[offset:], len(data)
+}
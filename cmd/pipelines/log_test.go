@@ -0,0 +1,87 @@
+package pipelines
+
+import "testing"
+
+func TestTailLines(t *testing.T) {
+	log := "one\ntwo\nthree\nfour\n"
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, log},
+		{2, "three\nfour\n"},
+		{4, log},
+		{10, log},
+	}
+	for _, tt := range tests {
+		if got := TailLines(log, tt.n); got != tt.want {
+			t.Errorf("TailLines(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+
+	// A trace cut off mid-line keeps its partial last line
+	if got := TailLines("a\nb\nc", 1); got != "c" {
+		t.Errorf("TailLines() without trailing newline = %q, want %q", got, "c")
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[float64]string{
+		0:     "-",
+		42:    "42s",
+		205:   "3m25s",
+		3725:  "1h2m5s",
+		12.75: "12s",
+	}
+	for seconds, want := range tests {
+		if got := FormatDuration(seconds); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", seconds, got, want)
+		}
+	}
+}
+
+func TestIsFinished(t *testing.T) {
+	for _, status := range []string{"success", "failed", "canceled", "skipped"} {
+		if !IsFinished(status) {
+			t.Errorf("IsFinished(%q) = false", status)
+		}
+	}
+	for _, status := range []string{"created", "pending", "running", "waiting_for_resource", "manual"} {
+		if IsFinished(status) {
+			t.Errorf("IsFinished(%q) = true", status)
+		}
+	}
+}
+
+func TestIsJobFinished(t *testing.T) {
+	if !IsJobFinished("manual") || !IsJobFinished("failed") {
+		t.Error("IsJobFinished() should treat manual and failed jobs as finished")
+	}
+	if IsJobFinished("running") {
+		t.Error("IsJobFinished(\"running\") = true")
+	}
+}
+
+func TestNewOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		offset     int
+		partial    bool
+		wantOutput string
+		wantNext   int
+	}{
+		{name: "range honored", data: "line 3\n", offset: 14, partial: true, wantOutput: "line 3\n", wantNext: 21},
+		{name: "full trace", data: "line 1\nline 2\nline 3\n", offset: 14, wantOutput: "line 3\n", wantNext: 21},
+		{name: "no new output", data: "line 1\n", offset: 7, wantOutput: "", wantNext: 7},
+		{name: "trace restarted", data: "new\n", offset: 14, wantOutput: "new\n", wantNext: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, next := newOutput(tt.data, tt.offset, tt.partial)
+			if output != tt.wantOutput || next != tt.wantNext {
+				t.Errorf("newOutput() = %q, %d, want %q, %d", output, next, tt.wantOutput, tt.wantNext)
+			}
+		})
+	}
+}
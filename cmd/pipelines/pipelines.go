@@ -0,0 +1,212 @@
+package pipelines
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+var (
+	client *gitlab.Client
+	// Command groups
+	PipelineCmd = &cobra.Command{
+		Use:   "pipeline",
+		Short: "Inspect and manage GitLab CI pipelines",
+	}
+
+	JobCmd = &cobra.Command{
+		Use:   "job",
+		Short: "Inspect GitLab CI jobs",
+	}
+
+	getCmd = &cobra.Command{
+		Use:   "get",
+		Short: "Get pipeline details",
+		Run:   runGet,
+	}
+
+	jobsCmd = &cobra.Command{
+		Use:   "jobs",
+		Short: "List the jobs of a pipeline",
+		Run:   runJobs,
+	}
+
+	retryCmd = &cobra.Command{
+		Use:   "retry",
+		Short: "Retry the failed jobs of a pipeline",
+		Run:   runRetry,
+	}
+
+	cancelCmd = &cobra.Command{
+		Use:   "cancel",
+		Short: "Cancel a running pipeline",
+		Run:   runCancel,
+	}
+
+	logCmd = &cobra.Command{
+		Use:   "log",
+		Short: "Print the log of a job",
+		Run:   runLog,
+	}
+)
+
+func init() {
+	client = utils.GetClient()
+
+	// Add subcommands
+	PipelineCmd.AddCommand(getCmd, jobsCmd, retryCmd, cancelCmd)
+	JobCmd.AddCommand(logCmd)
+
+	// Pipeline selection flags shared by the pipeline subcommands
+	for _, c := range []*cobra.Command{getCmd, jobsCmd, retryCmd, cancelCmd} {
+		c.Flags().IntP("project", "p", 0, "Project ID")
+		c.Flags().Int("pipeline", 0, "Pipeline ID")
+		c.Flags().IntP("mr", "m", 0, "Use the head pipeline of this merge request")
+		c.MarkFlagsMutuallyExclusive("pipeline", "mr")
+	}
+
+	// Get flags
+	getCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+
+	// Jobs flags
+	jobsCmd.Flags().StringSlice("scope", nil, "Only list jobs with these statuses (e.g. failed,running)")
+	jobsCmd.Flags().Bool("include-retried", false, "Include jobs that were retried")
+	jobsCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+
+	// Log flags
+	logCmd.Flags().IntP("project", "p", 0, "Project ID")
+	logCmd.Flags().Int("job", 0, "Job ID")
+	logCmd.Flags().IntP("tail", "n", 0, "Only print the last n lines")
+	logCmd.Flags().BoolP("follow", "f", false, "Keep printing new output until the job finishes")
+	logCmd.MarkFlagRequired("job")
+}
+
+// pipelineFromFlags returns the project and pipeline selected by the flags
+func pipelineFromFlags(cmd *cobra.Command) (int, int) {
+	projectID, _ := utils.GetProjectID(cmd)
+	if projectID == 0 {
+		log.Fatal("Project ID is required. Provide --project flag or run in GitLab CI")
+	}
+
+	pipelineID, _ := cmd.Flags().GetInt("pipeline")
+	mrIID, _ := cmd.Flags().GetInt("mr")
+	switch {
+	case pipelineID != 0:
+		return projectID, pipelineID
+	case mrIID != 0:
+		id, err := HeadPipelineID(projectID, mrIID)
+		if err != nil {
+			log.Fatalf("Failed to find pipeline: %v", err)
+		}
+		return projectID, id
+	default:
+		log.Fatal("Either --pipeline or --mr is required")
+	}
+	return 0, 0
+}
+
+func runGet(cmd *cobra.Command, args []string) {
+	projectID, pipelineID := pipelineFromFlags(cmd)
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := GetPipelineAsJSON(projectID, pipelineID)
+		if err != nil {
+			log.Fatalf("Failed to get pipeline: %v", err)
+		}
+		fmt.Println(output)
+		return
+	}
+
+	pipeline, err := GetPipeline(projectID, pipelineID)
+	if err != nil {
+		log.Fatalf("Failed to get pipeline: %v", err)
+	}
+
+	fmt.Printf("Pipeline #%d\n", pipeline.ID)
+	fmt.Printf("Status: %s\n", pipeline.Status)
+	fmt.Printf("Ref: %s\n", pipeline.Ref)
+	fmt.Printf("SHA: %s\n", pipeline.SHA)
+	fmt.Printf("Source: %s\n", pipeline.Source)
+	fmt.Printf("Duration: %s\n", FormatDuration(float64(pipeline.Duration)))
+	fmt.Printf("URL: %s\n", pipeline.WebURL)
+}
+
+func runJobs(cmd *cobra.Command, args []string) {
+	projectID, pipelineID := pipelineFromFlags(cmd)
+	scope, _ := cmd.Flags().GetStringSlice("scope")
+	includeRetried, _ := cmd.Flags().GetBool("include-retried")
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		output, err := ListJobsAsJSON(projectID, pipelineID, scope, includeRetried)
+		if err != nil {
+			log.Fatalf("Failed to list jobs: %v", err)
+		}
+		fmt.Println(output)
+		return
+	}
+
+	jobs, err := ListJobs(projectID, pipelineID, scope, includeRetried)
+	if err != nil {
+		log.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs) == 0 {
+		fmt.Printf("No jobs in pipeline #%d\n", pipelineID)
+		return
+	}
+
+	for _, j := range jobs {
+		status := j.Status
+		if j.Status == "failed" && j.AllowFailure {
+			status = "failed (allowed)"
+		}
+		fmt.Printf("%-10d %-12s %-30s %-18s %s\n", j.ID, j.Stage, j.Name, status, FormatDuration(j.Duration))
+		if j.FailureReason != "" {
+			fmt.Printf("%11s reason: %s\n", "", j.FailureReason)
+		}
+	}
+}
+
+func runRetry(cmd *cobra.Command, args []string) {
+	projectID, pipelineID := pipelineFromFlags(cmd)
+
+	pipeline, err := RetryPipeline(projectID, pipelineID)
+	if err != nil {
+		log.Fatalf("Failed to retry pipeline: %v", err)
+	}
+	fmt.Printf("Retried pipeline #%d, now %s\n", pipeline.ID, pipeline.Status)
+}
+
+func runCancel(cmd *cobra.Command, args []string) {
+	projectID, pipelineID := pipelineFromFlags(cmd)
+
+	pipeline, err := CancelPipeline(projectID, pipelineID)
+	if err != nil {
+		log.Fatalf("Failed to cancel pipeline: %v", err)
+	}
+	fmt.Printf("Canceled pipeline #%d, now %s\n", pipeline.ID, pipeline.Status)
+}
+
+func runLog(cmd *cobra.Command, args []string) {
+	projectID, _ := utils.GetProjectID(cmd)
+	if projectID == 0 {
+		log.Fatal("Project ID is required. Provide --project flag or run in GitLab CI")
+	}
+	jobID, _ := cmd.Flags().GetInt("job")
+	tail, _ := cmd.Flags().GetInt("tail")
+	follow, _ := cmd.Flags().GetBool("follow")
+
+	status, err := StreamJobLog(projectID, jobID, tail, follow, os.Stdout)
+	if err != nil {
+		log.Fatalf("Failed to read job log: %v", err)
+	}
+
+	// A followed job that failed fails the command, so scripts can wait on it
+	if follow && status != "success" {
+		log.Fatalf("Job %d finished with status %s", jobID, status)
+	}
+}
@@ -0,0 +1,248 @@
+package pipelines
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"mpg-gitlab/cmd/types"
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// GetPipeline returns a pipeline by ID
+func GetPipeline(projectID, pipelineID int) (*types.Pipeline, error) {
+	pipeline, _, err := client.Pipelines.GetPipeline(projectID, pipelineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pipeline: %v", err)
+	}
+	return ConvertPipeline(pipeline), nil
+}
+
+// GetPipelineAsJSON returns a pipeline as formatted JSON
+func GetPipelineAsJSON(projectID, pipelineID int) (string, error) {
+	pipeline, err := GetPipeline(projectID, pipelineID)
+	if err != nil {
+		return "", err
+	}
+
+	jsonData, err := json.MarshalIndent(pipeline, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal pipeline: %v", err)
+	}
+	return string(jsonData), nil
+}
+
+// HeadPipelineID returns the ID of the latest pipeline on the head commit of a merge request
+func HeadPipelineID(projectID, mrIID int) (int, error) {
+	mr, _, err := client.MergeRequests.GetMergeRequest(projectID, mrIID, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get merge request: %v", err)
+	}
+	pipeline, err := MergeRequestHeadPipeline(projectID, mr)
+	if err != nil {
+		return 0, err
+	}
+	if pipeline == nil {
+		return 0, fmt.Errorf("no pipeline ran on the head of merge request !%d yet", mrIID)
+	}
+	return pipeline.ID, nil
+}
+
+// MergeRequestHeadPipeline returns the latest pipeline that ran on the head commit of a merge request
+// It falls back to the MR's pipeline list when GitLab reports no head pipeline, or
+// one for an older commit, and returns nil when no pipeline ran on the head yet.
+func MergeRequestHeadPipeline(projectID int, mr *gitlab.MergeRequest) (*types.Pipeline, error) {
+	return MergeRequestPipelineOn(projectID, mr, mr.SHA)
+}
+
+// MergeRequestPipelineOn returns the latest pipeline of a merge request that ran on a commit,
+// or nil when none did yet
+func MergeRequestPipelineOn(projectID int, mr *gitlab.MergeRequest, sha string) (*types.Pipeline, error) {
+	if p := mr.HeadPipeline; p != nil && p.SHA == sha {
+		return ConvertPipeline(p), nil
+	}
+
+	var head *gitlab.PipelineInfo
+	err := WalkMergeRequestPipelines(projectID, mr.IID, func(info *gitlab.PipelineInfo) bool {
+		if info.SHA == sha {
+			head = info
+		}
+		return head == nil
+	})
+	if err != nil || head == nil {
+		return nil, err
+	}
+	return GetPipeline(projectID, head.ID)
+}
+
+// WalkMergeRequestPipelines calls visit with the pipelines of a merge request, newest
+// first, until visit returns false or every page was read
+// The client's call has no paging options, so the requests are built by hand.
+func WalkMergeRequestPipelines(projectID, mrIID int, visit func(*gitlab.PipelineInfo) bool) error {
+	u := fmt.Sprintf("projects/%d/merge_requests/%d/pipelines", projectID, mrIID)
+	opts := &gitlab.ListOptions{PerPage: 100}
+	for {
+		req, err := client.NewRequest(http.MethodGet, u, opts, nil)
+		if err != nil {
+			return fmt.Errorf("failed to list pipelines: %v", err)
+		}
+		var page []*gitlab.PipelineInfo
+		resp, err := client.Do(req, &page)
+		if err != nil {
+			return fmt.Errorf("failed to list pipelines: %v", err)
+		}
+		for _, info := range page {
+			if !visit(info) {
+				return nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListJobs returns the jobs of a pipeline, optionally limited to some statuses
+// Retried jobs are left out unless includeRetried is set.
+func ListJobs(projectID, pipelineID int, scope []string, includeRetried bool) ([]types.Job, error) {
+	opts := &gitlab.ListJobsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	if len(scope) > 0 {
+		states := make([]gitlab.BuildStateValue, len(scope))
+		for i, s := range scope {
+			states[i] = gitlab.BuildStateValue(s)
+		}
+		opts.Scope = &states
+	}
+	if includeRetried {
+		opts.IncludeRetried = gitlab.Bool(true)
+	}
+
+	jobs := []types.Job{}
+	for {
+		page, resp, err := client.Jobs.ListPipelineJobs(projectID, pipelineID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %v", err)
+		}
+		for _, j := range page {
+			jobs = append(jobs, *convertGitLabJob(j))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	// GitLab lists the most recent jobs first; show them in pipeline order
+	for i, j := 0, len(jobs)-1; i < j; i, j = i+1, j-1 {
+		jobs[i], jobs[j] = jobs[j], jobs[i]
+	}
+	return jobs, nil
+}
+
+// ListJobsAsJSON returns the jobs of a pipeline as formatted JSON
+func ListJobsAsJSON(projectID, pipelineID int, scope []string, includeRetried bool) (string, error) {
+	jobs, err := ListJobs(projectID, pipelineID, scope, includeRetried)
+	if err != nil {
+		return "", err
+	}
+
+	jsonData, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal jobs: %v", err)
+	}
+	return string(jsonData), nil
+}
+
+// RetryPipeline retries the failed and canceled jobs of a pipeline
+func RetryPipeline(projectID, pipelineID int) (*types.Pipeline, error) {
+	pipeline, _, err := client.Pipelines.RetryPipelineBuild(projectID, pipelineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry pipeline: %v", err)
+	}
+	return ConvertPipeline(pipeline), nil
+}
+
+// CancelPipeline cancels the running jobs of a pipeline
+func CancelPipeline(projectID, pipelineID int) (*types.Pipeline, error) {
+	pipeline, _, err := client.Pipelines.CancelPipelineBuild(projectID, pipelineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel pipeline: %v", err)
+	}
+	return ConvertPipeline(pipeline), nil
+}
+
+// WaitPipeline waits until a pipeline has finished and returns its final state
+func WaitPipeline(projectID, pipelineID int, opts utils.WaitOptions) (*types.Pipeline, error) {
+	var pipeline *gitlab.Pipeline
+	err := utils.Wait(opts, func() (bool, string, error) {
+		var err error
+		pipeline, _, err = client.Pipelines.GetPipeline(projectID, pipelineID)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to get pipeline: %v", err)
+		}
+		return IsFinished(pipeline.Status), fmt.Sprintf("pipeline #%d %s", pipeline.ID, pipeline.Status), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pipeline did not finish: %w", err)
+	}
+	return ConvertPipeline(pipeline), nil
+}
+
+// IsFinished reports whether a pipeline status is final
+// A manual pipeline is blocked on a manual job and goes on once someone starts it.
+func IsFinished(status string) bool {
+	switch status {
+	case "success", "failed", "canceled", "skipped":
+		return true
+	}
+	return false
+}
+
+// IsJobFinished reports whether a job status is final
+// A manual job doesn't run until someone starts it, so there is nothing to wait for.
+func IsJobFinished(status string) bool {
+	return IsFinished(status) || status == "manual"
+}
+
+// FormatDuration renders a run time in seconds like "3m25s", or "-" before a run starts
+func FormatDuration(seconds float64) string {
+	if seconds <= 0 {
+		return "-"
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// ConvertPipeline converts a GitLab pipeline to our type
+func ConvertPipeline(p *gitlab.Pipeline) *types.Pipeline {
+	return &types.Pipeline{
+		ID:         p.ID,
+		Status:     p.Status,
+		Ref:        p.Ref,
+		SHA:        p.SHA,
+		Source:     p.Source,
+		Duration:   p.Duration,
+		CreatedAt:  p.CreatedAt,
+		FinishedAt: p.FinishedAt,
+		WebURL:     p.WebURL,
+	}
+}
+
+// Helper function to convert GitLab job to our type
+func convertGitLabJob(j *gitlab.Job) *types.Job {
+	return &types.Job{
+		ID:            j.ID,
+		Name:          j.Name,
+		Stage:         j.Stage,
+		Status:        j.Status,
+		AllowFailure:  j.AllowFailure,
+		FailureReason: j.FailureReason,
+		Duration:      j.Duration,
+		WebURL:        j.WebURL,
+	}
+}
@@ -0,0 +1,39 @@
+package pipelines
+
+import (
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestMergeRequestHeadPipelineUsesMatchingHead(t *testing.T) {
+	mr := &gitlab.MergeRequest{
+		IID:          1,
+		SHA:          "abc123",
+		HeadPipeline: &gitlab.Pipeline{ID: 7, SHA: "abc123", Status: "success", Duration: 95},
+	}
+
+	pipeline, err := MergeRequestHeadPipeline(1, mr)
+	if err != nil {
+		t.Fatalf("MergeRequestHeadPipeline() error = %v", err)
+	}
+	if pipeline == nil || pipeline.ID != 7 || pipeline.Status != "success" || pipeline.Duration != 95 {
+		t.Errorf("MergeRequestHeadPipeline() = %+v", pipeline)
+	}
+}
+
+func TestMergeRequestPipelineOnPinnedCommit(t *testing.T) {
+	mr := &gitlab.MergeRequest{
+		IID:          1,
+		SHA:          "def456",
+		HeadPipeline: &gitlab.Pipeline{ID: 8, SHA: "abc123", Status: "running"},
+	}
+
+	pipeline, err := MergeRequestPipelineOn(1, mr, "abc123")
+	if err != nil {
+		t.Fatalf("MergeRequestPipelineOn() error = %v", err)
+	}
+	if pipeline == nil || pipeline.ID != 8 {
+		t.Errorf("MergeRequestPipelineOn() = %+v, want pipeline #8", pipeline)
+	}
+}
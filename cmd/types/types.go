@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"mpg-gitlab/cmd/utils"
+
+	"github.com/xanzy/go-gitlab"
 )
 
 // Issue represents a GitLab issue with its core attributes
@@ -36,6 +38,26 @@ type MergeRequest struct {
 	WebURL       string     `json:"web_url"`             // Web URL to the MR
 	MergeStatus  string     `json:"merge_status"`        // Current merge status
 	HasConflicts bool       `json:"has_conflicts"`       // Whether MR has conflicts
+	Approvals    *Approvals `json:"approvals,omitempty"` // Approval state, when requested
+}
+
+// Approvals represents the approval state of a merge request
+type Approvals struct {
+	Approved   bool           `json:"approved"`    // Whether all required approvals are given
+	Required   int            `json:"required"`    // Number of approvals required
+	Left       int            `json:"left"`        // Number of approvals still needed
+	ApprovedBy []string       `json:"approved_by"` // Usernames of the approvers
+	Rules      []ApprovalRule `json:"rules"`       // Approval rules, if the instance supports them
+}
+
+// ApprovalRule represents a merge request approval rule
+type ApprovalRule struct {
+	Name              string   `json:"name"`               // Rule name
+	Type              string   `json:"type"`               // Rule type (regular/code_owner/report_approver/any_approver)
+	Required          int      `json:"required"`           // Number of approvals required by the rule
+	Approved          bool     `json:"approved"`           // Whether the rule is satisfied
+	ApprovedBy        []string `json:"approved_by"`        // Usernames that approved under this rule
+	EligibleApprovers []string `json:"eligible_approvers"` // Usernames allowed to approve under this rule
 }
 
 // Milestone represents a GitLab milestone with its core attributes
@@ -50,6 +72,45 @@ type Milestone struct {
 	DueDate     *time.Time `json:"due_date,omitempty"`   // Due date, if set
 	StartDate   *time.Time `json:"start_date,omitempty"` // Start date, if set
 	WebURL      string     `json:"web_url"`              // Web URL to the milestone
+	GroupID     int        `json:"group_id,omitempty"`   // Owning group, for group milestones
+}
+
+// Note represents a comment on a GitLab issue or merge request
+// It maps to the GitLab API note object but includes only the fields we need
+type Note struct {
+	ID        int       `json:"id"`         // ID of the note
+	Body      string    `json:"body"`       // Note content
+	Author    string    `json:"author"`     // Username of the author
+	System    bool      `json:"system"`     // Whether GitLab generated the note
+	CreatedAt time.Time `json:"created_at"` // Creation timestamp
+	UpdatedAt time.Time `json:"updated_at"` // Last update timestamp
+}
+
+// Pipeline represents a GitLab CI pipeline with its core attributes
+// It maps to the GitLab API pipeline object but includes only the fields we need
+type Pipeline struct {
+	ID         int        `json:"id"`                    // ID of the pipeline
+	Status     string     `json:"status"`                // Current status (running/success/failed/...)
+	Ref        string     `json:"ref"`                   // Branch or tag the pipeline ran for
+	SHA        string     `json:"sha"`                   // Commit the pipeline ran on
+	Source     string     `json:"source"`                // What triggered the pipeline (push/merge_request_event/...)
+	Duration   int        `json:"duration"`              // Run time in seconds
+	CreatedAt  *time.Time `json:"created_at,omitempty"`  // Creation timestamp
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // Completion timestamp, if finished
+	WebURL     string     `json:"web_url"`               // Web URL to the pipeline
+}
+
+// Job represents a job of a GitLab CI pipeline
+// It maps to the GitLab API job object but includes only the fields we need
+type Job struct {
+	ID            int     `json:"id"`                       // ID of the job
+	Name          string  `json:"name"`                     // Job name
+	Stage         string  `json:"stage"`                    // Stage the job belongs to
+	Status        string  `json:"status"`                   // Current status (running/success/failed/...)
+	AllowFailure  bool    `json:"allow_failure"`            // Whether a failure keeps the pipeline green
+	FailureReason string  `json:"failure_reason,omitempty"` // Why the job failed, if it did
+	Duration      float64 `json:"duration"`                 // Run time in seconds
+	WebURL        string  `json:"web_url"`                  // Web URL to the job
 }
 
 // GetLinkedIssueIIDs returns the IIDs of issues referenced in the MR description
@@ -75,3 +136,25 @@ func (i *Issue) GetDescription() string {
 	}
 	return i.Description
 }
+
+// FromGroupMilestone converts a group milestone so group and project milestones are handled alike
+func FromGroupMilestone(m *gitlab.GroupMilestone) *gitlab.Milestone {
+	return &gitlab.Milestone{
+		ID:          m.ID,
+		IID:         m.IID,
+		GroupID:     m.GroupID,
+		Title:       m.Title,
+		Description: m.Description,
+		StartDate:   m.StartDate,
+		DueDate:     m.DueDate,
+		State:       m.State,
+		UpdatedAt:   m.UpdatedAt,
+		CreatedAt:   m.CreatedAt,
+		Expired:     m.Expired,
+	}
+}
+
+// TruncateDay drops the time of day, keeping the calendar date
+func TruncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -2,6 +2,9 @@ package types
 
 import (
 	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
 )
 
 // TestMergeRequest_GetDescription tests the GetDescription method of MergeRequest
@@ -118,3 +121,18 @@ func TestMergeRequest_GetLinkedIssueIIDs(t *testing.T) {
 		})
 	}
 }
+
+func TestFromGroupMilestone(t *testing.T) {
+	due := gitlab.ISOTime(time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC))
+	m := FromGroupMilestone(&gitlab.GroupMilestone{ID: 5, IID: 2, GroupID: 7, Title: "Sprint 42", State: "active", DueDate: &due})
+	if m.ID != 5 || m.GroupID != 7 || m.Title != "Sprint 42" || m.State != "active" || m.DueDate != &due {
+		t.Errorf("FromGroupMilestone() = %+v", m)
+	}
+}
+
+func TestTruncateDay(t *testing.T) {
+	got := TruncateDay(time.Date(2026, 10, 19, 17, 45, 0, 0, time.UTC))
+	if !got.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("TruncateDay() = %v", got)
+	}
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// ReadConfigFile reads a configuration file from the working directory or, failing
+// that, from the project repository at ref. It reports whether the file was found.
+func ReadConfigFile(projectID int, path, ref string) ([]byte, bool, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		return data, true, nil
+	}
+	return ReadRepositoryFile(projectID, path, ref)
+}
+
+// ReadRepositoryFile reads a file from the project repository at ref, reporting whether it exists
+func ReadRepositoryFile(projectID int, path, ref string) ([]byte, bool, error) {
+	opts := &gitlab.GetRawFileOptions{}
+	if ref != "" {
+		opts.Ref = gitlab.String(ref)
+	}
+	data, resp, err := client.RepositoryFiles.GetRawFile(projectID, path, opts)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get %s: %v", path, err)
+	}
+	return data, true, nil
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runGit runs a git command in the current directory and returns its trimmed output
+func runGit(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CurrentBranch returns the name of the branch checked out in the local repository
+func CurrentBranch() (string, error) {
+	branch, err := runGit("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if branch == "HEAD" {
+		return "", fmt.Errorf("not on a branch (detached HEAD)")
+	}
+	return branch, nil
+}
+
+// CommitMessages returns the full messages of the commits on HEAD that are not on base,
+// oldest first. The remote-tracking branch origin/<base> is preferred when it exists.
+func CommitMessages(base string) ([]string, error) {
+	ref := base
+	if _, err := runGit("rev-parse", "--verify", "--quiet", "origin/"+base); err == nil {
+		ref = "origin/" + base
+	}
+
+	out, err := runGit("log", "--reverse", "--format=%B%x00", ref+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []string
+	for _, message := range strings.Split(out, "\x00") {
+		if message = strings.TrimSpace(message); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
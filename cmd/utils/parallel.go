@@ -0,0 +1,26 @@
+package utils
+
+import "sync"
+
+// ForEachConcurrently calls fn for every index below n, with at most limit calls running at once
+// It returns once all calls are done. A limit below 1 runs the calls one at a time.
+func ForEachConcurrently(n, limit int, fn func(i int)) {
+	if limit < 1 {
+		limit = 1
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
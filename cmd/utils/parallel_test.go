@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachConcurrently(t *testing.T) {
+	var running, peak int32
+	var mu sync.Mutex
+	done := map[int]bool{}
+
+	ForEachConcurrently(20, 3, func(i int) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		mu.Lock()
+		done[i] = true
+		mu.Unlock()
+	})
+
+	if len(done) != 20 {
+		t.Errorf("ran %d calls, want 20", len(done))
+	}
+	if peak > 3 {
+		t.Errorf("%d calls ran at once, want at most 3", peak)
+	}
+}
+
+func TestForEachConcurrentlyWithoutLimit(t *testing.T) {
+	var order []int
+	ForEachConcurrently(3, 0, func(i int) { order = append(order, i) })
+	if len(order) != 3 || order[0] != 0 || order[2] != 2 {
+		t.Errorf("calls ran as %v, want one at a time in order", order)
+	}
+}
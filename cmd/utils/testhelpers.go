@@ -28,4 +28,19 @@ func CreateMockNote(id int, body string) *gitlab.Note {
 		ID:   id,
 		Body: body,
 	}
-} 
\ No newline at end of file
+}
+
+// CreateMockDiscussion creates a mock discussion thread for testing
+// Each body becomes a resolvable note; the thread is resolved if resolved is true
+func CreateMockDiscussion(id string, resolved bool, bodies ...string) *gitlab.Discussion {
+	discussion := &gitlab.Discussion{ID: id}
+	for i, body := range bodies {
+		discussion.Notes = append(discussion.Notes, &gitlab.Note{
+			ID:         i + 1,
+			Body:       body,
+			Resolvable: true,
+			Resolved:   resolved,
+		})
+	}
+	return discussion
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	if got := UnifiedDiff("a", "b", "same\n", "same\n"); got != "" {
+		t.Errorf("UnifiedDiff() of equal texts = %q", got)
+	}
+
+	from := "## Changelog\n\n### [Fix]\n- [Fix] Crash (#1)\n"
+	to := "## Changelog\n\n### [Feature]\n- [Feature] Dark mode (#2)\n\n### [Fix]\n- [Fix] Crash (#1)\n"
+	want := strings.Join([]string{
+		"--- current",
+		"+++ updated",
+		"@@ -1,4 +1,7 @@",
+		" ## Changelog",
+		" ",
+		"+### [Feature]",
+		"+- [Feature] Dark mode (#2)",
+		"+",
+		" ### [Fix]",
+		" - [Fix] Crash (#1)",
+		"",
+	}, "\n")
+	if got := UnifiedDiff("current", "updated", from, to); got != want {
+		t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffHunks(t *testing.T) {
+	var from, to []string
+	for i := 1; i <= 20; i++ {
+		line := strings.Repeat("x", i)
+		from = append(from, line)
+		switch i {
+		case 2:
+			to = append(to, "changed")
+		case 18:
+			// Removed
+		default:
+			to = append(to, line)
+		}
+	}
+
+	got := UnifiedDiff("a", "b", strings.Join(from, "\n"), strings.Join(to, "\n"))
+	if n := strings.Count(got, "@@ -"); n != 2 {
+		t.Fatalf("UnifiedDiff() has %d hunks, want 2:\n%s", n, got)
+	}
+	for _, want := range []string{"@@ -1,5 +1,5 @@", "-xx\n+changed", "@@ -15,6 +15,5 @@", "-" + strings.Repeat("x", 18) + "\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("UnifiedDiff() is missing %q:\n%s", want, got)
+		}
+	}
+
+	// Adding to an empty text
+	if got := UnifiedDiff("a", "b", "", "new\n"); !strings.Contains(got, "@@ -0,0 +1,1 @@\n+new\n") {
+		t.Errorf("UnifiedDiff() from an empty text =\n%s", got)
+	}
+}
+
+func TestDescriptionPreview(t *testing.T) {
+	got := DescriptionPreview("## Changelog", "")
+	if want := "Updated description:\n## Changelog\n\nNo changes to the current description\n"; got != want {
+		t.Errorf("DescriptionPreview() = %q, want %q", got, want)
+	}
+
+	got = DescriptionPreview("new\n", "--- a\n+++ b\n")
+	if want := "Updated description:\nnew\n\nDiff:\n--- a\n+++ b\n"; got != want {
+		t.Errorf("DescriptionPreview() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// ResolveUserID looks up a GitLab user by username and returns its ID
+// A leading "@" is accepted so handles can be passed as they appear in GitLab
+func ResolveUserID(username string) (int, error) {
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+	if username == "" {
+		return 0, fmt.Errorf("empty username")
+	}
+
+	users, _, err := client.Users.ListUsers(&gitlab.ListUsersOptions{
+		Username: gitlab.String(username),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up user %q: %v", username, err)
+	}
+
+	for _, user := range users {
+		if strings.EqualFold(user.Username, username) {
+			return user.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("user %q not found", username)
+}
+
+// ResolveUserIDs resolves a list of usernames to user IDs
+// It fails on the first username that does not exist
+func ResolveUserIDs(usernames []string) ([]int, error) {
+	var ids []int
+	for _, username := range usernames {
+		id, err := ResolveUserID(username)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// SplitList splits a comma-separated flag value into trimmed, non-empty items
+func SplitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// DefaultPollInterval is the first delay between polls of asynchronous GitLab state
+	DefaultPollInterval = 2 * time.Second
+	// DefaultMaxPollInterval caps the backoff between polls
+	DefaultMaxPollInterval = 30 * time.Second
+)
+
+// ErrWaitTimeout is wrapped by the error Wait returns when the timeout passes
+var ErrWaitTimeout = errors.New("timed out")
+
+// Clock functions, replaced in tests
+var (
+	now   = time.Now
+	sleep = time.Sleep
+)
+
+// WaitOptions controls how Wait polls
+type WaitOptions struct {
+	Timeout     time.Duration // Give up after this long; zero waits indefinitely
+	Interval    time.Duration // First delay between polls, doubled while nothing changes
+	MaxInterval time.Duration // Upper bound for the delay between polls
+	Progress    io.Writer     // Receives a line whenever the status changes, if set
+}
+
+// PollFunc checks asynchronous state once
+// It reports whether waiting is over and a short status for progress output.
+type PollFunc func() (done bool, status string, err error)
+
+// Wait calls poll until it reports done or fails, backing off between polls
+// The backoff resets whenever the status changes, since the state is moving then.
+// When the timeout passes first, the returned error wraps ErrWaitTimeout.
+func Wait(opts WaitOptions, poll PollFunc) error {
+	base := opts.Interval
+	if base <= 0 {
+		base = DefaultPollInterval
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = DefaultMaxPollInterval
+	}
+	if maxInterval < base {
+		maxInterval = base
+	}
+
+	start := now()
+	interval := base
+	last := ""
+	for {
+		done, status, err := poll()
+		if err != nil {
+			return err
+		}
+		elapsed := now().Sub(start)
+
+		if status != last {
+			if opts.Progress != nil && status != "" {
+				fmt.Fprintf(opts.Progress, "  %s (%s)\n", status, elapsed.Round(time.Second))
+			}
+			last, interval = status, base
+		}
+		if done {
+			return nil
+		}
+
+		if opts.Timeout > 0 {
+			remaining := opts.Timeout - elapsed
+			if remaining <= 0 {
+				return fmt.Errorf("%w after %s: %s", ErrWaitTimeout, opts.Timeout, last)
+			}
+			if interval > remaining {
+				interval = remaining
+			}
+		}
+		sleep(interval)
+
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
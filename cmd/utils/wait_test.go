@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock replaces the clock functions used by Wait for the duration of a test
+func fakeClock(t *testing.T) *[]time.Duration {
+	current := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+	now = func() time.Time { return current }
+	sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		current = current.Add(d)
+	}
+	t.Cleanup(func() { now, sleep = time.Now, time.Sleep })
+	return &sleeps
+}
+
+func TestWaitBacksOffUntilDone(t *testing.T) {
+	sleeps := fakeClock(t)
+
+	statuses := []string{"pending", "pending", "pending", "running", "running", "success"}
+	polls := 0
+	var progress strings.Builder
+	err := Wait(WaitOptions{Interval: time.Second, MaxInterval: 3 * time.Second, Progress: &progress}, func() (bool, string, error) {
+		status := statuses[polls]
+		polls++
+		return status == "success", status, nil
+	})
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	// Delays double while the status stays the same and reset once it changes
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, time.Second, 2 * time.Second}
+	if len(*sleeps) != len(want) {
+		t.Fatalf("Wait() slept %v, want %v", *sleeps, want)
+	}
+	for i := range want {
+		if (*sleeps)[i] != want[i] {
+			t.Errorf("Wait() slept %v, want %v", *sleeps, want)
+			break
+		}
+	}
+
+	wantProgress := "  pending (0s)\n  running (6s)\n  success (9s)\n"
+	if progress.String() != wantProgress {
+		t.Errorf("Wait() progress = %q, want %q", progress.String(), wantProgress)
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	sleeps := fakeClock(t)
+
+	err := Wait(WaitOptions{Timeout: 10 * time.Second, Interval: 4 * time.Second, MaxInterval: 4 * time.Second}, func() (bool, string, error) {
+		return false, "running", nil
+	})
+	if !errors.Is(err, ErrWaitTimeout) {
+		t.Fatalf("Wait() error = %v, want a timeout", err)
+	}
+	if !strings.Contains(err.Error(), "running") {
+		t.Errorf("Wait() error = %q, want the last status", err)
+	}
+
+	// The last delay is cut short so the timeout isn't overshot
+	var total time.Duration
+	for _, d := range *sleeps {
+		total += d
+	}
+	if total != 10*time.Second {
+		t.Errorf("Wait() slept %v in total, want 10s", total)
+	}
+}
+
+func TestWaitStopsOnError(t *testing.T) {
+	fakeClock(t)
+
+	polls := 0
+	err := Wait(WaitOptions{}, func() (bool, string, error) {
+		polls++
+		if polls == 2 {
+			return false, "", errors.New("boom")
+		}
+		return false, "pending", nil
+	})
+	if err == nil || err.Error() != "boom" || polls != 2 {
+		t.Errorf("Wait() = %v after %d polls", err, polls)
+	}
+}
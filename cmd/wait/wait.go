@@ -0,0 +1,161 @@
+package wait
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"mpg-gitlab/cmd/mergerequests"
+	"mpg-gitlab/cmd/pipelines"
+	"mpg-gitlab/cmd/utils"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Exit codes of the wait commands
+	exitFailed  = 1 // The state settled, but not as hoped, or the API failed
+	exitTimeout = 2 // The timeout passed before the state settled
+)
+
+var (
+	// Command groups
+	WaitCmd = &cobra.Command{
+		Use:   "wait",
+		Short: "Wait for asynchronous GitLab state to settle",
+	}
+
+	mrMergeableCmd = &cobra.Command{
+		Use:   "mr-mergeable",
+		Short: "Wait until GitLab has checked a merge request can be merged",
+		Run:   runMRMergeable,
+	}
+
+	pipelineCmd = &cobra.Command{
+		Use:   "pipeline",
+		Short: "Wait until a pipeline has finished",
+		Run:   runPipeline,
+	}
+
+	rebaseCmd = &cobra.Command{
+		Use:   "rebase",
+		Short: "Wait until a merge request rebase has finished",
+		Run:   runRebase,
+	}
+)
+
+func init() {
+	// Add subcommands
+	WaitCmd.AddCommand(mrMergeableCmd, pipelineCmd, rebaseCmd)
+
+	// Polling flags shared by every subcommand
+	for _, c := range []*cobra.Command{mrMergeableCmd, pipelineCmd, rebaseCmd} {
+		c.Flags().IntP("project", "p", 0, "Project ID")
+		c.Flags().Duration("timeout", 30*time.Minute, "Maximum time to wait (0 waits indefinitely)")
+		c.Flags().Duration("interval", utils.DefaultPollInterval, "First delay between polls")
+		c.Flags().Duration("max-interval", utils.DefaultMaxPollInterval, "Maximum delay between polls")
+		c.Flags().BoolP("quiet", "q", false, "Don't report progress")
+	}
+
+	// MR mergeable flags
+	mrMergeableCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	mrMergeableCmd.MarkFlagRequired("mr")
+
+	// Pipeline flags
+	pipelineCmd.Flags().Int("pipeline", 0, "Pipeline ID")
+	pipelineCmd.Flags().IntP("mr", "m", 0, "Wait for a pipeline on the head of this merge request")
+	pipelineCmd.MarkFlagsMutuallyExclusive("pipeline", "mr")
+
+	// Rebase flags
+	rebaseCmd.Flags().IntP("mr", "m", 0, "Merge request IID")
+	rebaseCmd.Flags().Bool("start", false, "Start a rebase first instead of waiting for a running one")
+	rebaseCmd.MarkFlagRequired("mr")
+}
+
+// optionsFromFlags returns the project and polling options selected by the flags
+func optionsFromFlags(cmd *cobra.Command) (int, utils.WaitOptions) {
+	projectID, _ := utils.GetProjectID(cmd)
+	if projectID == 0 {
+		log.Fatal("Project ID is required. Provide --project flag or run in GitLab CI")
+	}
+
+	opts := utils.WaitOptions{Progress: os.Stdout}
+	opts.Timeout, _ = cmd.Flags().GetDuration("timeout")
+	opts.Interval, _ = cmd.Flags().GetDuration("interval")
+	opts.MaxInterval, _ = cmd.Flags().GetDuration("max-interval")
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		opts.Progress = nil
+	}
+	return projectID, opts
+}
+
+// exit reports a failed wait and exits with the matching exit code
+func exit(err error) {
+	log.Print(err)
+	if errors.Is(err, utils.ErrWaitTimeout) {
+		os.Exit(exitTimeout)
+	}
+	os.Exit(exitFailed)
+}
+
+func runMRMergeable(cmd *cobra.Command, args []string) {
+	projectID, opts := optionsFromFlags(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+
+	mr, err := mergerequests.WaitMergeStatus(projectID, mrIID, opts)
+	if err != nil {
+		exit(err)
+	}
+	if mr.MergeStatus != "can_be_merged" {
+		exit(fmt.Errorf("merge request !%d can't be merged: %s", mrIID, mr.MergeStatus))
+	}
+	fmt.Printf("Merge request !%d can be merged\n", mrIID)
+}
+
+func runPipeline(cmd *cobra.Command, args []string) {
+	projectID, opts := optionsFromFlags(cmd)
+	pipelineID, _ := cmd.Flags().GetInt("pipeline")
+	mrIID, _ := cmd.Flags().GetInt("mr")
+
+	var id int
+	var status string
+	switch {
+	case pipelineID != 0:
+		pipeline, err := pipelines.WaitPipeline(projectID, pipelineID, opts)
+		if err != nil {
+			exit(err)
+		}
+		id, status = pipeline.ID, pipeline.Status
+	case mrIID != 0:
+		pipeline, err := mergerequests.WaitHeadPipeline(projectID, mrIID, "", opts)
+		if err != nil {
+			exit(err)
+		}
+		id, status = pipeline.ID, pipeline.Status
+	default:
+		log.Fatal("Either --pipeline or --mr is required")
+	}
+
+	if status != "success" {
+		exit(fmt.Errorf("pipeline #%d finished with status %s", id, status))
+	}
+	fmt.Printf("Pipeline #%d succeeded\n", id)
+}
+
+func runRebase(cmd *cobra.Command, args []string) {
+	projectID, opts := optionsFromFlags(cmd)
+	mrIID, _ := cmd.Flags().GetInt("mr")
+	start, _ := cmd.Flags().GetBool("start")
+
+	wait := mergerequests.WaitRebase
+	if start {
+		wait = mergerequests.RebaseAndWait
+	}
+	mr, err := wait(projectID, mrIID, opts)
+	if err != nil {
+		exit(err)
+	}
+	fmt.Printf("Merge request !%d rebased, head is now %s\n", mrIID, mr.SHA)
+}
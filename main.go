@@ -7,6 +7,9 @@ import (
 	"mpg-gitlab/cmd/issues"
 	"mpg-gitlab/cmd/mergerequests"
 	"mpg-gitlab/cmd/milestones"
+	"mpg-gitlab/cmd/notes"
+	"mpg-gitlab/cmd/pipelines"
+	"mpg-gitlab/cmd/wait"
 
 	"github.com/spf13/cobra"
 )
@@ -27,7 +30,14 @@ func init() {
 		mergerequests.MergeRequestsCmd,
 		issues.IssuesCmd,
 		milestones.MilestonesCmd,
+		notes.NotesCmd,
+		pipelines.PipelineCmd,
+		pipelines.JobCmd,
+		wait.WaitCmd,
 	)
+
+	// mr add-changelog writes milestone changelogs, which the milestones package renders
+	mergerequests.MergeRequestsCmd.AddCommand(milestones.MergeRequestAddChangelogCmd)
 }
 
 func main() {